@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const depPackagePath = "goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+
+// constructor annotated by the comment is generated even if it is not found in registration code
+const generateAnnotation = "//dep:generate"
+
+// constructor of component whose typed factory method is generated
+type constructor struct {
+	name       string
+	paramTypes []string
+	returnsErr bool
+	// import path by package name used in parameter and output types
+	imports map[string]string
+}
+
+type Generator struct {
+	fset         *token.FileSet
+	packageName  string
+	funcs        map[string]*ast.FuncDecl
+	funcImports  map[string]map[string]string
+	referenced   map[string]bool
+	constructors []*constructor
+	skipped      []string
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		fset:        token.NewFileSet(),
+		funcs:       make(map[string]*ast.FuncDecl),
+		funcImports: make(map[string]map[string]string),
+		referenced:  make(map[string]bool),
+	}
+}
+
+// parse go files of the package in the directory, test files and the output file are skipped
+func (g *Generator) ParseDir(dir string, output string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || filepath.Base(file) == filepath.Base(output) {
+			continue
+		}
+		if err := g.ParseFile(file, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parse go source, src is read from the file if nil
+func (g *Generator) ParseFile(filename string, src any) error {
+	file, err := parser.ParseFile(g.fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	if g.packageName == "" {
+		g.packageName = file.Name.Name
+	} else if g.packageName != file.Name.Name {
+		return fmt.Errorf("multiple packages found in the directory: %s, %s", g.packageName, file.Name.Name)
+	}
+
+	imports := getImports(file)
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if funcDecl.Recv == nil {
+			g.funcs[funcDecl.Name.Name] = funcDecl
+			g.funcImports[funcDecl.Name.Name] = imports
+			if isAnnotated(funcDecl) {
+				g.referenced[funcDecl.Name.Name] = true
+			}
+		}
+	}
+	// constructors passed to registration calls, e.g. dep.RegisterSingleton[Component](components, NewComponent)
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || !isRegistrationCall(call) {
+			return true
+		}
+		for _, arg := range call.Args {
+			if ident, ok := arg.(*ast.Ident); ok {
+				g.referenced[ident.Name] = true
+			}
+		}
+		return true
+	})
+	return nil
+}
+
+func getImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = importPath
+	}
+	return imports
+}
+
+func isAnnotated(funcDecl *ast.FuncDecl) bool {
+	if funcDecl.Doc == nil {
+		return false
+	}
+	for _, comment := range funcDecl.Doc.List {
+		if strings.TrimSpace(comment.Text) == generateAnnotation {
+			return true
+		}
+	}
+	return false
+}
+
+// Register* of dep and hosting packages, and Add*Impl of component hub
+func isRegistrationCall(call *ast.CallExpr) bool {
+	fun := call.Fun
+	switch expr := fun.(type) {
+	case *ast.IndexExpr:
+		fun = expr.X
+	case *ast.IndexListExpr:
+		fun = expr.X
+	}
+	var name string
+	switch expr := fun.(type) {
+	case *ast.Ident:
+		name = expr.Name
+	case *ast.SelectorExpr:
+		name = expr.Sel.Name
+	default:
+		return false
+	}
+	return strings.HasPrefix(name, "Register") || strings.HasPrefix(name, "Add") && strings.HasSuffix(name, "Impl")
+}
+
+// collect constructors referenced by registration code or annotation, unsupported ones are skipped with reason
+func (g *Generator) Collect() {
+	names := make([]string, 0, len(g.referenced))
+	for name := range g.referenced {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		funcDecl, exist := g.funcs[name]
+		if !exist {
+			continue
+		}
+		ctor, reason := g.newConstructor(funcDecl)
+		if ctor == nil {
+			g.skipped = append(g.skipped, fmt.Sprintf("%s: %s", name, reason))
+			continue
+		}
+		g.constructors = append(g.constructors, ctor)
+	}
+}
+
+func (g *Generator) Skipped() []string {
+	return g.skipped
+}
+
+func (g *Generator) newConstructor(funcDecl *ast.FuncDecl) (*constructor, string) {
+	funcType := funcDecl.Type
+	if funcType.TypeParams != nil && len(funcType.TypeParams.List) > 0 {
+		return nil, "generic func is not supported"
+	}
+	results := expandFields(funcType.Results)
+	if len(results) == 0 || len(results) > 2 {
+		return nil, "should return component, or component and error"
+	}
+	if len(results) == 2 && g.exprString(results[1]) != "error" {
+		return nil, "the second output should be error"
+	}
+
+	ctor := &constructor{
+		name:       funcDecl.Name.Name,
+		returnsErr: len(results) == 2,
+		imports:    make(map[string]string),
+	}
+	fileImports := g.funcImports[ctor.name]
+	for _, param := range expandFields(funcType.Params) {
+		if _, ok := param.(*ast.Ellipsis); ok {
+			return nil, "variadic func is not supported"
+		}
+		if err := collectImports(param, fileImports, ctor.imports); err != nil {
+			return nil, err.Error()
+		}
+		ctor.paramTypes = append(ctor.paramTypes, g.exprString(param))
+	}
+	return ctor, ""
+}
+
+// one type expression for each parameter or output, e.g. (a, b int) is expanded to int, int
+func expandFields(fields *ast.FieldList) []ast.Expr {
+	exprs := make([]ast.Expr, 0)
+	if fields == nil {
+		return exprs
+	}
+	for _, field := range fields.List {
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			exprs = append(exprs, field.Type)
+		}
+	}
+	return exprs
+}
+
+func collectImports(expr ast.Expr, fileImports map[string]string, used map[string]string) error {
+	var err error
+	ast.Inspect(expr, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if ident, ok := selector.X.(*ast.Ident); ok {
+			importPath, exist := fileImports[ident.Name]
+			if !exist {
+				err = fmt.Errorf("package %s is not imported", ident.Name)
+				return false
+			}
+			used[ident.Name] = importPath
+		}
+		return false
+	})
+	return err
+}
+
+func (g *Generator) exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = format.Node(&buf, g.fset, expr)
+	return buf.String()
+}
+
+// generate go source registering typed factory methods of collected constructors
+func (g *Generator) Generate() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by depgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n", g.packageName)
+	if len(g.constructors) == 0 {
+		return format.Source(buf.Bytes())
+	}
+
+	imports := map[string]string{"dep": depPackagePath}
+	for _, ctor := range g.constructors {
+		for name, importPath := range ctor.imports {
+			if exist, ok := imports[name]; ok && exist != importPath {
+				return nil, fmt.Errorf("package name %s is imported as both %s and %s", name, exist, importPath)
+			}
+			imports[name] = importPath
+		}
+	}
+
+	buf.WriteString("\n")
+	buf.WriteString(formatImports(imports))
+	buf.WriteString("\nfunc init() {\n")
+	for _, ctor := range g.constructors {
+		args := make([]string, 0, len(ctor.paramTypes))
+		for index, paramType := range ctor.paramTypes {
+			args = append(args, fmt.Sprintf("dep.Arg[%s](args, %d)", paramType, index))
+		}
+		call := fmt.Sprintf("%s(%s)", ctor.name, strings.Join(args, ", "))
+		fmt.Fprintf(&buf, "\tdep.RegisterGeneratedFactory(%s, func(args []any) (any, error) {\n", ctor.name)
+		if ctor.returnsErr {
+			fmt.Fprintf(&buf, "\t\treturn %s\n", call)
+		} else {
+			fmt.Fprintf(&buf, "\t\treturn %s, nil\n", call)
+		}
+		buf.WriteString("\t})\n")
+	}
+	buf.WriteString("}\n")
+	return format.Source(buf.Bytes())
+}
+
+func formatImports(imports map[string]string) string {
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return imports[names[i]] < imports[names[j]]
+	})
+	var sb strings.Builder
+	sb.WriteString("import (\n")
+	for _, name := range names {
+		importPath := imports[name]
+		if path.Base(importPath) == name {
+			fmt.Fprintf(&sb, "\t%q\n", importPath)
+		} else {
+			fmt.Fprintf(&sb, "\t%s %q\n", name, importPath)
+		}
+	}
+	sb.WriteString(")\n")
+	return sb.String()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const registrationSource = `package sample
+
+import (
+	"net/http"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+)
+
+type Component interface{}
+type component struct{}
+
+func NewComponent(context dep.Context, client *http.Client) *component {
+	return &component{}
+}
+
+func NewFailable(context dep.Context) (*component, error) {
+	return &component{}, nil
+}
+
+//dep:generate
+func NewAnnotated() *component {
+	return &component{}
+}
+
+func NewVariadic(names ...string) *component {
+	return &component{}
+}
+
+func NewGeneric[T any](value T) *component {
+	return &component{}
+}
+
+func NewUnreferenced() *component {
+	return &component{}
+}
+
+func ConfigureComponents(components dep.ComponentCollection) {
+	dep.RegisterSingleton[Component](components, NewComponent)
+	dep.RegisterTransient[Component](components, NewFailable)
+	dep.RegisterTransient[Component](components, NewVariadic)
+	dep.RegisterTransient[Component](components, NewGeneric[int])
+}
+`
+
+func generate(t *testing.T, src string) (string, *Generator) {
+	g := NewGenerator()
+	if err := g.ParseFile("sample.go", src); err != nil {
+		t.Fatalf("failed to parse source: %v", err)
+	}
+	g.Collect()
+	output, err := g.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate source: %v", err)
+	}
+	return string(output), g
+}
+
+func TestGenerate(t *testing.T) {
+	output, g := generate(t, registrationSource)
+
+	for _, expected := range []string{
+		"package sample",
+		`"net/http"`,
+		`"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"`,
+		"return NewComponent(dep.Arg[dep.Context](args, 0), dep.Arg[*http.Client](args, 1)), nil",
+		"return NewFailable(dep.Arg[dep.Context](args, 0))\n",
+		"return NewAnnotated(), nil",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("generated source should contain: %s, actual:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "NewUnreferenced") {
+		t.Errorf("constructor not registered or annotated should not be generated")
+	}
+
+	skipped := strings.Join(g.Skipped(), "\n")
+	if !strings.Contains(skipped, "NewVariadic: variadic func is not supported") {
+		t.Errorf("variadic constructor should be skipped, actual: %s", skipped)
+	}
+	if strings.Contains(output, "NewVariadic") || strings.Contains(output, "NewGeneric") {
+		t.Errorf("unsupported constructors should not be generated")
+	}
+}
+
+func TestGenerate_empty(t *testing.T) {
+	output, _ := generate(t, "package sample\n")
+	if strings.Contains(output, "import") || !strings.Contains(output, "package sample") {
+		t.Errorf("only package clause should be generated without constructors, actual:\n%s", output)
+	}
+}
+
+func TestParseFile_multiple_packages(t *testing.T) {
+	g := NewGenerator()
+	_ = g.ParseFile("a.go", "package a\n")
+	if err := g.ParseFile("b.go", "package b\n"); err == nil || !strings.Contains(err.Error(), "multiple packages") {
+		t.Errorf("multiple packages should be rejected, actual: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+// depgen generates typed factory methods of component constructors, so that components are created
+// by calling constructors directly instead of reflect.Value.Call, e.g.
+//
+//	//go:generate go run goms.io/azureml/mir/mir-vmagent/cmd/depgen
+//
+// constructors passed to Register* calls or Add*Impl calls of component hub in the package are generated,
+// as well as constructors annotated by //dep:generate comment.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the package to generate for")
+	output := flag.String("output", "zz_generated_factories.go", "name of the generated file in the directory")
+	flag.Parse()
+
+	if err := run(*dir, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "depgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, output string) error {
+	generator := NewGenerator()
+	if err := generator.ParseDir(dir, output); err != nil {
+		return err
+	}
+	generator.Collect()
+	for _, skipped := range generator.Skipped() {
+		fmt.Fprintf(os.Stderr, "depgen: skipped %s\n", skipped)
+	}
+
+	src, err := generator.Generate()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, output), src, 0644)
+}
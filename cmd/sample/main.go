@@ -1,5 +1,7 @@
 package main
 
+//go:generate go run ../depgen
+
 func main() {
 	host := ConfigureHost().Build()
 
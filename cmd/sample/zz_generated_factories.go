@@ -0,0 +1,36 @@
+// Code generated by depgen. DO NOT EDIT.
+
+package main
+
+import (
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/hosting"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
+)
+
+func init() {
+	dep.RegisterGeneratedFactory(NewBlobDownloader, func(args []any) (any, error) {
+		return NewBlobDownloader(dep.Arg[dep.Properties](args, 0)), nil
+	})
+	dep.RegisterGeneratedFactory(NewComponent, func(args []any) (any, error) {
+		return NewComponent(dep.Arg[dep.Context](args, 0), dep.Arg[*Configuration](args, 1), dep.Arg[hosting.Host](args, 2)), nil
+	})
+	dep.RegisterGeneratedFactory(NewHandleComp, func(args []any) (any, error) {
+		return NewHandleComp(dep.Arg[dep.Context](args, 0), dep.Arg[logger.Logger](args, 1), dep.Arg[ScopedComp](args, 2)), nil
+	})
+	dep.RegisterGeneratedFactory(NewHandler, func(args []any) (any, error) {
+		return NewHandler(dep.Arg[dep.Context](args, 0)), nil
+	})
+	dep.RegisterGeneratedFactory(NewLoopIteration, func(args []any) (any, error) {
+		return NewLoopIteration(dep.Arg[dep.Context](args, 0)), nil
+	})
+	dep.RegisterGeneratedFactory(NewMyProcessor, func(args []any) (any, error) {
+		return NewMyProcessor(dep.Arg[dep.Context](args, 0)), nil
+	})
+	dep.RegisterGeneratedFactory(NewScopedComp, func(args []any) (any, error) {
+		return NewScopedComp(dep.Arg[dep.Context](args, 0), dep.Arg[logger.Logger](args, 1)), nil
+	})
+	dep.RegisterGeneratedFactory(NewUrlDownloader, func(args []any) (any, error) {
+		return NewUrlDownloader(), nil
+	})
+}
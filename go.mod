@@ -5,6 +5,7 @@ go 1.18
 require (
 	go.uber.org/zap v1.18.1
 	golang.org/x/sys v0.0.0-20220114195835-da31bd327af9
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
@@ -12,7 +13,6 @@ require (
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/goleak v1.1.12 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
 
 replace (
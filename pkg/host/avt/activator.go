@@ -1,6 +1,8 @@
 package avt
 
 import (
+	"fmt"
+
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
@@ -15,11 +17,41 @@ type Activator interface {
 func GetComponent[T any](avt Activator) T {
 	return dep.GetComponent[T](avt.GetProvider())
 }
+func TryGetComponent[T any](avt Activator) (T, error) {
+	return dep.TryGetComponent[T](avt.GetProvider())
+}
 
 func CreateActivator(configureComponents ConfigureComponentsMethod) Activator {
 	return buildActivator(true, "", nil, configureComponents, nil, nil)
 }
-func CreateActivatorEx(debug bool, name string, globalProps dep.Properties, configureComponents ConfigureComponentsMethod, loggerFactory logger.LoggerFactory) Activator {
+// test helper, overrides are applied after base configuration and replace existing registrations,
+// e.g. to swap real dependency for fake without rebuilding the whole configure function
+func CreateActivatorWithOverrides(configureComponents ConfigureComponentsMethod, overrides ...ConfigureComponentsMethod) Activator {
+	return CreateActivator(WithOverrides(configureComponents, overrides...))
+}
+
+// combine base configure function with overrides which are registered in replace mode
+func WithOverrides(configureComponents ConfigureComponentsMethod, overrides ...ConfigureComponentsMethod) ConfigureComponentsMethod {
+	return func(context BuilderContext, components dep.ComponentCollection) {
+		if configureComponents != nil {
+			configureComponents(context, components)
+		}
+		replacing := dep.Replace(components)
+		for _, override := range overrides {
+			override(context, replacing)
+		}
+	}
+}
+
+// override registration of T with the instance, e.g. a fake
+func OverrideInstance[T any](instance T) ConfigureComponentsMethod {
+	return func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterInstance[T](components, instance)
+	}
+}
+
+// optional configureOptions are applied after default options, e.g. to turn on ValidateOnBuild
+func CreateActivatorEx(debug bool, name string, globalProps dep.Properties, configureComponents ConfigureComponentsMethod, loggerFactory logger.LoggerFactory, configureOptions ...ConfigureComponentProviderMethod) Activator {
 	var configLoggerFactory ConfigureLoggerFactoryMethod
 	if loggerFactory != nil {
 		configLoggerFactory = func(context BuilderContext, factoryBuilder LoggerFactoryBuilder) {
@@ -32,6 +64,9 @@ func CreateActivatorEx(debug bool, name string, globalProps dep.Properties, conf
 		options.EnableSingletonConcurrency = true
 		options.TrackTransientRecurrence = false
 		options.EnableDiagnostics = debug
+		for _, configure := range configureOptions {
+			configure(context, options)
+		}
 	}
 	return buildActivator(debug, name, globalProps, configureComponents, configLoggerFactory, configCompProvider)
 }
@@ -55,11 +90,64 @@ func buildActivator(debug bool, name string, globalProps dep.Properties, configu
 		activator.configureComponents(configureComponents)
 	}
 
+	activator.validateComponents()
+
 	activator.Logger.Debugw("configured components complete", "count", activator.getRegisteredCount())
 
 	return activator
 }
 
+// child activator owns its registrations, configurations and singletons, and falls back to components of the parent,
+// e.g. each tenant has its own component set
+type ChildActivator interface {
+	Activator
+
+	// dispose singletons of the child activator, the parent is not affected
+	Dispose() error
+}
+
+// implemented by activators which are able to create child activator
+type childActivatorFactory interface {
+	createChild(props dep.Properties, configureComponents ConfigureComponentsMethod) ChildActivator
+}
+
+// parent is either root activator or child activator
+func CreateChildActivator(parent Activator, props dep.Properties, configureComponents ConfigureComponentsMethod) ChildActivator {
+	factory, ok := parent.(childActivatorFactory)
+	if !ok {
+		panic(fmt.Errorf("activator type %s is not able to create child activator", types.Of(parent).FullName()))
+	}
+	return factory.createChild(props, configureComponents)
+}
+
+type DefaultChildActivator struct {
+	builderContext *HostBuilderContext
+	container      dep.ChildContainer
+}
+
+func newChildActivator(builderContext *HostBuilderContext, createContainer func(configure dep.ConfigureChildComponentsMethod) dep.ChildContainer, configureComponents ConfigureComponentsMethod) *DefaultChildActivator {
+	return &DefaultChildActivator{
+		builderContext: builderContext,
+		container: createContainer(func(components dep.ComponentCollection) {
+			if configureComponents != nil {
+				configureComponents(NewBuilderContext(builderContext), components)
+			}
+		}),
+	}
+}
+
+func (ca *DefaultChildActivator) createChild(props dep.Properties, configureComponents ConfigureComponentsMethod) ChildActivator {
+	return newChildActivator(ca.builderContext, func(configure dep.ConfigureChildComponentsMethod) dep.ChildContainer {
+		return ca.container.CreateChild(props, configure)
+	}, configureComponents)
+}
+func (ca *DefaultChildActivator) GetProvider() dep.ComponentProviderEx {
+	return ca.container
+}
+func (ca *DefaultChildActivator) Dispose() error {
+	return ca.container.Dispose()
+}
+
 type DefaultActivator struct {
 	hostContext *DefaultHostContext
 	LogFactory  logger.LoggerFactory
@@ -80,6 +168,18 @@ func (da *DefaultActivator) configureComponents(configure ConfigureComponentsMet
 	builderContext := NewBuilderContext(da.hostContext.builderContext)
 	configure(builderContext, da.hostContext.ComponentCollection)
 }
+func (da *DefaultActivator) validateComponents() {
+	compMgr := da.hostContext.ComponentManager
+	if !compMgr.GetOptions().ValidateOnBuild {
+		return
+	}
+
+	err := compMgr.Validate()
+	if err != nil {
+		da.Logger.Errorw("validate registered components failed", "error", err)
+		panic(err)
+	}
+}
 func (da *DefaultActivator) getContext() dep.HostContextEx {
 	return da.hostContext
 }
@@ -87,6 +187,13 @@ func (da *DefaultActivator) getRegisteredCount() int {
 	return da.hostContext.ComponentCollection.Count()
 }
 
+func (da *DefaultActivator) createChild(props dep.Properties, configureComponents ConfigureComponentsMethod) ChildActivator {
+	hostCtxt := da.hostContext
+	return newChildActivator(hostCtxt.builderContext, func(configure dep.ConfigureChildComponentsMethod) dep.ChildContainer {
+		return dep.NewChildContainer(hostCtxt.ComponentManager, hostCtxt.GetGlobalScope(), props, configure)
+	}, configureComponents)
+}
+
 // public APIs
 func (da *DefaultActivator) GetProvider() dep.ComponentProviderEx {
 	return da.hostContext
@@ -1,6 +1,7 @@
 package avt
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -87,6 +88,28 @@ func Test_Activator_comp_not_registered(t *testing.T) {
 	ano.Another()
 }
 
+func Test_Activator_validate_on_build(t *testing.T) {
+	defer test.AssertPanicContent(t, "dependency avt.FirstInterface is not registered", "panic content is not expected")
+
+	registerComponents := func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterTransient[AnotherInterface](components, func(first FirstInterface) *AnotherStruct { return nil })
+	}
+	validateOnBuild := func(context BuilderContext, options *dep.ComponentProviderOptions) {
+		options.ValidateOnBuild = true
+	}
+	_ = CreateActivatorEx(false, "UnitTest", nil, registerComponents, nil, validateOnBuild)
+}
+
+func Test_Activator_try_comp_not_registered(t *testing.T) {
+	avt := prepareActivator(nil)
+
+	ano, err := TryGetComponent[AnotherInterface](avt)
+	var notRegistered *dep.ComponentNotRegisteredError
+	if !errors.As(err, &notRegistered) || ano != nil {
+		t.Errorf("not registered error expected, actual: %v", err)
+	}
+}
+
 func Test_Activator_sys_component(t *testing.T) {
 	avt := prepareActivator(nil)
 
@@ -310,6 +333,55 @@ func Test_Activator_scopectxt(t *testing.T) {
 }
 
 // target types for test
+func Test_Activator_overrides(t *testing.T) {
+	registerComponents := func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterSingleton[AnotherInterface](components, NewAnotherStruct)
+		dep.RegisterTransient[FirstInterface](components, NewActualStruct)
+	}
+	fake := &AnotherStruct{value: 100}
+	avt := CreateActivatorWithOverrides(
+		registerComponents,
+		OverrideInstance[AnotherInterface](fake),
+		func(context BuilderContext, components dep.ComponentCollection) {
+			dep.RegisterTransient[SecondInterface](components, NewActualStruct)
+		},
+	)
+
+	if GetComponent[AnotherInterface](avt) != fake {
+		t.Errorf("registration should be overridden by fake")
+	}
+	if GetComponent[FirstInterface](avt) == nil || GetComponent[SecondInterface](avt) == nil {
+		t.Errorf("components of base configuration and overrides should be registered")
+	}
+}
+
+func Test_Activator_child(t *testing.T) {
+	avt := prepareActivator(func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterSingleton[AnotherInterface](components, NewAnotherStruct)
+		dep.RegisterSingleton[FirstInterface](components, NewActualStruct)
+	})
+	child := CreateChildActivator(avt, nil, func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterSingleton[FirstInterface](components, func(another AnotherInterface) *ActualStruct {
+			return &ActualStruct{value: 2}
+		})
+	})
+	grandChild := CreateChildActivator(child, nil, nil)
+
+	if GetComponent[AnotherInterface](child) != GetComponent[AnotherInterface](avt) {
+		t.Errorf("component not registered in child activator should be resolved from the parent")
+	}
+	first := GetComponent[FirstInterface](grandChild)
+	if first.(*ActualStruct).value != 2 || first != GetComponent[FirstInterface](child) {
+		t.Errorf("component should be resolved from the nearest ancestor which registers it")
+	}
+	if GetComponent[FirstInterface](avt).(*ActualStruct).value != 1 {
+		t.Errorf("parent should not be affected by child activator")
+	}
+	if err := child.Dispose(); err != nil {
+		t.Errorf("unexpected dispose error: %v", err)
+	}
+}
+
 type FirstInterface interface {
 	First()
 }
@@ -0,0 +1,64 @@
+package clock
+
+import "time"
+
+// source of time for loops and timers, replaced by fake clock in tests
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Until(t time.Time) time.Duration
+	NewTimer(d time.Duration) Timer
+	Sleep(d time.Duration)
+}
+
+// same semantics as time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// clock backed by package time
+type SystemClock struct{}
+
+func NewSystemClock() Clock {
+	return SystemClock{}
+}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+func (SystemClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+func (SystemClock) Until(t time.Time) time.Duration {
+	return time.Until(t)
+}
+func (SystemClock) NewTimer(d time.Duration) Timer {
+	return &systemTimer{timer: time.NewTimer(d)}
+}
+func (SystemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (st *systemTimer) C() <-chan time.Time {
+	return st.timer.C
+}
+func (st *systemTimer) Stop() bool {
+	return st.timer.Stop()
+}
+func (st *systemTimer) Reset(d time.Duration) bool {
+	return st.timer.Reset(d)
+}
+
+// return the system clock if nil
+func OrSystem(clk Clock) Clock {
+	if clk == nil {
+		return NewSystemClock()
+	}
+	return clk
+}
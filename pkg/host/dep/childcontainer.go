@@ -0,0 +1,81 @@
+package dep
+
+import (
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+type ConfigureChildComponentsMethod func(components ComponentCollection)
+
+// child container owns its registrations, configurations and singletons, types not registered in it are resolved from the parent,
+// e.g. each tenant has its own component set which falls back to shared host components
+type ChildContainer interface {
+	ComponentProviderEx
+
+	GetComponentManager() ComponentManager
+	GetGlobalScope() ScopeContextEx
+	// create child container of this container
+	CreateChild(props Properties, configure ConfigureChildComponentsMethod) ChildContainer
+	// dispose singletons of the container in reverse creation order, the parent is not affected
+	Dispose() error
+}
+
+type DefaultChildContainer struct {
+	globalScope *DefaultScopeContext
+	context     ContextEx
+	manager     *DefaultComponentManager
+}
+
+// properties of parent global scope are inherited and updated by props,
+// options of parent are copied so the container validates its registrations if ValidateOnBuild is on
+func NewChildContainer(parent ComponentManager, parentScope ScopeContextEx, props Properties, configure ConfigureChildComponentsMethod) *DefaultChildContainer {
+	options := *parent.GetOptions()
+	inheritProps := parentScope.GetScope().CopyProperties()
+	inheritProps.Update(props)
+	globalScope := NewGlobalScopeContext(parentScope.IsDebug(), inheritProps)
+	globalScope.EnableConcurrency(options.EnableSingletonConcurrency)
+	globalScope.Initialize(ScopeType_Global, nil)
+
+	manager := NewChildComponentManager(parent, globalScope, &options)
+	manager.Initialize()
+
+	container := &DefaultChildContainer{
+		globalScope: globalScope,
+		context:     NewComponentContext(globalScope, manager, types.Get[ChildContainer]()),
+		manager:     manager,
+	}
+	if configure != nil {
+		configure(NewComponentCollection(container.context, manager))
+	}
+	if options.ValidateOnBuild {
+		if err := manager.Validate(); err != nil {
+			panic(err)
+		}
+	}
+	return container
+}
+
+func (cc *DefaultChildContainer) GetComponentManager() ComponentManager {
+	return cc.manager
+}
+func (cc *DefaultChildContainer) GetGlobalScope() ScopeContextEx {
+	return cc.globalScope
+}
+func (cc *DefaultChildContainer) CreateChild(props Properties, configure ConfigureChildComponentsMethod) ChildContainer {
+	return NewChildContainer(cc.manager, cc.globalScope, props, configure)
+}
+func (cc *DefaultChildContainer) Dispose() error {
+	return cc.manager.Dispose()
+}
+
+func (cc *DefaultChildContainer) GetConfiguration(configType types.DataType) any {
+	return cc.context.GetConfiguration(configType)
+}
+func (cc *DefaultChildContainer) GetComponent(interfaceType types.DataType) any {
+	return cc.context.GetComponent(interfaceType)
+}
+func (cc *DefaultChildContainer) GetKeyedComponent(interfaceType types.DataType, key any) any {
+	return GetComponent[ContextualProvider](cc.context).GetKeyedComponent(interfaceType, key, cc.context)
+}
+func (cc *DefaultChildContainer) CreateWithProperties(interfaceType types.DataType, props Properties) any {
+	return cc.context.CreateWithProperties(interfaceType, props)
+}
@@ -0,0 +1,122 @@
+package dep
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func prepareChildContainer(configureParent func(cm ComponentManager), configure ConfigureChildComponentsMethod) (ComponentManager, ContextEx, ChildContainer) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+	if configureParent != nil {
+		configureParent(cm)
+	}
+	return cm, ctxt, NewChildContainer(cm, ctxt.GetScopeContext(), Props(Pair("tenant", "contoso")), configure)
+}
+
+func TestChildContainer_parent_fallback(t *testing.T) {
+	parentConfig := &MyConfig{value: 123}
+	childConfig := &MyConfig{value: 456}
+	cm, ctxt, child := prepareChildContainer(
+		func(cm ComponentManager) {
+			AddConfig[MyConfig](cm, parentConfig)
+			RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+			RegisterSingleton[FirstInterface](cm, NewActualStruct)
+		},
+		func(components ComponentCollection) {
+			AddConfig[MyConfig](components, childConfig)
+			// own singleton depends on shared host component
+			RegisterSingleton[FirstInterface](components, func(another AnotherInterface, props Properties) *ActualStruct {
+				if GetProp[string](props, "tenant") != "contoso" {
+					t.Errorf("properties of child container should be inherited by its components")
+				}
+				return NewActualStruct()
+			})
+		},
+	)
+
+	if GetConfig[MyConfig](child) != childConfig || GetConfig[MyConfig](ctxt) != parentConfig {
+		t.Errorf("configuration of child container should not affect the parent")
+	}
+	if GetComponent[AnotherInterface](child) != GetComponentFrom[AnotherInterface](cm, ctxt, nil) {
+		t.Errorf("component not registered in child container should be resolved from the parent")
+	}
+	first := GetComponent[FirstInterface](child)
+	if first != GetComponent[FirstInterface](child) || first == GetComponentFrom[FirstInterface](cm, ctxt, nil) {
+		t.Errorf("child container should own its singletons")
+	}
+	if IsComponentRegistered[AnotherInterface](child.GetComponentManager()) {
+		t.Errorf("registrations of parent should not be counted as child's")
+	}
+}
+
+func TestChildContainer_dispose(t *testing.T) {
+	recorder := NewDisposeRecorder()
+	cm, ctxt, child := prepareChildContainer(
+		func(cm ComponentManager) {
+			RegisterInstance[DisposeRecorder](cm, recorder)
+			RegisterSingleton[DisposableSecond](cm, NewDisposableSecond)
+		},
+		func(components ComponentCollection) {
+			RegisterSingleton[DisposableFirst](components, NewDisposableFirst)
+		},
+	)
+
+	_ = GetComponent[DisposableFirst](child)
+	if err := child.Dispose(); err != nil {
+		t.Errorf("unexpected dispose error: %v", err)
+	}
+	expectRecords(t, recorder, "first")
+
+	// singleton of parent is still alive
+	_ = GetComponentFrom[DisposableSecond](cm, ctxt, nil)
+	_ = cm.Dispose()
+	expectRecords(t, recorder, "first", "second")
+}
+
+func TestChildContainer_nested(t *testing.T) {
+	_, _, child := prepareChildContainer(
+		func(cm ComponentManager) {
+			RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+		},
+		func(components ComponentCollection) {
+			RegisterSingleton[FirstInterface](components, NewActualStruct)
+		},
+	)
+	grandChild := child.CreateChild(nil, func(components ComponentCollection) {
+		RegisterTransient[SecondInterface](components, func(first FirstInterface, another AnotherInterface) *ActualStruct {
+			return NewActualStruct()
+		})
+	})
+
+	if GetComponent[SecondInterface](grandChild) == nil {
+		t.Errorf("component should be resolved with dependencies from ancestors")
+	}
+	if GetComponent[FirstInterface](grandChild) != GetComponent[FirstInterface](child) {
+		t.Errorf("singleton of parent container should be shared")
+	}
+}
+
+func TestChildContainer_validate(t *testing.T) {
+	_, _, child := prepareChildContainer(
+		func(cm ComponentManager) {
+			RegisterSingleton[AnotherInterface](cm, func(second SecondInterface) *AnotherStruct { return NewAnotherStruct(nil) })
+		},
+		func(components ComponentCollection) {
+			RegisterSingleton[FirstInterface](components, func(another AnotherInterface, second SecondInterface) *ActualStruct {
+				return NewActualStruct()
+			})
+		},
+	)
+
+	err := child.GetComponentManager().Validate()
+	var validationErr *DependencyValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Errors) != 1 {
+		t.Fatalf("only missing dependency of child container should be reported, actual: %v", err)
+	}
+	expected := "component dep.FirstInterface: parameter 1, dependency dep.SecondInterface is not registered"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("validation error should contain: %s, actual: %v", expected, err)
+	}
+}
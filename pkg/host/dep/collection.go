@@ -21,6 +21,15 @@ type ComponentCollection interface {
 	RegisterScopedForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType)
 	RegisterScopedForTypeEx(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, scopeType types.DataType)
 	RegisterTransientForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType)
+	// register one of multiple implementations, all implementations are injected as []T in registration order,
+	// scope type is used for scoped implementation only
+	RegisterManyForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, lifetime Lifetime, scopeType types.DataType)
+	// register component resolved by type and key, scope type is used for scoped component only
+	RegisterKeyedForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, key any, lifetime Lifetime, scopeType types.DataType)
+	// wrap instances of the registered component type, applied in registration order
+	DecorateForType(decorator FreeStyleDecoratorMethod, interfaceType types.DataType)
+	// get collection sharing the same registrations, whose registrations are handled by the mode
+	WithRegistrationMode(mode RegistrationMode) ComponentCollection
 
 	IsComponentRegistered(componentType types.DataType) bool
 	Count() int
@@ -41,6 +50,22 @@ func RegisterScoped[T any, S any](collection ComponentCollection, createInstance
 func RegisterTransient[T any](collection ComponentCollection, createInstance FreeStyleFactoryMethod) {
 	collection.RegisterTransientForType(createInstance, types.Get[T]())
 }
+func RegisterMany[T any](collection ComponentCollection, lifetime Lifetime, createInstance FreeStyleFactoryMethod) {
+	collection.RegisterManyForType(createInstance, types.Get[T](), lifetime, ScopeType_Any)
+}
+func RegisterManyScoped[T any, S any](collection ComponentCollection, createInstance FreeStyleFactoryMethod) {
+	collection.RegisterManyForType(createInstance, types.Get[T](), Lifetime_Scoped, types.Get[S]())
+}
+// registrations by returned collection replace existing ones of the same component type
+func Replace(collection ComponentCollection) ComponentCollection {
+	return collection.WithRegistrationMode(RegistrationMode_Replace)
+}
+
+// registrations by returned collection are skipped if the component type is already registered
+func TryRegister(collection ComponentCollection) ComponentCollection {
+	return collection.WithRegistrationMode(RegistrationMode_TryRegister)
+}
+
 func IsComponentRegistered[T any](collection ComponentCollection) bool {
 	return collection.IsComponentRegistered(types.Get[T]())
 }
@@ -115,6 +140,18 @@ func (cc *DefaultComponentCollection) RegisterScopedForTypeEx(createInstance Fre
 func (cc *DefaultComponentCollection) RegisterTransientForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType) {
 	cc.cm.RegisterTransientForType(createInstance, interfaceType)
 }
+func (cc *DefaultComponentCollection) RegisterManyForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, lifetime Lifetime, scopeType types.DataType) {
+	cc.cm.RegisterManyForType(createInstance, interfaceType, lifetime, scopeType)
+}
+func (cc *DefaultComponentCollection) RegisterKeyedForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, key any, lifetime Lifetime, scopeType types.DataType) {
+	cc.cm.RegisterKeyedForType(createInstance, interfaceType, key, lifetime, scopeType)
+}
+func (cc *DefaultComponentCollection) DecorateForType(decorator FreeStyleDecoratorMethod, interfaceType types.DataType) {
+	cc.cm.DecorateForType(decorator, interfaceType)
+}
+func (cc *DefaultComponentCollection) WithRegistrationMode(mode RegistrationMode) ComponentCollection {
+	return NewComponentCollection(cc.context, cc.cm.WithRegistrationMode(mode).(ComponentManager))
+}
 
 func (cc *DefaultComponentCollection) CreateComponentHub(creator func(context Context, provider ContextualProvider) any) any {
 	return creator(cc.context, cc.cm)
@@ -82,3 +82,87 @@ func TestCollection_scoped(t *testing.T) {
 		t.Errorf("create component SecondInterface result %p is nil", scoped)
 	}
 }
+
+func TestCollection_replace(t *testing.T) {
+	cm, ctxt := prepareComponentManager(true)
+	components, provider := createCollection(ctxt, cm)
+
+	AddConfig[MyConfig](components, &MyConfig{value: 123})
+	RegisterSingleton[FirstInterface](components, NewActualStruct)
+	Decorate[FirstInterface](components, func(inner FirstInterface) FirstInterface { return inner })
+	count := components.Count()
+
+	fake := NewActualStruct()
+	expected := &MyConfig{value: 456}
+	replacing := Replace(components)
+	AddConfig[MyConfig](replacing, expected)
+	RegisterInstance[FirstInterface](replacing, fake)
+	RegisterTransient[SecondInterface](replacing, NewActualStruct)
+
+	if GetComponent[FirstInterface](provider) != fake || GetConfig[MyConfig](provider) != expected {
+		t.Errorf("registration should be replaced")
+	}
+	if !IsComponentRegistered[SecondInterface](components) || components.Count() != count+1 {
+		t.Errorf("component should be added if not registered, count: %d", components.Count())
+	}
+
+	registration := cm.GetRegistrations().GetRegistration(types.Get[FirstInterface]())
+	if registration.Mode != RegistrationMode_Replace || registration.Replaced == nil || registration.Replaced.Mode != RegistrationMode_Default {
+		t.Errorf("replaced registration should be recorded: %v", registration)
+	}
+	cm.PrintDiagnostics()
+}
+
+func TestCollection_registration_mode_view(t *testing.T) {
+	cm, ctxt := prepareComponentManager(true)
+	components, provider := createCollection(ctxt, cm)
+
+	var created Context
+	RegisterInstance[FirstInterface](components, NewActualStruct())
+	RegisterTransient[FirstInterface](Replace(components), func(context Context) *ActualStruct {
+		created = context
+		return NewActualStruct()
+	})
+	GetComponent[FirstInterface](provider)
+
+	// component registered through the view is served by the manager itself
+	if created.(*DefaultComponentContext).contextualProvider != cm {
+		t.Errorf("contextual provider of component registered with mode should be the manager")
+	}
+	// mode applies only to registrations through the view
+	defer test.AssertPanicContent(t, "already exist", "registration without mode should not replace existing one")
+	RegisterInstance[FirstInterface](components, NewActualStruct())
+}
+
+func TestCollection_try_register(t *testing.T) {
+	cm, ctxt := prepareComponentManager(true)
+	components, provider := createCollection(ctxt, cm)
+
+	first := NewActualStruct()
+	RegisterInstance[FirstInterface](components, first)
+
+	optional := TryRegister(components)
+	RegisterInstance[FirstInterface](optional, NewActualStruct())
+	RegisterTransient[SecondInterface](optional, NewActualStruct)
+
+	if GetComponent[FirstInterface](provider) != first {
+		t.Errorf("registered component should not be replaced by TryRegister")
+	}
+	registration := cm.GetRegistrations().GetRegistration(types.Get[SecondInterface]())
+	if registration == nil || registration.Mode != RegistrationMode_TryRegister {
+		t.Errorf("component should be registered if not registered: %v", registration)
+	}
+
+	// the original collection is not affected
+	defer test.AssertPanicContent(t, "specified component type already exist", "panic content is not expected")
+	RegisterTransient[SecondInterface](components, NewActualStruct)
+}
+
+func TestCollection_replace_many(t *testing.T) {
+	defer test.AssertPanicContent(t, "registration mode Replace is not supported for multiple implementations", "panic content is not expected")
+
+	cm, ctxt := prepareComponentManager(true)
+	components, _ := createCollection(ctxt, cm)
+
+	RegisterMany[AnotherInterface](Replace(components), Lifetime_Singleton, NewAnotherStruct)
+}
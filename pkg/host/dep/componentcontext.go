@@ -16,6 +16,8 @@ type DefaultComponentContext struct {
 	parentContext      ScopeContextEx
 	contextualProvider ContextualProvider
 	props              Properties
+	lifetimeChain      []LifetimeFrame
+	resolutionFrame    *ResolutionFrame
 
 	// local context dependencies
 	localDeps DepDict[ComponentGetter]
@@ -38,6 +40,33 @@ func (cc *DefaultComponentContext) GetTracker() DependencyTracker {
 	return cc.depTracker
 }
 
+func (cc *DefaultComponentContext) GetLifetimeChain() []LifetimeFrame {
+	return cc.lifetimeChain
+}
+func (cc *DefaultComponentContext) SetLifetimeChain(chain []LifetimeFrame) {
+	cc.lifetimeChain = chain
+}
+
+func (cc *DefaultComponentContext) GetResolutionFrame() *ResolutionFrame {
+	return cc.resolutionFrame
+}
+func (cc *DefaultComponentContext) SetResolutionFrame(frame *ResolutionFrame) {
+	cc.resolutionFrame = frame
+}
+
+func (cc *DefaultComponentContext) GetDecorators(componentType types.DataType) []*DecoratorRegistration {
+	if provider, ok := cc.contextualProvider.(DecoratorProvider); ok {
+		return provider.GetDecorators(componentType)
+	}
+	return []*DecoratorRegistration{}
+}
+
+func (cc *DefaultComponentContext) RecordInstance(compCtxt ContextEx, compType types.DataType, lifetime Lifetime) {
+	if recorder, ok := cc.contextualProvider.(InstanceRecorder); ok {
+		recorder.RecordInstance(compCtxt, compType, lifetime)
+	}
+}
+
 func (cc *DefaultComponentContext) IsDebug() bool {
 	return cc.debug
 }
@@ -86,6 +115,10 @@ func (cc *DefaultComponentContext) GetComponent(interfaceType types.DataType) an
 	return cc.CreateWithProperties(interfaceType, nil)
 }
 
+func (cc *DefaultComponentContext) GetKeyedComponent(interfaceType types.DataType, key any) any {
+	return cc.contextualProvider.GetKeyedComponent(interfaceType, key, cc)
+}
+
 func (cc *DefaultComponentContext) getContextDependency(depType types.DataType) any {
 	// check local dict first
 	if cc.localDeps.ExistDependency(depType) {
@@ -103,6 +136,15 @@ func (cc *DefaultComponentContext) CreateWithProperties(interfaceType types.Data
 	return cc.contextualProvider.GetOrCreateWithProperties(interfaceType, cc, props)
 }
 
+// types of contextual dependencies added by GetComponentContextFactory
+var ComponentContextualTypes = []types.DataType{
+	types.Get[Context](),
+	types.Get[ComponentProviderEx](),
+	types.Get[logger.Logger](),
+	types.Get[Properties](),
+	types.Get[ScopeContext](),
+}
+
 // utility API
 func GetComponentContextFactory(ctxtProvider ContextualProvider, compType types.DataType) ContextFactoryMethod {
 	return func(scopeCtxt ScopeContextEx) ContextEx {
@@ -24,6 +24,11 @@ type ComponentHub[T any, K comparable] interface {
 	CompImplCollection[T, K]
 }
 
+// implemented by component provider which records implementations of component hubs
+type hubRegistrar interface {
+	addHubImplementation(registration *ComponentRegistration)
+}
+
 type DefaultComponentImplHub[T any, K comparable] struct {
 	context             Context
 	provider            ContextualProvider
@@ -46,15 +51,29 @@ func (ih *DefaultComponentImplHub[T, K]) GetComponentType() types.DataType {
 	return ih.interfaceType
 }
 
+// record implementation in registrations of the provider for diagnostics
+func (ih *DefaultComponentImplHub[T, K]) recordImpl(key K, lifetime Lifetime, createInstance FreeStyleFactoryMethod) {
+	if registrar, ok := ih.provider.(hubRegistrar); ok {
+		registrar.addHubImplementation(&ComponentRegistration{
+			ComponentType: ih.interfaceType,
+			Lifetime:      lifetime,
+			FactoryMethod: createInstance,
+			HubKey:        key,
+		})
+	}
+}
+
 func (ih *DefaultComponentImplHub[T, K]) AddSingletonImpl(key K, createInstance FreeStyleFactoryMethod) {
 	ctxtFactory := GetComponentContextFactory(ih.provider, ih.interfaceType)
 	factoryMethod := ih.lifecycleController.BuildSingletonFactoryMethod([]types.DataType{ih.interfaceType}, createInstance, ctxtFactory)
 	ih.impls[key] = factoryMethod
+	ih.recordImpl(key, Lifetime_Singleton, createInstance)
 }
 func (ih *DefaultComponentImplHub[T, K]) AddTransientImpl(key K, createInstance FreeStyleFactoryMethod) {
 	ctxtFactory := GetComponentContextFactory(ih.provider, ih.interfaceType)
 	factoryMethod := ih.lifecycleController.BuildTransientFactoryMethod(ih.interfaceType, createInstance, ctxtFactory)
 	ih.impls[key] = factoryMethod
+	ih.recordImpl(key, Lifetime_Transient, createInstance)
 }
 func (ih *DefaultComponentImplHub[T, K]) AddImpl(key K, createInstance FreeStyleFactoryMethod) {
 	ih.AddTransientImpl(key, createInstance)
@@ -14,6 +14,23 @@ type DependencyTracker interface {
 	GetDependents() []ContextEx
 }
 
+// frame of lifetime chain of a component context
+type LifetimeFrame struct {
+	ComponentType types.DataType
+	Lifetime      Lifetime
+}
+
+func (lf LifetimeFrame) String() string {
+	return fmt.Sprintf("%s[%v]", lf.ComponentType.FullName(), lf.Lifetime)
+}
+
+// implemented by contexts of components created by lifecycle controller,
+// lifetime chain starts from the longest-lived component which captures the component, and ends with the component itself
+type LifetimeTracker interface {
+	GetLifetimeChain() []LifetimeFrame
+	SetLifetimeChain(chain []LifetimeFrame)
+}
+
 type LoggerProvider interface {
 	GetLogger() logger.Logger
 	GetLoggerWithName(name string) logger.Logger
@@ -112,8 +129,12 @@ type HostContextEx interface {
 
 type FactoryAction func() (interface{}, ContextEx)
 
+// create component in the given resolution frame
+type ResolutionAction func(frame *ResolutionFrame) (interface{}, ContextEx)
+
 type ScopedCompRecord interface {
-	Execute(createComponent FactoryAction) (interface{}, ContextEx, bool, bool)
+	// get or create the component requested by dependent, return instance, component context, instance exist, cyclic dependency detected
+	Execute(dependent ContextEx, createComponent ResolutionAction) (interface{}, ContextEx, bool, bool)
 }
 
 type ScopeData interface {
@@ -123,8 +144,13 @@ type ScopeData interface {
 
 	// clear all entries in the scope
 	Clear()
+	// dispose tracked instances in reverse creation order, then clear all entries in the scope
+	Dispose() error
 }
 
+// notified of instance created by lifecycle controller
+type InstanceObserver func(instance any)
+
 type ScopeDataEx interface {
 	ScopeData
 
@@ -138,6 +164,14 @@ type ScopeDataEx interface {
 
 	// retrieve if entry exist, or insert new entry and return if not
 	GetCompRecord(compType types.DataType) ScopedCompRecord
+	// retrieve or insert entry by record key, for components which are not identified by type only
+	GetCompRecordByKey(key interface{}, name string) ScopedCompRecord
+	// track instance to be disposed with the scope, ignored if it is not disposable
+	TrackDisposable(instance any)
+	// track instance created in the scope, it is disposed with the scope if disposable and observers are notified
+	TrackInstance(instance any)
+	// observe instances created in the scope afterwards, e.g. singletons subscribing host lifecycle events
+	ObserveInstances(observer InstanceObserver)
 
 	CopyProperties() Properties
 }
@@ -5,6 +5,14 @@ import "goms.io/azureml/mir/mir-vmagent/pkg/host/types"
 type ContextualProvider interface {
 	GetConfiguration(configType types.DataType, dependent Context) interface{}
 	GetOrCreateWithProperties(interfaceType types.DataType, dependent Context, props Properties) interface{}
+
+	// error-returning variants, ResolutionError is returned instead of panic
+	TryGetConfiguration(configType types.DataType, dependent Context) (interface{}, error)
+	TryGetOrCreateWithProperties(interfaceType types.DataType, dependent Context, props Properties) (interface{}, error)
+
+	// component registered by RegisterKeyed with the key
+	GetKeyedComponent(interfaceType types.DataType, key any, dependent Context) interface{}
+	TryGetKeyedComponent(interfaceType types.DataType, key any, dependent Context) (interface{}, error)
 }
 
 func GetConfigFrom[T any](ctxtProvider ContextualProvider, dependent Context) *T {
@@ -13,3 +21,10 @@ func GetConfigFrom[T any](ctxtProvider ContextualProvider, dependent Context) *T
 func GetComponentFrom[T any](ctxtProvider ContextualProvider, dependent Context, props Properties) T {
 	return ctxtProvider.GetOrCreateWithProperties(types.Get[T](), dependent, props).(T)
 }
+
+func ResolveConfig[T any](ctxtProvider ContextualProvider, dependent Context) (*T, error) {
+	return castResolved[*T](ctxtProvider.TryGetConfiguration(types.Get[T](), dependent))
+}
+func ResolveComponent[T any](ctxtProvider ContextualProvider, dependent Context, props Properties) (T, error) {
+	return castResolved[T](ctxtProvider.TryGetOrCreateWithProperties(types.Get[T](), dependent, props))
+}
@@ -0,0 +1,73 @@
+package dep
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+// required type: func(inner T, ...) T
+type FreeStyleDecoratorMethod interface{}
+
+// metadata of a decorator which wraps instances of the component type
+type DecoratorRegistration struct {
+	ComponentType types.DataType
+	Decorator     FreeStyleDecoratorMethod
+}
+
+func (dr *DecoratorRegistration) String() string {
+	return getMethodName(dr.Decorator)
+}
+
+// get types of decorator parameters except the first one, which is the inner instance
+func (dr *DecoratorRegistration) GetParameterTypes() []types.DataType {
+	funcType := types.GetFuncType(dr.Decorator)
+	paramTypes := make([]types.DataType, 0, funcType.GetNumOfInput())
+	for i := 1; i < funcType.GetNumOfInput(); i++ {
+		paramTypes = append(paramTypes, funcType.GetInput(i))
+	}
+	return paramTypes
+}
+
+// get dependencies of decorator parameters except the first one
+func (dr *DecoratorRegistration) GetParameters() []*Parameter {
+	return getParameters(dr.GetParameterTypes(), 1)
+}
+
+// implemented by component provider supporting decorators
+type DecoratorProvider interface {
+	// decorators of the component type in registration order
+	GetDecorators(componentType types.DataType) []*DecoratorRegistration
+}
+
+// wrap instances of registered component type T by decorator, e.g. add logging, metrics or caching without touching its factory method.
+// decorators are applied in registration order, the decorated instance follows lifetime of the registration
+func Decorate[T any](collection ComponentCollection, decorator FreeStyleDecoratorMethod) {
+	collection.DecorateForType(decorator, types.Get[T]())
+}
+
+// get decorators of the component type, empty if the context does not support decorators
+func GetDecorators(context ContextEx, componentType types.DataType) []*DecoratorRegistration {
+	if provider, ok := context.(DecoratorProvider); ok {
+		return provider.GetDecorators(componentType)
+	}
+	return []*DecoratorRegistration{}
+}
+
+func DecorationChainToString(componentType types.DataType, decorators []*DecoratorRegistration) string {
+	names := make([]string, 0, len(decorators)+1)
+	names = append(names, componentType.FullName())
+	for _, decorator := range decorators {
+		names = append(names, decorator.String())
+	}
+	return strings.Join(names, " -> ")
+}
+
+func getMethodName(method any) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(method).Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return types.Of(method).FullName()
+}
@@ -0,0 +1,265 @@
+package dep
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+type DecoratedAnother struct {
+	AnotherInterface
+	inner AnotherInterface
+	name  string
+}
+
+func decorateAnotherWithName(name string) func(inner AnotherInterface) AnotherInterface {
+	return func(inner AnotherInterface) AnotherInterface {
+		return &DecoratedAnother{AnotherInterface: inner, inner: inner, name: name}
+	}
+}
+
+func getDecorationNames(instance AnotherInterface) []string {
+	names := make([]string, 0)
+	for {
+		decorated, ok := instance.(*DecoratedAnother)
+		if !ok {
+			return names
+		}
+		names = append(names, decorated.name)
+		instance = decorated.inner
+	}
+}
+
+func TestDecorate_singleton(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	created := 0
+	RegisterSingleton[AnotherInterface](cm, func(context Context) *AnotherStruct {
+		created++
+		return NewAnotherStruct(context)
+	})
+	Decorate[AnotherInterface](cm, decorateAnotherWithName("logging"))
+	Decorate[AnotherInterface](cm, decorateAnotherWithName("metrics"))
+
+	inst1 := GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	inst2 := GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	if inst1 != inst2 || created != 1 {
+		t.Errorf("decorated singleton should be created once, created: %d", created)
+	}
+	// the last registered decorator is the outermost
+	names := getDecorationNames(inst1)
+	if strings.Join(names, ",") != "metrics,logging" {
+		t.Errorf("decorators should be applied in registration order, actual: %v", names)
+	}
+
+	compType := types.Get[AnotherInterface]()
+	chain := DecorationChainToString(compType, cm.GetRegistrations().GetDecorators(compType))
+	if !strings.HasPrefix(chain, "dep.AnotherInterface -> ") || strings.Count(chain, "decorateAnotherWithName") != 2 {
+		t.Errorf("unexpected decoration chain: %s", chain)
+	}
+}
+
+type DecoratedFirst struct {
+	FirstInterface
+}
+
+func TestDecorate_singleton_for_types(t *testing.T) {
+	for _, firstResolved := range []bool{true, false} {
+		options := NewComponentProviderOptions(InterfaceType)
+		cm, ctxt := prepareComponentManagerWithOptions(options)
+
+		created := 0
+		cm.RegisterSingletonForTypes(func() *ActualStruct {
+			created++
+			return NewActualStruct()
+		}, types.Get[FirstInterface](), types.Get[SecondInterface]())
+		Decorate[FirstInterface](cm, func(inner FirstInterface) FirstInterface {
+			return &DecoratedFirst{FirstInterface: inner}
+		})
+
+		// decorator of one type applies to the instance of the type only, regardless of resolution order
+		var first FirstInterface
+		var second SecondInterface
+		if firstResolved {
+			first = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+			second = GetComponentFrom[SecondInterface](cm, ctxt, nil)
+		} else {
+			second = GetComponentFrom[SecondInterface](cm, ctxt, nil)
+			first = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+		}
+		decorated, ok := first.(*DecoratedFirst)
+		if !ok || decorated.FirstInterface != second.(*ActualStruct) {
+			t.Errorf("first should decorate the shared instance, first resolved: %v, actual: %T", firstResolved, first)
+		}
+		if created != 1 || GetComponentFrom[FirstInterface](cm, ctxt, nil) != first {
+			t.Errorf("decorated singleton should be created once, first resolved: %v, created: %d", firstResolved, created)
+		}
+	}
+}
+
+func TestDecorate_transient_with_dependencies(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	AddConfig[MyConfig](cm, &MyConfig{value: 123})
+	RegisterTransient[AnotherInterface](cm, NewAnotherStruct)
+	Decorate[AnotherInterface](cm, func(inner AnotherInterface, config *MyConfig, context Context) AnotherInterface {
+		if context.Name() != "dep.AnotherInterface" {
+			t.Errorf("decorator should be injected with context of decorated component, actual: %s", context.Name())
+		}
+		inner.(*AnotherStruct).value = config.value
+		return &DecoratedAnother{AnotherInterface: inner, inner: inner, name: "config"}
+	})
+
+	inst1 := GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	inst2 := GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	if inst1 == inst2 {
+		t.Errorf("decorated transient should be created on each resolution")
+	}
+	if inst1.(*DecoratedAnother).inner.(*AnotherStruct).value != 123 {
+		t.Errorf("decorator dependencies should be injected")
+	}
+}
+
+func TestDecorate_scoped(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithScope(options, ScopeTest)
+
+	RegisterScoped[AnotherInterface, TestScope](cm, NewAnotherStruct)
+	Decorate[AnotherInterface](cm, decorateAnotherWithName("cache"))
+
+	inst1 := GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	inst2 := GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	inst3 := GetComponentFrom[AnotherInterface](cm, createNewContext(ctxt, ScopeTest), nil)
+	if inst1 != inst2 || inst1 == inst3 {
+		t.Errorf("decorated scoped component should be reused in the same scope only")
+	}
+	if _, ok := inst3.(*DecoratedAnother); !ok {
+		t.Errorf("scoped component should be decorated in new scope")
+	}
+}
+
+func TestDecorate_many(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterMany[AnotherInterface](cm, Lifetime_Singleton, newAnotherStructWithValue(1))
+	RegisterMany[AnotherInterface](cm, Lifetime_Transient, newAnotherStructWithValue(2))
+	Decorate[AnotherInterface](cm, decorateAnotherWithName("logging"))
+
+	for _, inst := range GetComponentFrom[[]AnotherInterface](cm, ctxt, nil) {
+		if _, ok := inst.(*DecoratedAnother); !ok {
+			t.Errorf("each implementation should be decorated")
+		}
+	}
+}
+
+type DecoratedDownloader struct {
+	Downloader
+}
+
+func TestDecorate_component_hub(t *testing.T) {
+	cm, ctxt := prepareComponentManager(true)
+	components, provider := createCollection(ctxt, cm)
+
+	RegisterComponent(
+		components,
+		func(props Properties) string { return GetProp[string](props, "type") },
+		func(comp CompImplCollection[Downloader, string]) {
+			comp.AddSingletonImpl("url", NewUrlDownloader)
+			comp.AddImpl("blob", NewBlobDownloader)
+		},
+	)
+	Decorate[Downloader](components, func(inner Downloader) Downloader {
+		return &DecoratedDownloader{Downloader: inner}
+	})
+
+	for _, Type := range []string{"url", "blob"} {
+		downloader := CreateComponent[Downloader](provider, Props(Pair("type", Type)))
+		if _, ok := downloader.(*DecoratedDownloader); !ok || downloader.GetType() != Type {
+			t.Errorf("implementation %s should be decorated", Type)
+		}
+	}
+}
+
+func TestDecorate_not_registered(t *testing.T) {
+	defer test.AssertPanicContent(t, "decorated component type is not registered: dep.AnotherInterface", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	Decorate[AnotherInterface](cm, decorateAnotherWithName("logging"))
+}
+
+func TestDecorate_inner_parameter_missing(t *testing.T) {
+	defer test.AssertPanicContent(t, "first parameter of decorator should be of decorated component type: dep.AnotherInterface", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+	Decorate[AnotherInterface](cm, func(context Context) AnotherInterface { return NewAnotherStruct(context) })
+}
+
+func TestComponentManager_Validate_decorator(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+	Decorate[AnotherInterface](cm, func(inner AnotherInterface, first FirstInterface) AnotherInterface { return inner })
+
+	err := cm.Validate()
+	var validationErr *DependencyValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Errors) != 1 {
+		t.Fatalf("missing dependency of decorator should be reported, actual: %v", err)
+	}
+	expected := "of component dep.AnotherInterface: parameter 1, dependency dep.FirstInterface is not registered"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("validation error should contain: %s, actual: %v", expected, err)
+	}
+}
+
+// decorator which does not dispose its inner instance
+type DisposableDecorator struct {
+	DisposableFirst
+	DisposableSecond
+	name     string
+	recorder DisposeRecorder
+}
+
+func (dd *DisposableDecorator) Dispose() error {
+	dd.recorder.Record(dd.name)
+	return nil
+}
+
+func TestDecorate_dispose_inner(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	recorder := NewDisposeRecorder()
+	RegisterInstance[DisposeRecorder](cm, recorder)
+	RegisterSingleton[DisposableSecond](cm, NewDisposableSecond)
+	RegisterTransient[DisposableFirst](cm, NewDisposableFirst)
+	Decorate[DisposableSecond](cm, func(inner DisposableSecond, recorder DisposeRecorder) DisposableSecond {
+		return &DisposableDecorator{DisposableSecond: inner, name: "second-decorator", recorder: recorder}
+	})
+	// decorator returning the inner instance adds no layer to dispose
+	Decorate[DisposableSecond](cm, func(inner DisposableSecond) DisposableSecond { return inner })
+	Decorate[DisposableFirst](cm, func(inner DisposableFirst, recorder DisposeRecorder) DisposableFirst {
+		return &DisposableDecorator{DisposableFirst: inner, name: "first-decorator", recorder: recorder}
+	})
+
+	scopeFactory := GetComponentFrom[ScopeFactory](cm, ctxt, nil)
+	scope := scopeFactory.CreateScope(ctxt, nil)
+	scope.Execute("Resolve", func(first DisposableFirst) {})
+
+	// inner instances are disposed after the decorated ones
+	_ = scope.Dispose()
+	expectRecords(t, recorder, "first-decorator", "first")
+	_ = cm.Dispose()
+	expectRecords(t, recorder, "first-decorator", "first", "second-decorator", "second")
+}
@@ -0,0 +1,234 @@
+package dep
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+// kind of factory method or action parameter
+type ParamKind uint8
+
+const (
+	// resolved eagerly when the method is called
+	ParamKind_Direct ParamKind = iota
+	// Optional[T], resolved eagerly but absent if T is not registered
+	ParamKind_Optional
+	// Lazy[T], Provider[T] or func() T, resolved on demand
+	ParamKind_Lazy
+	// []T, all implementations registered by RegisterMany, empty if none
+	ParamKind_Many
+	// Keyed[T, K], registered by RegisterKeyed with the key of K
+	ParamKind_Keyed
+)
+
+func (pk ParamKind) String() string {
+	switch pk {
+	case ParamKind_Direct:
+		return "Direct"
+	case ParamKind_Optional:
+		return "Optional"
+	case ParamKind_Lazy:
+		return "Lazy"
+	case ParamKind_Many:
+		return "Many"
+	case ParamKind_Keyed:
+		return "Keyed"
+	default:
+		return fmt.Sprintf("ParamKind(%d)", pk)
+	}
+}
+
+// implemented by Optional[T], Lazy[T], Provider[T] and Keyed[T, K] to wrap the actual dependency of type T
+type dependencyWrapper interface {
+	getElementType() types.DataType
+	getParamKind() ParamKind
+	// create wrapper of the same type, resolve returns ResolutionError on failure
+	wrap(resolve func() (any, error)) any
+}
+
+// dependency which is absent if T is not registered, e.g. an optional plugin
+type Optional[T any] struct {
+	value   T
+	present bool
+}
+
+func NewOptional[T any](value T) Optional[T] {
+	return Optional[T]{value: value, present: true}
+}
+
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.present
+}
+func (o Optional[T]) IsPresent() bool {
+	return o.present
+}
+func (o Optional[T]) OrElse(defaultValue T) T {
+	if o.present {
+		return o.value
+	}
+	return defaultValue
+}
+
+func (o Optional[T]) getElementType() types.DataType {
+	return types.Get[T]()
+}
+func (o Optional[T]) getParamKind() ParamKind {
+	return ParamKind_Optional
+}
+func (o Optional[T]) wrap(resolve func() (any, error)) any {
+	value, err := resolve()
+	if err != nil {
+		if isNotRegistered(err, o.getElementType()) {
+			return Optional[T]{}
+		}
+		// registered but failed to create, or its own dependencies missing
+		panic(err)
+	}
+	return NewOptional(value.(T))
+}
+
+// dependency which is resolved on first call of Get(), and the instance is reused afterwards
+type Lazy[T any] struct {
+	state *lazyState[T]
+}
+
+type lazyState[T any] struct {
+	once    sync.Once
+	resolve func() T
+	value   T
+	// panic of the first resolution, raised again on each call
+	failed  bool
+	failure any
+}
+
+func NewLazy[T any](resolve func() T) Lazy[T] {
+	return Lazy[T]{state: &lazyState[T]{resolve: resolve}}
+}
+
+func (l Lazy[T]) Get() T {
+	l.state.once.Do(func() {
+		l.state.failed = true
+		defer func() {
+			if l.state.failed {
+				l.state.failure = recover()
+			}
+		}()
+		l.state.value = l.state.resolve()
+		l.state.failed = false
+	})
+	if l.state.failed {
+		panic(l.state.failure)
+	}
+	return l.state.value
+}
+
+func (l Lazy[T]) getElementType() types.DataType {
+	return types.Get[T]()
+}
+func (l Lazy[T]) getParamKind() ParamKind {
+	return ParamKind_Lazy
+}
+func (l Lazy[T]) wrap(resolve func() (any, error)) any {
+	return NewLazy(func() T {
+		value, err := resolve()
+		if err != nil {
+			panic(err)
+		}
+		return value.(T)
+	})
+}
+
+// dependency which is resolved on each call, following the lifetime of T, e.g. a factory of transient workers
+type Provider[T any] func() T
+
+func (p Provider[T]) getElementType() types.DataType {
+	return types.Get[T]()
+}
+func (p Provider[T]) getParamKind() ParamKind {
+	return ParamKind_Lazy
+}
+func (p Provider[T]) wrap(resolve func() (any, error)) any {
+	return Provider[T](func() T {
+		value, err := resolve()
+		if err != nil {
+			panic(err)
+		}
+		return value.(T)
+	})
+}
+
+func isNotRegistered(err error, depType types.DataType) bool {
+	var notRegistered *ComponentNotRegisteredError
+	if !errors.As(err, &notRegistered) || notRegistered.Key != nil {
+		return false
+	}
+	// configuration is injected as pointer but registered as struct
+	if depType.IsPtr() {
+		depType = depType.ElementType()
+	}
+	return notRegistered.ComponentType.Key() == depType.Key()
+}
+
+func getDependencyWrapper(paramType types.DataType) (dependencyWrapper, bool) {
+	if !paramType.IsStruct() && !paramType.IsFunc() {
+		return nil, false
+	}
+	wrapper, ok := types.Zero(paramType).(dependencyWrapper)
+	return wrapper, ok
+}
+
+// unnamed func() T is injected as provider which resolves T on each call, the same as Provider[T].
+// named func types, e.g. type NameFunc func() string, are resolved as components
+func isProviderFunc(paramType types.DataType) bool {
+	if !paramType.IsFunc() || paramType.Name() != "" {
+		return false
+	}
+	funcType := types.ToFuncType(paramType)
+	return funcType.GetNumOfInput() == 0 && funcType.GetNumOfOutput() == 1
+}
+
+// get kind and actual dependency type of the parameter
+func GetParamKind(paramType types.DataType) (ParamKind, types.DataType) {
+	if wrapper, ok := getDependencyWrapper(paramType); ok {
+		return wrapper.getParamKind(), wrapper.getElementType()
+	}
+	if isProviderFunc(paramType) {
+		return ParamKind_Lazy, types.ToFuncType(paramType).GetOutputType()
+	}
+	if paramType.IsSlice() {
+		return ParamKind_Many, paramType.ElementType()
+	}
+	return ParamKind_Direct, paramType
+}
+
+// dependency of factory method or decorator parameter, or tagged field of struct registered by RegisterStruct
+type Parameter struct {
+	// index of parameter or field
+	Index int
+	// name of field, empty for parameter
+	Field string
+	Kind  ParamKind
+	// actual dependency type, e.g. T of Optional[T], configuration is pointer of struct
+	Type types.DataType
+	// key of Keyed[T, K], nil otherwise
+	Key any
+}
+
+func (p *Parameter) String() string {
+	if p.Field != "" {
+		return fmt.Sprintf("field %s", p.Field)
+	}
+	return fmt.Sprintf("parameter %d", p.Index)
+}
+
+func getParameters(paramTypes []types.DataType, firstIndex int) []*Parameter {
+	params := make([]*Parameter, 0, len(paramTypes))
+	for offset, rawParamType := range paramTypes {
+		paramKind, paramType := GetParamKind(rawParamType)
+		params = append(params, &Parameter{Index: firstIndex + offset, Kind: paramKind, Type: paramType, Key: getParamKey(rawParamType)})
+	}
+	return params
+}
@@ -0,0 +1,222 @@
+package dep
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+)
+
+type OptionalHolder struct {
+	ActualStruct
+	another AnotherInterface
+	config  *MyConfig
+	present bool
+}
+
+func TestInjection_Optional(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterTransient[FirstInterface](cm, func(another Optional[AnotherInterface], config Optional[*MyConfig]) *OptionalHolder {
+		holder := &OptionalHolder{present: another.IsPresent()}
+		holder.another, _ = another.Get()
+		holder.config = config.OrElse(&MyConfig{value: 456})
+		return holder
+	})
+
+	holder := GetComponentFrom[FirstInterface](cm, ctxt, nil).(*OptionalHolder)
+	if holder.present || holder.another != nil || holder.config.value != 456 {
+		t.Errorf("optional dependencies should be absent: %v", holder)
+	}
+
+	AddConfig[MyConfig](cm, &MyConfig{value: 123})
+	RegisterTransient[AnotherInterface](cm, NewAnotherStruct)
+
+	holder = GetComponentFrom[FirstInterface](cm, ctxt, nil).(*OptionalHolder)
+	if !holder.present || holder.another == nil || holder.config.value != 123 {
+		t.Errorf("optional dependencies should be present: %v", holder)
+	}
+}
+
+func TestInjection_Optional_missing_dependency(t *testing.T) {
+	defer test.AssertPanicContent(t, "dependency not configured, type: dep.SecondInterface", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	// registered optional dependency fails due to its own dependency missing
+	RegisterTransient[AnotherInterface](cm, func(second SecondInterface) *AnotherStruct { return NewAnotherStruct(nil) })
+	RegisterTransient[FirstInterface](cm, func(another Optional[AnotherInterface]) *ActualStruct { return NewActualStruct() })
+
+	_ = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+}
+
+func TestInjection_Lazy(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	created := 0
+	RegisterSingleton[AnotherInterface](cm, func(context Context) *AnotherStruct {
+		created++
+		return NewAnotherStruct(context)
+	})
+	var lazy Lazy[AnotherInterface]
+	RegisterTransient[FirstInterface](cm, func(another Lazy[AnotherInterface]) *ActualStruct {
+		lazy = another
+		return NewActualStruct()
+	})
+
+	_ = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+	if created != 0 {
+		t.Errorf("lazy dependency should not be created before first call")
+	}
+	inst1 := lazy.Get()
+	inst2 := lazy.Get()
+	if created != 1 || inst1 != inst2 {
+		t.Errorf("lazy dependency should be created once, created: %d", created)
+	}
+}
+
+func TestInjection_Lazy_failed(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	var lazy Lazy[SecondInterface]
+	RegisterTransient[FirstInterface](cm, func(second Lazy[SecondInterface]) *ActualStruct {
+		lazy = second
+		return NewActualStruct()
+	})
+	_ = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+
+	// failure of the first resolution is raised again instead of returning zero value
+	for i := 0; i < 2; i++ {
+		func() {
+			defer test.AssertPanicContent(t, "dependency not configured, type: dep.SecondInterface", "lazy dependency failure should be raised on each call")
+			_ = lazy.Get()
+		}()
+	}
+}
+
+func TestInjection_Lazy_cyclic(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	// cycle is broken by lazy dependency explicitly
+	RegisterSingleton[FirstInterface](cm, func(second Lazy[SecondInterface]) FirstInterface {
+		return NewActualStruct()
+	})
+	RegisterSingleton[SecondInterface](cm, NewSecondDepOnFirst)
+
+	if err := cm.Validate(); err != nil {
+		t.Errorf("lazy dependency should not be reported as cycle: %v", err)
+	}
+	_ = GetComponentFrom[SecondInterface](cm, ctxt, nil)
+	_ = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+}
+
+func TestInjection_ProviderFunc(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	created := 0
+	RegisterTransient[AnotherInterface](cm, func(context Context) *AnotherStruct {
+		created++
+		return NewAnotherStruct(context)
+	})
+	var provide Provider[AnotherInterface]
+	RegisterTransient[FirstInterface](cm, func(another Provider[AnotherInterface]) *ActualStruct {
+		provide = another
+		return NewActualStruct()
+	})
+
+	_ = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+	if created != 0 {
+		t.Errorf("provided dependency should not be created before call")
+	}
+	inst1 := provide()
+	inst2 := provide()
+	if created != 2 || inst1 == inst2 {
+		t.Errorf("transient dependency should be resolved on each call, created: %d", created)
+	}
+
+	// func() T is the same as Provider[T]
+	var provideFunc func() AnotherInterface
+	RegisterTransient[SecondInterface](cm, func(another func() AnotherInterface) *ActualStruct {
+		provideFunc = another
+		return NewActualStruct()
+	})
+	_ = GetComponentFrom[SecondInterface](cm, ctxt, nil)
+	if inst3 := provideFunc(); created != 3 || inst3 == inst1 {
+		t.Errorf("func dependency should be resolved on each call, created: %d", created)
+	}
+}
+
+type NameFunc func() string
+
+func TestInjection_FuncDependency(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+	ctrl, _ := createLifecycleController(ctxt, &LifecycleOptions{})
+
+	// dependencies of named func type are injected as they are, only Provider[T] and func() T are resolved on demand
+	name := ""
+	action := ctrl.BuildActionMethod(func(named NameFunc) {
+		name = named()
+	})
+	action(ctxt, "TestAction", DepInst[NameFunc](func() string { return "named" }))
+	if name != "named" {
+		t.Errorf("func dependency should be injected directly, actual: %s", name)
+	}
+
+	RegisterTransient[AnotherInterface](cm, NewAnotherStruct)
+	RegisterSingleton[FirstInterface](cm, func(named NameFunc) *ActualStruct {
+		return NewActualStruct()
+	})
+	if err := cm.Validate(); err == nil || !strings.Contains(err.Error(), "is not registered") {
+		t.Errorf("named func dependency should not be resolved as provider, actual: %v", err)
+	}
+}
+
+func TestInjection_Action_optional(t *testing.T) {
+	_, ctxt := prepareComponentManager(true)
+	options := &LifecycleOptions{}
+	ctrl, _ := createLifecycleController(ctxt, options)
+
+	present := true
+	action := ctrl.BuildActionMethod(func(another Optional[AnotherInterface]) {
+		present = another.IsPresent()
+	})
+	action(ctxt, "TestAction")
+	if present {
+		t.Error("optional dependency should be absent")
+	}
+}
+
+func TestComponentManager_Validate_optional(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterSingleton[FirstInterface](cm, func(another Optional[AnotherInterface], second Lazy[SecondInterface], provide Provider[AnotherInterface]) *ActualStruct {
+		return NewActualStruct()
+	})
+
+	err := cm.Validate()
+	var validationErr *DependencyValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("lazy dependencies should be validated, actual: %v", err)
+	}
+	// optional dependency is not reported
+	if len(validationErr.Errors) != 2 {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+	for _, expected := range []string{
+		"component dep.FirstInterface: parameter 1, dependency dep.SecondInterface is not registered",
+		"component dep.FirstInterface: parameter 2, dependency dep.AnotherInterface is not registered",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("validation error should contain: %s, actual: %v", expected, err)
+		}
+	}
+}
@@ -54,6 +54,8 @@ type DepDictReader[T FactoryConstraint] interface {
 type DepDictWriter[T FactoryConstraint] interface {
 	AddDependency(factory T, depType types.DataType)
 	AddDependencies(deps ...*Dependency[T])
+	// return false if the dependency type does not exist
+	RemoveDependency(depType types.DataType) bool
 }
 type DepDict[T FactoryConstraint]interface {
 	DepDictReader[T]
@@ -102,10 +104,18 @@ func (dd *DefaultDepDict[T]) AddDependency(getInstance T, depType types.DataType
 	dd.dependencies[depType.Key()] = getInstance
 }
 
+func (dd *DefaultDepDict[T]) RemoveDependency(depType types.DataType) bool {
+	if !dd.ExistDependency(depType) {
+		return false
+	}
+	delete(dd.dependencies, depType.Key())
+	return true
+}
+
 func (dd *DefaultDepDict[T]) GetDependency(depType types.DataType) T {
 	factory, exist := dd.dependencies[depType.Key()]
 	if !exist {
-		panic(fmt.Errorf("dependency not configured, type: %v, quit", depType.FullName()))
+		panic(NewComponentNotRegisteredError(depType))
 	}
 	return factory
 }
@@ -11,6 +11,8 @@ type FreeStyleMethod any
 type DepInjector interface {
 	Initialize(compProvider ComponentProviderEx, contextualDeps DepDictReader[ComponentGetter])
 	BuildComponent(factoryMethod FreeStyleFactoryMethod, compType types.DataType) any
+	// create pointer of struct whose tagged fields are injected, see GetInjectedFields
+	BuildStruct(structType types.DataType) any
 	ExecuteActionFunc(processorMethod FreeStyleProcessorMethod, actionName string)
 }
 
@@ -44,10 +46,64 @@ func (di *DefaultDepInjector) getDependency(depType types.DataType) any {
 		}
 	}
 
+	// []T receives all implementations registered by RegisterMany, empty if none
+	if depType.IsSlice() {
+		instance, err := CatchResolutionError(depType, func() any {
+			return di.componentProvider.GetComponent(depType)
+		})
+		if err != nil {
+			if isNotRegistered(err, depType) {
+				return types.MakeSlice(depType, nil)
+			}
+			panic(err)
+		}
+		return instance
+	}
+	// Optional[T], Lazy[T], Provider[T] and Keyed[T, K]
+	if wrapper, ok := getDependencyWrapper(depType); ok {
+		if keyed, ok := wrapper.(keyedWrapper); ok {
+			return wrapper.wrap(di.getKeyedResolver(wrapper.getElementType(), keyed.getKey()))
+		}
+		return wrapper.wrap(di.getResolver(wrapper.getElementType()))
+	}
+	// func() T
+	if isProviderFunc(depType) {
+		funcType := types.ToFuncType(depType)
+		resolve := di.getResolver(funcType.GetOutputType())
+		return types.MakeFunc(funcType, func(inputs []any) []any {
+			instance, err := resolve()
+			if err != nil {
+				panic(err)
+			}
+			return []any{instance}
+		})
+	}
+
 	return di.componentProvider.GetComponent(depType)
 }
 
+// resolve the dependency on demand, panic is returned as ResolutionError
+func (di *DefaultDepInjector) getResolver(depType types.DataType) func() (any, error) {
+	return func() (any, error) {
+		return CatchResolutionError(depType, func() any {
+			return di.getDependency(depType)
+		})
+	}
+}
+
+func (di *DefaultDepInjector) getKeyedResolver(depType types.DataType, key any) func() (any, error) {
+	return func() (any, error) {
+		return CatchResolutionError(depType, func() any {
+			return getKeyedComponent(di.componentProvider, depType, key)
+		})
+	}
+}
+
 func (di *DefaultDepInjector) callMethodWithDepInjection(method FreeStyleMethod) []any {
+	// call generated factory method directly, see cmd/depgen
+	if generated, ok := method.(*generatedFactory); ok {
+		return generated.call(di.getDependency)
+	}
 	// call method with dependency injection
 	outputs := types.ToFunc(method).Call(func(index int, argType types.DataType) any {
 		return di.getDependency(argType)
@@ -68,7 +124,7 @@ func (di *DefaultDepInjector) handleActionMethodOutputs(actionName string, outpu
 				panic(fmt.Errorf("action %s should only return error or nothing, actual type: %s", actionName, types.Of(outputs[0]).FullName()))
 			}
 			if err != nil {
-				panic(fmt.Errorf("action %s error: %v", actionName, err))
+				panic(NewActionError(actionName, err))
 			}
 		}
 	}
@@ -80,20 +136,20 @@ func (di *DefaultDepInjector) ExecuteActionFunc(processorMethod FreeStyleProcess
 }
 func (di *DefaultDepInjector) handleFactoryMethodOutputs(compType types.DataType, outputs []any) any {
 	if len(outputs) > 2 {
-		panic(fmt.Errorf("dependency(%s) factory method should return no more than 2 outputs: %d", compType.FullName(), len(outputs)))
+		panic(NewFactoryError(compType, nil, "dependency(%s) factory method should return no more than 2 outputs: %d", compType.FullName(), len(outputs)))
 	}
 	if len(outputs) >= 2 {
 		if outputs[1] != nil {
 			err := outputs[1].(error)
 			if err != nil {
-				panic(fmt.Errorf("dependency(%s) factory method error: %v", compType.FullName(), err))
+				panic(NewFactoryError(compType, err, "dependency(%s) factory method error: %v", compType.FullName(), err))
 			}
 		}
 	}
 	if len(outputs) >= 1 {
 		return outputs[0]
 	} else {
-		panic(fmt.Errorf("dependency(%s) factory method should return at least one output: %d", compType.FullName(), len(outputs)))
+		panic(NewFactoryError(compType, nil, "dependency(%s) factory method should return at least one output: %d", compType.FullName(), len(outputs)))
 	}
 }
 func (di *DefaultDepInjector) BuildComponent(factoryMethod FreeStyleFactoryMethod, compType types.DataType) any {
@@ -101,3 +157,24 @@ func (di *DefaultDepInjector) BuildComponent(factoryMethod FreeStyleFactoryMetho
 	outputs := di.callMethodWithDepInjection(factoryMethod)
 	return di.handleFactoryMethodOutputs(compType, outputs)
 }
+
+func (di *DefaultDepInjector) BuildStruct(structType types.DataType) any {
+	values := make(map[int]any)
+	for _, field := range GetInjectedFields(structType) {
+		if !field.Optional {
+			values[field.Index] = di.getDependency(field.Type)
+			continue
+		}
+		// optional field is kept as zero value if absent
+		value, err := di.getResolver(field.Type)()
+		if err != nil {
+			if isNotRegistered(err, field.Type) {
+				continue
+			}
+			// registered but failed to create, or its own dependencies missing
+			panic(err)
+		}
+		values[field.Index] = value
+	}
+	return types.NewStruct(structType, values)
+}
@@ -0,0 +1,115 @@
+package dep
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+// components implementing Disposable or io.Closer are disposed automatically when the owner scope ends:
+// - singleton: disposed on host shutdown
+// - scoped: disposed when the matched scope is disposed
+// - transient: disposed when the scope it is resolved from is disposed, transient resolved from global scope is owned by the caller
+//
+// instances supplied by getter, e.g. RegisterInstance, are owned by the caller and never disposed by the container
+type Disposable interface {
+	Dispose() error
+}
+
+// implemented by getters, whose instances are created by the caller instead of the container
+type suppliedInstance interface {
+	suppliedInstance()
+}
+
+func (tg TypedGetter[T]) suppliedInstance()  {}
+func (cg ComponentGetter) suppliedInstance() {}
+
+func isSuppliedInstance(createInstance FreeStyleFactoryMethod) bool {
+	_, ok := createInstance.(suppliedInstance)
+	return ok
+}
+
+func IsDisposable(instance any) bool {
+	switch instance.(type) {
+	case Disposable, io.Closer:
+		return true
+	default:
+		return false
+	}
+}
+
+// dispose the instance if it is Disposable or io.Closer, panic from the instance is recovered and returned as error
+func DisposeInstance(instance any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic disposing %s: %v", types.Of(instance).FullName(), r)
+		}
+	}()
+
+	switch inst := instance.(type) {
+	case Disposable:
+		err = inst.Dispose()
+	case io.Closer:
+		err = inst.Close()
+	}
+	if err != nil {
+		err = fmt.Errorf("failed disposing %s: %w", types.Of(instance).FullName(), err)
+	}
+	return err
+}
+
+// dispose instances in reverse order, all errors are collected
+func DisposeAll(instances []any) error {
+	errs := make([]error, 0)
+	for i := len(instances) - 1; i >= 0; i-- {
+		if err := DisposeInstance(instances[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return NewAggregateError(errs...)
+}
+
+// error collects multiple failures into one
+type AggregateError struct {
+	Errors []error
+}
+
+// return nil if no error specified
+func NewAggregateError(errs ...error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &AggregateError{Errors: errs}
+}
+
+func (ae *AggregateError) Error() string {
+	if len(ae.Errors) == 1 {
+		return ae.Errors[0].Error()
+	}
+	msgs := make([]string, 0, len(ae.Errors))
+	for _, err := range ae.Errors {
+		msgs = append(msgs, "\t"+err.Error())
+	}
+	return fmt.Sprintf("%d errors occurred:\n%s", len(ae.Errors), strings.Join(msgs, "\n"))
+}
+
+// support errors.Is/As against inner errors, errors of go1.18 does not unwrap multiple errors
+func (ae *AggregateError) Is(target error) bool {
+	for _, err := range ae.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+func (ae *AggregateError) As(target any) bool {
+	for _, err := range ae.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
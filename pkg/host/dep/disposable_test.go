@@ -0,0 +1,226 @@
+package dep
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+type DisposeRecorder interface {
+	Record(name string)
+	GetRecords() []string
+}
+
+type DefaultDisposeRecorder struct {
+	records []string
+}
+
+func NewDisposeRecorder() *DefaultDisposeRecorder {
+	return &DefaultDisposeRecorder{records: make([]string, 0)}
+}
+func (dr *DefaultDisposeRecorder) Record(name string) {
+	dr.records = append(dr.records, name)
+}
+func (dr *DefaultDisposeRecorder) GetRecords() []string {
+	return dr.records
+}
+
+type DisposableFirst interface {
+	First()
+}
+type DisposableSecond interface {
+	Second()
+}
+
+type DisposableStruct struct {
+	name     string
+	recorder DisposeRecorder
+	err      error
+}
+
+func (ds *DisposableStruct) First()  {}
+func (ds *DisposableStruct) Second() {}
+func (ds *DisposableStruct) Dispose() error {
+	ds.recorder.Record(ds.name)
+	return ds.err
+}
+
+type CloserStruct struct {
+	name     string
+	recorder DisposeRecorder
+}
+
+func (cs *CloserStruct) Another()            {}
+func (cs *CloserStruct) GetContext() Context { return nil }
+func (cs *CloserStruct) Close() error {
+	cs.recorder.Record(cs.name)
+	return nil
+}
+
+func NewDisposableFirst(recorder DisposeRecorder, second DisposableSecond) *DisposableStruct {
+	return &DisposableStruct{name: "first", recorder: recorder}
+}
+func NewDisposableSecond(recorder DisposeRecorder) *DisposableStruct {
+	return &DisposableStruct{name: "second", recorder: recorder}
+}
+func NewCloserStruct(recorder DisposeRecorder) *CloserStruct {
+	return &CloserStruct{name: "closer", recorder: recorder}
+}
+
+func expectRecords(t *testing.T, recorder DisposeRecorder, expected ...string) {
+	actual := strings.Join(recorder.GetRecords(), ",")
+	if actual != strings.Join(expected, ",") {
+		t.Errorf("unexpected dispose order, expected - %v, actual - %v", expected, actual)
+	}
+}
+
+func TestComponentManager_Dispose_singleton(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	recorder := NewDisposeRecorder()
+	RegisterInstance[DisposeRecorder](cm, recorder)
+	RegisterSingleton[DisposableSecond](cm, NewDisposableSecond)
+	RegisterSingleton[DisposableFirst](cm, NewDisposableFirst)
+
+	_ = GetComponentFrom[DisposableFirst](cm, ctxt, nil)
+	expectRecords(t, recorder)
+
+	err := cm.Dispose()
+	if err != nil {
+		t.Errorf("unexpected dispose error: %v", err)
+	}
+	expectRecords(t, recorder, "first", "second")
+
+	// disposed instances are not tracked any more
+	_ = cm.Dispose()
+	expectRecords(t, recorder, "first", "second")
+}
+
+func TestComponentManager_Dispose_scoped(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	recorder := NewDisposeRecorder()
+	RegisterInstance[DisposeRecorder](cm, recorder)
+	RegisterScoped[DisposableSecond, any](cm, NewDisposableSecond)
+	RegisterTransient[DisposableFirst](cm, NewDisposableFirst)
+	RegisterTransient[AnotherInterface](cm, NewCloserStruct)
+
+	scopeFactory := GetComponentFrom[ScopeFactory](cm, ctxt, nil)
+	scope := scopeFactory.CreateScope(ctxt, nil)
+	scope.Execute("Resolve", func(first DisposableFirst, another AnotherInterface) {})
+	expectRecords(t, recorder)
+
+	err := scope.Dispose()
+	if err != nil {
+		t.Errorf("unexpected dispose error: %v", err)
+	}
+	expectRecords(t, recorder, "closer", "first", "second")
+
+	// transient resolved from global scope is owned by the caller
+	_ = GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	_ = cm.Dispose()
+	expectRecords(t, recorder, "closer", "first", "second")
+}
+
+func TestComponentManager_Dispose_supplied(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	recorder := NewDisposeRecorder()
+	RegisterInstance[DisposeRecorder](cm, recorder)
+	// instances supplied by the caller are owned by the caller
+	RegisterInstance[DisposableSecond](cm, &DisposableStruct{name: "second", recorder: recorder})
+	RegisterScoped[AnotherInterface, any](cm, Getter[AnotherInterface](&CloserStruct{name: "closer", recorder: recorder}))
+	RegisterSingleton[DisposableFirst](cm, NewDisposableFirst)
+
+	scopeFactory := GetComponentFrom[ScopeFactory](cm, ctxt, nil)
+	scope := scopeFactory.CreateScope(ctxt, nil)
+	scope.Execute("Resolve", func(first DisposableFirst, another AnotherInterface) {})
+	_ = scope.Dispose()
+	expectRecords(t, recorder)
+
+	if err := cm.Dispose(); err != nil {
+		t.Errorf("unexpected dispose error: %v", err)
+	}
+	expectRecords(t, recorder, "first")
+}
+
+func TestComponentManager_Dispose_errors(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	recorder := NewDisposeRecorder()
+	errFirst := fmt.Errorf("first failed")
+	RegisterInstance[DisposeRecorder](cm, recorder)
+	RegisterSingleton[DisposableSecond](cm, func(recorder DisposeRecorder) DisposableSecond {
+		return &DisposableStruct{name: "second", recorder: recorder, err: fmt.Errorf("second failed")}
+	})
+	RegisterSingleton[DisposableFirst](cm, func(recorder DisposeRecorder, second DisposableSecond) DisposableFirst {
+		return &DisposableStruct{name: "first", recorder: recorder, err: errFirst}
+	})
+
+	_ = GetComponentFrom[DisposableFirst](cm, ctxt, nil)
+
+	err := cm.Dispose()
+	expectRecords(t, recorder, "first", "second")
+
+	var aggErr *AggregateError
+	if !errors.As(err, &aggErr) {
+		t.Fatalf("aggregated error expected, actual: %v", err)
+	}
+	if len(aggErr.Errors) != 2 {
+		t.Errorf("expect 2 errors collected, actual: %d", len(aggErr.Errors))
+	}
+	if !errors.Is(err, errFirst) {
+		t.Errorf("inner error is not reported: %v", err)
+	}
+}
+
+type DisposeError struct {
+	Name string
+}
+
+func (de *DisposeError) Error() string { return "failed to dispose " + de.Name }
+
+func TestAggregateError_Is_As(t *testing.T) {
+	errFirst := errors.New("first")
+	err := NewAggregateError(errFirst, fmt.Errorf("wrapped: %w", &DisposeError{Name: "second"}))
+	if !errors.Is(err, errFirst) {
+		t.Errorf("inner error should be matched: %v", err)
+	}
+	var disposeErr *DisposeError
+	if !errors.As(err, &disposeErr) || disposeErr.Name != "second" {
+		t.Errorf("wrapped inner error should be matched: %v", err)
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) || errors.Is(err, errors.New("first")) {
+		t.Errorf("errors not aggregated should not be matched: %v", err)
+	}
+}
+
+func TestDisposeInstance_panic(t *testing.T) {
+	err := DisposeInstance(&PanicDisposable{})
+	if err == nil || !strings.Contains(err.Error(), "panic disposing") {
+		t.Errorf("panic from disposable should be returned as error: %v", err)
+	}
+
+	if DisposeInstance(&AnotherStruct{}) != nil {
+		t.Errorf("non-disposable instance should be ignored")
+	}
+	if IsDisposable(types.Get[logger.Logger]()) {
+		t.Errorf("data type should not be disposable")
+	}
+}
+
+type PanicDisposable struct{}
+
+func (pd *PanicDisposable) Dispose() error {
+	panic("dispose panic")
+}
@@ -0,0 +1,170 @@
+package dep
+
+import (
+	"errors"
+	"fmt"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+// errors raised during resolving components, they are raised as panic by GetComponent/GetComponentFrom,
+// and returned by TryGetComponent/ResolveComponent which callers can inspect with errors.As
+type ResolutionError interface {
+	error
+
+	GetComponentType() types.DataType
+}
+
+type resolutionError struct {
+	ComponentType types.DataType
+	message       string
+}
+
+func newResolutionError(componentType types.DataType, format string, args ...any) resolutionError {
+	return resolutionError{
+		ComponentType: componentType,
+		message:       fmt.Sprintf(format, args...),
+	}
+}
+
+func (re *resolutionError) Error() string {
+	return re.message
+}
+func (re *resolutionError) GetComponentType() types.DataType {
+	return re.ComponentType
+}
+
+// requested component or configuration type is not registered
+type ComponentNotRegisteredError struct {
+	resolutionError
+	// key of keyed component registered by RegisterKeyed, nil otherwise
+	Key any
+}
+
+func NewComponentNotRegisteredError(componentType types.DataType) *ComponentNotRegisteredError {
+	return &ComponentNotRegisteredError{resolutionError: newResolutionError(componentType, "dependency not configured, type: %v, quit", componentType.FullName())}
+}
+
+func NewKeyedComponentNotRegisteredError(componentType types.DataType, key any) *ComponentNotRegisteredError {
+	return &ComponentNotRegisteredError{
+		resolutionError: newResolutionError(componentType, "keyed dependency not configured, type: %v, key: %v, quit", componentType.FullName(), key),
+		Key:             key,
+	}
+}
+
+// requested type or created instance type violates type constraints
+type TypeConstraintError struct {
+	resolutionError
+}
+
+func NewTypeConstraintError(componentType types.DataType, format string, args ...any) *TypeConstraintError {
+	return &TypeConstraintError{newResolutionError(componentType, format, args...)}
+}
+
+// cyclic dependency detected, or recursive dependency overflow on transient component
+type CyclicDependencyError struct {
+	resolutionError
+}
+
+func NewCyclicDependencyError(componentType types.DataType, format string, args ...any) *CyclicDependencyError {
+	return &CyclicDependencyError{newResolutionError(componentType, format, args...)}
+}
+
+// longer-lived component captures shorter-lived one
+type CaptiveDependencyError struct {
+	resolutionError
+	Chain []LifetimeFrame
+}
+
+func NewCaptiveDependencyError(chain []LifetimeFrame, format string, args ...any) *CaptiveDependencyError {
+	return &CaptiveDependencyError{
+		resolutionError: newResolutionError(chain[len(chain)-1].ComponentType, format, args...),
+		Chain:           chain,
+	}
+}
+
+// no scope of required type is found for scoped component
+type ScopeMismatchError struct {
+	resolutionError
+	ScopeType types.DataType
+}
+
+func NewScopeMismatchError(componentType types.DataType, scopeType types.DataType, format string, args ...any) *ScopeMismatchError {
+	return &ScopeMismatchError{
+		resolutionError: newResolutionError(componentType, format, args...),
+		ScopeType:       scopeType,
+	}
+}
+
+// factory method failed to create the component, including returning error, returning invalid outputs and panic
+type FactoryError struct {
+	resolutionError
+	Err error
+}
+
+func NewFactoryError(componentType types.DataType, err error, format string, args ...any) *FactoryError {
+	return &FactoryError{
+		resolutionError: newResolutionError(componentType, format, args...),
+		Err:             err,
+	}
+}
+
+func (fe *FactoryError) Unwrap() error {
+	return fe.Err
+}
+
+// action method returned error, raised as panic by the action method built by LifecycleController
+type ActionError struct {
+	Action string
+	Err    error
+}
+
+func NewActionError(action string, err error) *ActionError {
+	return &ActionError{
+		Action: action,
+		Err:    err,
+	}
+}
+
+func (ae *ActionError) Error() string {
+	return fmt.Sprintf("action %s error: %v", ae.Action, ae.Err)
+}
+func (ae *ActionError) Unwrap() error {
+	return ae.Err
+}
+
+// resolve component and recover panic as error, panic not raised as ResolutionError is wrapped by FactoryError
+func CatchResolutionError(componentType types.DataType, resolve func() any) (instance any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			instance = nil
+			err = toResolutionError(componentType, r)
+		}
+	}()
+	return resolve(), nil
+}
+
+func toResolutionError(componentType types.DataType, r any) error {
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("%v", r)
+	}
+	var resolutionErr ResolutionError
+	if errors.As(err, &resolutionErr) {
+		return err
+	}
+	return NewFactoryError(componentType, err, "dependency(%s) factory method panic: %v", componentType.FullName(), err)
+}
+
+// convert resolved instance to requested type, zero value of T is returned along with error
+func castResolved[T any](instance any, err error) (T, error) {
+	var zero T
+	if err != nil {
+		return zero, err
+	}
+	result, ok := instance.(T)
+	if !ok {
+		return zero, NewTypeConstraintError(types.Get[T](), "created component instance type does not match, instance type: %v, requested type: %v", types.Of(instance).FullName(), types.Get[T]().FullName())
+	}
+	return result, nil
+}
@@ -0,0 +1,117 @@
+package dep
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+func TestTryGetComponent(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	AddConfig[MyConfig](cm, &MyConfig{value: 123})
+	RegisterTransient[AnotherInterface](cm, NewAnotherStruct)
+
+	ano, err := TryGetComponent[AnotherInterface](ctxt)
+	if err != nil || ano == nil {
+		t.Errorf("component should be resolved, error: %v", err)
+	}
+	ano, err = ResolveComponent[AnotherInterface](cm, ctxt, nil)
+	if err != nil || ano == nil {
+		t.Errorf("component should be resolved, error: %v", err)
+	}
+	config, err := TryGetConfig[MyConfig](ctxt)
+	if err != nil || config.value != 123 {
+		t.Errorf("configuration should be resolved, error: %v", err)
+	}
+	config, err = ResolveConfig[MyConfig](cm, ctxt)
+	if err != nil || config.value != 123 {
+		t.Errorf("configuration should be resolved, error: %v", err)
+	}
+}
+
+func TestTryGetComponent_not_registered(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterTransient[FirstInterface](cm, func(another AnotherInterface) *ActualStruct { return NewActualStruct() })
+
+	first, err := TryGetComponent[FirstInterface](ctxt)
+	var notRegistered *ComponentNotRegisteredError
+	if !errors.As(err, &notRegistered) || first != nil {
+		t.Fatalf("not registered error expected, actual: %v", err)
+	}
+	// the missing dependency is reported
+	if notRegistered.ComponentType.Key() != types.Get[AnotherInterface]().Key() {
+		t.Errorf("unexpected component type: %s", notRegistered.ComponentType.FullName())
+	}
+
+	_, err = ResolveConfig[MyConfig](cm, ctxt)
+	if !errors.As(err, &notRegistered) {
+		t.Errorf("not registered error expected, actual: %v", err)
+	}
+}
+
+func TestTryGetComponent_type_constraint(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	_, err := ResolveComponent[*ActualStruct](cm, ctxt, nil)
+	var constraintErr *TypeConstraintError
+	if !errors.As(err, &constraintErr) {
+		t.Errorf("type constraint error expected, actual: %v", err)
+	}
+}
+
+func TestTryGetComponent_cyclic(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterSingleton[FirstInterface](cm, NewFirstDepOnSecond)
+	RegisterSingleton[SecondInterface](cm, NewSecondDepOnFirst)
+
+	_, err := TryGetComponent[FirstInterface](ctxt)
+	var cyclicErr *CyclicDependencyError
+	if !errors.As(err, &cyclicErr) {
+		t.Errorf("cyclic dependency error expected, actual: %v", err)
+	}
+}
+
+func TestTryGetComponent_scope_mismatch(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithScope(options, ScopeTest)
+
+	RegisterScoped[AnotherInterface, SmallScope](cm, NewAnotherStruct)
+
+	_, err := TryGetComponent[AnotherInterface](ctxt)
+	var mismatchErr *ScopeMismatchError
+	if !errors.As(err, &mismatchErr) {
+		t.Fatalf("scope mismatch error expected, actual: %v", err)
+	}
+	if mismatchErr.ScopeType.Key() != ScopeSmall.Key() {
+		t.Errorf("unexpected scope type: %s", mismatchErr.ScopeType.Name())
+	}
+}
+
+func TestTryGetComponent_factory_error(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	errCreate := fmt.Errorf("failed to create instance")
+	RegisterTransient[FirstInterface](cm, func() (*ActualStruct, error) { return nil, errCreate })
+	RegisterTransient[SecondInterface](cm, func() *ActualStruct { panic("factory panic") })
+
+	_, err := TryGetComponent[FirstInterface](ctxt)
+	var factoryErr *FactoryError
+	if !errors.As(err, &factoryErr) || !errors.Is(err, errCreate) {
+		t.Errorf("factory error expected, actual: %v", err)
+	}
+
+	_, err = TryGetComponent[SecondInterface](ctxt)
+	if !errors.As(err, &factoryErr) {
+		t.Errorf("panic from factory method should be returned as factory error, actual: %v", err)
+	}
+}
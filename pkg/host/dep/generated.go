@@ -0,0 +1,93 @@
+package dep
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+// typed call of constructor generated by cmd/depgen, which calls the constructor directly instead of reflect.Value.Call,
+// arguments are resolved by parameter types of the constructor in parameter order
+type GeneratedFactoryMethod func(args []any) (any, error)
+
+type generatedFactoryRegistry struct {
+	mutex     sync.RWMutex
+	factories map[uintptr]GeneratedFactoryMethod
+}
+
+var generatedFactories = &generatedFactoryRegistry{
+	factories: make(map[uintptr]GeneratedFactoryMethod),
+}
+
+// register generated factory method of the constructor, called by init() of code generated by cmd/depgen.
+// component registered with the constructor is created by the generated factory method with identical lifetime semantics
+func RegisterGeneratedFactory(constructor FreeStyleFactoryMethod, factory GeneratedFactoryMethod) {
+	if factory == nil {
+		panic(fmt.Errorf("generated factory method is nil: %s", getMethodName(constructor)))
+	}
+	key := getConstructorKey(constructor)
+
+	defer generatedFactories.mutex.Unlock()
+	generatedFactories.mutex.Lock()
+	generatedFactories.factories[key] = factory
+}
+
+// get argument of generated factory method, nil argument is converted to zero value of T
+func Arg[T any](args []any, index int) T {
+	value, _ := args[index].(T)
+	return value
+}
+
+// constructor is identified by its code pointer, generated factory is for top level function only
+func getConstructorKey(constructor FreeStyleFactoryMethod) uintptr {
+	if constructor == nil || !types.Of(constructor).IsFunc() {
+		panic(fmt.Errorf("constructor of generated factory method should be func: %v", constructor))
+	}
+	return reflect.ValueOf(constructor).Pointer()
+}
+
+func getGeneratedFactory(constructor FreeStyleFactoryMethod) (GeneratedFactoryMethod, bool) {
+	defer generatedFactories.mutex.RUnlock()
+	generatedFactories.mutex.RLock()
+	factory, exist := generatedFactories.factories[getConstructorKey(constructor)]
+	return factory, exist
+}
+
+// free style factory method which is called by DepInjector without reflection
+type generatedFactory struct {
+	constructor FreeStyleFactoryMethod
+	paramTypes  []types.DataType
+	create      GeneratedFactoryMethod
+}
+
+func (gf *generatedFactory) call(getArgument func(argType types.DataType) any) []any {
+	args := make([]any, 0, len(gf.paramTypes))
+	for _, paramType := range gf.paramTypes {
+		args = append(args, getArgument(paramType))
+	}
+	instance, err := gf.create(args)
+	return []any{instance, err}
+}
+
+// use generated factory method of the constructor if registered, parameter types are resolved once here instead of on each call
+func compileFactoryMethod(factoryMethod FreeStyleFactoryMethod) FreeStyleFactoryMethod {
+	if factoryMethod == nil || !types.Of(factoryMethod).IsFunc() {
+		return factoryMethod
+	}
+	create, exist := getGeneratedFactory(factoryMethod)
+	if !exist {
+		return factoryMethod
+	}
+	funcType := types.GetFuncType(factoryMethod)
+	paramTypes := make([]types.DataType, 0, funcType.GetNumOfInput())
+	for i := 0; i < funcType.GetNumOfInput(); i++ {
+		paramTypes = append(paramTypes, funcType.GetInput(i))
+	}
+	return &generatedFactory{
+		constructor: factoryMethod,
+		paramTypes:  paramTypes,
+		create:      create,
+	}
+}
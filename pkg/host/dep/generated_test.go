@@ -0,0 +1,106 @@
+package dep
+
+import (
+	"errors"
+	"testing"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+var generatedCalls int
+
+func newGeneratedAnother(context Context, config *MyConfig) *AnotherStruct {
+	inst := NewAnotherStruct(context)
+	inst.value = config.value
+	return inst
+}
+
+var errGeneratedFactory = errors.New("generated factory failure")
+
+func newGeneratedFailure(another AnotherInterface) (*ActualStruct, error) {
+	return nil, errGeneratedFactory
+}
+
+func init() {
+	RegisterGeneratedFactory(newGeneratedAnother, func(args []any) (any, error) {
+		generatedCalls++
+		return newGeneratedAnother(Arg[Context](args, 0), Arg[*MyConfig](args, 1)), nil
+	})
+	RegisterGeneratedFactory(newGeneratedFailure, func(args []any) (any, error) {
+		return newGeneratedFailure(Arg[AnotherInterface](args, 0))
+	})
+}
+
+func TestGeneratedFactory(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	AddConfig[MyConfig](cm, &MyConfig{value: 123})
+	RegisterSingleton[AnotherInterface](cm, newGeneratedAnother)
+
+	generatedCalls = 0
+	inst := GetComponentFrom[AnotherInterface](cm, ctxt, nil).(*AnotherStruct)
+	if inst != GetComponentFrom[AnotherInterface](cm, ctxt, nil) || generatedCalls != 1 {
+		t.Errorf("singleton should be created once by generated factory method, calls: %d", generatedCalls)
+	}
+	if inst.value != 123 || inst.context == nil || inst.context.Name() != "dep.AnotherInterface" {
+		t.Errorf("arguments of generated factory method should be injected")
+	}
+}
+
+func TestGeneratedFactory_transient(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	AddConfig[MyConfig](cm, &MyConfig{value: 123})
+	RegisterTransient[AnotherInterface](cm, newGeneratedAnother)
+
+	generatedCalls = 0
+	if GetComponentFrom[AnotherInterface](cm, ctxt, nil) == GetComponentFrom[AnotherInterface](cm, ctxt, nil) || generatedCalls != 2 {
+		t.Errorf("transient should be created on each resolution by generated factory method, calls: %d", generatedCalls)
+	}
+}
+
+func TestGeneratedFactory_error(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+	RegisterTransient[FirstInterface](cm, newGeneratedFailure)
+
+	_, err := CatchResolutionError(types.Get[FirstInterface](), func() any {
+		return GetComponentFrom[FirstInterface](cm, ctxt, nil)
+	})
+	var factoryErr *FactoryError
+	if !errors.As(err, &factoryErr) || !errors.Is(err, errGeneratedFactory) {
+		t.Errorf("error of generated factory method should be returned as factory error, actual: %v", err)
+	}
+}
+
+func BenchmarkTransient_reflection(b *testing.B) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptionsEx(options, false, nil)
+
+	AddConfig[MyConfig](cm, &MyConfig{value: 123})
+	RegisterTransient[AnotherInterface](cm, func(context Context, config *MyConfig) *AnotherStruct {
+		return newGeneratedAnother(context, config)
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	}
+}
+
+func BenchmarkTransient_generated(b *testing.B) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptionsEx(options, false, nil)
+
+	AddConfig[MyConfig](cm, &MyConfig{value: 123})
+	RegisterTransient[AnotherInterface](cm, newGeneratedAnother)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	}
+}
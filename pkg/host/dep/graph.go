@@ -0,0 +1,445 @@
+package dep
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+const (
+	// edge from component to the type of its factory method or decorator parameter, the kind is one of ParamKind
+	GraphEdge_Configuration = "Configuration"
+	// edge from component type registered by RegisterMany or RegisterComponent to its implementations
+	GraphEdge_Implementation = "Implementation"
+)
+
+// node of dependency graph, which is a registration, or a dependency which is not registered
+type GraphNode struct {
+	Id              string   `json:"id"`
+	ComponentType   string   `json:"componentType"`
+	Lifetime        string   `json:"lifetime,omitempty"`
+	ScopeType       string   `json:"scopeType,omitempty"`
+	IsConfiguration bool     `json:"isConfiguration,omitempty"`
+	HubKey          string   `json:"hubKey,omitempty"`
+	Key             string   `json:"key,omitempty"`
+	Decorators      []string `json:"decorators,omitempty"`
+	// registered in parent of child component manager
+	Inherited bool `json:"inherited,omitempty"`
+	// dependency is not registered
+	Missing bool `json:"missing,omitempty"`
+}
+
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	// parameter index of factory method or decorator, or field index of struct registered by RegisterStruct,
+	// -1 for implementation edge
+	Parameter int    `json:"parameter"`
+	Field     string `json:"field,omitempty"`
+	// name of decorator which introduces the dependency
+	Decorator string `json:"decorator,omitempty"`
+}
+
+// component instance which exists at runtime, dependents are tracked in Debug mode only
+type GraphInstance struct {
+	Id            string   `json:"id"`
+	NodeId        string   `json:"nodeId"`
+	ComponentType string   `json:"componentType"`
+	Lifetime      string   `json:"lifetime"`
+	ScopeId       string   `json:"scopeId"`
+	Dependents    []string `json:"dependents"`
+}
+
+// registration graph of component manager, instances are exported by runtime variant only
+type DependencyGraph struct {
+	Nodes     []*GraphNode     `json:"nodes"`
+	Edges     []*GraphEdge     `json:"edges"`
+	Instances []*GraphInstance `json:"instances,omitempty"`
+}
+
+func (dg *DependencyGraph) GetNode(id string) *GraphNode {
+	for _, node := range dg.Nodes {
+		if node.Id == id {
+			return node
+		}
+	}
+	return nil
+}
+
+func (dg *DependencyGraph) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(dg, "", "  ")
+}
+
+// Graphviz DOT format, e.g. render by `dot -Tsvg graph.dot -o graph.svg`
+func (dg *DependencyGraph) ToDOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph dependencies {\n")
+	sb.WriteString("\trankdir=LR;\n")
+	sb.WriteString("\tnode [shape=box];\n")
+	for _, node := range dg.Nodes {
+		sb.WriteString(fmt.Sprintf("\t%q [label=%q%s];\n", node.Id, node.label(), node.style()))
+	}
+	for _, edge := range dg.Edges {
+		sb.WriteString(fmt.Sprintf("\t%q -> %q [label=%q%s];\n", edge.From, edge.To, edge.label(), edge.style()))
+	}
+	for _, instance := range dg.Instances {
+		sb.WriteString(fmt.Sprintf("\t%q [label=%q, shape=ellipse];\n", instance.Id, fmt.Sprintf("%s\n%s", instance.ComponentType, instance.ScopeId)))
+		if instance.NodeId != "" {
+			sb.WriteString(fmt.Sprintf("\t%q -> %q [style=dotted, arrowhead=empty];\n", instance.Id, instance.NodeId))
+		}
+		for _, dependent := range instance.Dependents {
+			sb.WriteString(fmt.Sprintf("\t%q -> %q [color=blue];\n", dependent, instance.Id))
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func (gn *GraphNode) label() string {
+	lines := []string{gn.Id}
+	if gn.IsConfiguration {
+		lines = append(lines, GraphEdge_Configuration)
+	} else if gn.Lifetime != "" {
+		lifetime := gn.Lifetime
+		if gn.ScopeType != "" {
+			lifetime = fmt.Sprintf("%s@%s", lifetime, gn.ScopeType)
+		}
+		lines = append(lines, lifetime)
+	}
+	for _, decorator := range gn.Decorators {
+		lines = append(lines, fmt.Sprintf("decorated by %s", decorator))
+	}
+	return strings.Join(lines, "\n")
+}
+func (gn *GraphNode) style() string {
+	switch {
+	case gn.Missing:
+		return ", color=red, style=dashed"
+	case gn.Inherited:
+		return ", style=dashed"
+	case gn.IsConfiguration:
+		return ", shape=note"
+	default:
+		return ""
+	}
+}
+
+func (ge *GraphEdge) label() string {
+	switch {
+	case ge.Kind == GraphEdge_Implementation:
+		return ""
+	case ge.Decorator != "":
+		return fmt.Sprintf("%s(%s)", ge.Kind, ge.Decorator)
+	case ge.Field != "":
+		return fmt.Sprintf("%s(%s)", ge.Kind, ge.Field)
+	default:
+		return fmt.Sprintf("%s(%d)", ge.Kind, ge.Parameter)
+	}
+}
+func (ge *GraphEdge) style() string {
+	switch ge.Kind {
+	case ParamKind_Optional.String():
+		return ", style=dashed"
+	case ParamKind_Lazy.String():
+		return ", style=dotted"
+	case ParamKind_Many.String():
+		return ", style=bold"
+	case ParamKind_Keyed.String():
+		return ", color=darkgreen"
+	case GraphEdge_Implementation:
+		return ", arrowhead=empty"
+	default:
+		return ""
+	}
+}
+
+// builds graph from registrations statically, edges are derived from parameter types of factory methods and decorators
+type graphBuilder struct {
+	registrations RegistrationReader
+	graph         *DependencyGraph
+	nodes         map[string]*GraphNode
+	decorated     map[interface{}]bool
+	contextual    map[interface{}]bool
+}
+
+func newGraphBuilder(registrations RegistrationReader) *graphBuilder {
+	builder := &graphBuilder{
+		registrations: registrations,
+		graph: &DependencyGraph{
+			Nodes: make([]*GraphNode, 0),
+			Edges: make([]*GraphEdge, 0),
+		},
+		nodes:      make(map[string]*GraphNode),
+		decorated:  make(map[interface{}]bool),
+		contextual: make(map[interface{}]bool),
+	}
+	for _, depType := range registrations.GetContextualTypes() {
+		builder.contextual[depType.Key()] = true
+	}
+	return builder
+}
+
+// nodes of all registrations are added before edges, so that dependencies not registered are distinguished
+func (gb *graphBuilder) Build() *DependencyGraph {
+	registrations := gb.registrations.GetAllRegistrations()
+	for _, registration := range registrations {
+		gb.addRegistration(registration, false)
+	}
+	for _, registration := range registrations {
+		gb.addDependencies(registration)
+		if registration.Key != nil {
+			continue
+		}
+		if !gb.decorated[registration.ComponentType.Key()] {
+			gb.decorated[registration.ComponentType.Key()] = true
+			gb.addDecorators(registration.ComponentType)
+		}
+		if registration.IsMulti {
+			continue
+		}
+		for _, impl := range gb.registrations.GetHubImplementations(registration.ComponentType) {
+			gb.addDependencies(impl)
+		}
+	}
+	return gb.graph
+}
+
+func (gb *graphBuilder) addNode(node *GraphNode) *GraphNode {
+	if exist, ok := gb.nodes[node.Id]; ok {
+		return exist
+	}
+	gb.nodes[node.Id] = node
+	gb.graph.Nodes = append(gb.graph.Nodes, node)
+	return node
+}
+
+// multiple implementations share the node of component type, which links to the implementations,
+// keyed registration has its own node independent of the component type
+func (gb *graphBuilder) addRegistration(registration *ComponentRegistration, inherited bool) {
+	componentType := registration.ComponentType
+	if registration.Key != nil {
+		gb.addNode(gb.newNode(registration, inherited))
+		return
+	}
+	if _, exist := gb.nodes[componentType.FullName()]; !exist {
+		if registration.IsMulti {
+			gb.addNode(&GraphNode{Id: componentType.FullName(), ComponentType: componentType.FullName(), Inherited: inherited})
+		} else {
+			gb.addNode(gb.newNode(registration, inherited))
+			for _, impl := range gb.registrations.GetHubImplementations(componentType) {
+				gb.addImplementation(impl, inherited)
+			}
+		}
+	}
+	if registration.IsMulti {
+		gb.addImplementation(registration, inherited)
+	}
+}
+func (gb *graphBuilder) addImplementation(registration *ComponentRegistration, inherited bool) {
+	gb.addNode(gb.newNode(registration, inherited))
+	gb.graph.Edges = append(gb.graph.Edges, &GraphEdge{
+		From:      registration.ComponentType.FullName(),
+		To:        registration.Name(),
+		Kind:      GraphEdge_Implementation,
+		Parameter: -1,
+	})
+}
+
+func (gb *graphBuilder) newNode(registration *ComponentRegistration, inherited bool) *GraphNode {
+	node := &GraphNode{
+		Id:              registration.Name(),
+		ComponentType:   registration.ComponentType.FullName(),
+		IsConfiguration: registration.IsConfiguration,
+		Inherited:       inherited,
+	}
+	if !registration.IsConfiguration {
+		node.Lifetime = registration.Lifetime.String()
+	}
+	if registration.Lifetime == Lifetime_Scoped && registration.ScopeType != nil {
+		node.ScopeType = registration.ScopeType.Name()
+	}
+	if registration.HubKey != nil {
+		node.HubKey = fmt.Sprint(registration.HubKey)
+	}
+	if registration.Key != nil {
+		node.Key = fmt.Sprint(registration.Key)
+	}
+	return node
+}
+
+func (gb *graphBuilder) addDependencies(registration *ComponentRegistration) {
+	for _, param := range registration.GetParameters() {
+		gb.addDependency(registration.Name(), param, "")
+	}
+}
+
+// decorators wrap each implementation of the component type
+func (gb *graphBuilder) addDecorators(componentType types.DataType) {
+	node := gb.nodes[componentType.FullName()]
+	for _, decorator := range gb.registrations.GetDecorators(componentType) {
+		node.Decorators = append(node.Decorators, decorator.String())
+		for _, param := range decorator.GetParameters() {
+			gb.addDependency(node.Id, param, decorator.String())
+		}
+	}
+}
+func (gb *graphBuilder) addDependency(from string, param *Parameter, decorator string) {
+	paramType := param.Type
+	kind := param.Kind.String()
+	if paramType.IsPtr() {
+		// config type is registered as struct type but used as pointer of struct
+		paramType = paramType.ElementType()
+		kind = GraphEdge_Configuration
+	} else if gb.contextual[paramType.Key()] && param.Kind != ParamKind_Keyed {
+		return
+	}
+	var to *GraphNode
+	if param.Kind == ParamKind_Keyed {
+		to = gb.resolveKeyedNode(paramType, param.Key)
+	} else {
+		to = gb.resolveNode(paramType)
+	}
+	gb.graph.Edges = append(gb.graph.Edges, &GraphEdge{
+		From:      from,
+		To:        to.Id,
+		Kind:      kind,
+		Parameter: param.Index,
+		Field:     param.Field,
+		Decorator: decorator,
+	})
+}
+
+// dependency registered in parent of child component manager is added as node on first reference
+func (gb *graphBuilder) resolveNode(depType types.DataType) *GraphNode {
+	if node, exist := gb.nodes[depType.FullName()]; exist {
+		return node
+	}
+	if registration := gb.registrations.GetRegistration(depType); registration != nil {
+		if registration.IsMulti {
+			for _, impl := range gb.registrations.GetImplementations(depType) {
+				gb.addRegistration(impl, true)
+			}
+		} else {
+			gb.addRegistration(registration, true)
+		}
+		return gb.nodes[depType.FullName()]
+	}
+	return gb.addNode(&GraphNode{Id: depType.FullName(), ComponentType: depType.FullName(), Missing: true})
+}
+
+func (gb *graphBuilder) resolveKeyedNode(depType types.DataType, key any) *GraphNode {
+	name := getKeyedName(depType, key)
+	if node, exist := gb.nodes[name]; exist {
+		return node
+	}
+	if registration := gb.registrations.GetKeyedRegistration(depType, key); registration != nil {
+		return gb.addNode(gb.newNode(registration, true))
+	}
+	return gb.addNode(&GraphNode{Id: name, ComponentType: depType.FullName(), Key: fmt.Sprint(key), Missing: true})
+}
+
+// component instance created by lifecycle controller
+type InstanceRecord struct {
+	ComponentType types.DataType
+	Lifetime      Lifetime
+	// dependents of the instance are tracked by the context in Debug mode
+	Context ContextEx
+}
+
+// implemented by component provider which records singleton and scoped instances for diagnostics
+type InstanceRecorder interface {
+	RecordInstance(compCtxt ContextEx, compType types.DataType, lifetime Lifetime)
+}
+
+// record instance if the context supports it, transient instance is not recorded as it is owned by the dependent
+func RecordInstance(compCtxt ContextEx, compType types.DataType, lifetime Lifetime) {
+	if lifetime == Lifetime_Transient {
+		return
+	}
+	if recorder, ok := compCtxt.(InstanceRecorder); ok {
+		recorder.RecordInstance(compCtxt, compType, lifetime)
+	}
+}
+
+// records of existing instances, record is released when scope of the instance is disposed
+type instanceRegistry struct {
+	mutex   sync.Mutex
+	records []*InstanceRecord
+}
+
+func newInstanceRegistry() *instanceRegistry {
+	return &instanceRegistry{
+		records: make([]*InstanceRecord, 0),
+	}
+}
+
+func (ir *instanceRegistry) Add(record *InstanceRecord) {
+	defer ir.mutex.Unlock()
+	ir.mutex.Lock()
+	ir.records = append(ir.records, record)
+}
+func (ir *instanceRegistry) Remove(record *InstanceRecord) {
+	defer ir.mutex.Unlock()
+	ir.mutex.Lock()
+	for index, exist := range ir.records {
+		if exist == record {
+			ir.records = append(ir.records[:index], ir.records[index+1:]...)
+			return
+		}
+	}
+}
+func (ir *instanceRegistry) GetAll() []*InstanceRecord {
+	defer ir.mutex.Unlock()
+	ir.mutex.Lock()
+	result := make([]*InstanceRecord, 0, len(ir.records))
+	return append(result, ir.records...)
+}
+
+// tracked as disposable in scope of the instance to release its record
+type instanceRecordRelease func()
+
+func (release instanceRecordRelease) Dispose() error {
+	release()
+	return nil
+}
+
+func getInstanceId(record *InstanceRecord) string {
+	return fmt.Sprintf("%s@%p", record.ComponentType.FullName(), record.Context)
+}
+
+// add existing instances to the graph, dependent which is not a recorded instance is named by its context, e.g. Scope[0xc000123456]
+func addGraphInstances(graph *DependencyGraph, records []*InstanceRecord) {
+	ids := make(map[ContextEx]string)
+	for _, record := range records {
+		ids[record.Context] = getInstanceId(record)
+	}
+	graph.Instances = make([]*GraphInstance, 0, len(records))
+	for _, record := range records {
+		instance := &GraphInstance{
+			Id:            ids[record.Context],
+			ComponentType: record.ComponentType.FullName(),
+			Lifetime:      record.Lifetime.String(),
+			ScopeId:       record.Context.GetScopeContext().ScopeId(),
+			Dependents:    make([]string, 0),
+		}
+		if graph.GetNode(instance.ComponentType) != nil {
+			instance.NodeId = instance.ComponentType
+		}
+		exist := make(map[string]bool)
+		for _, dependent := range record.Context.GetTracker().GetDependents() {
+			id, ok := ids[dependent]
+			if !ok {
+				id = fmt.Sprintf("%s[%s]", dependent.Type(), dependent.Name())
+			}
+			if !exist[id] {
+				exist[id] = true
+				instance.Dependents = append(instance.Dependents, id)
+			}
+		}
+		graph.Instances = append(graph.Instances, instance)
+	}
+}
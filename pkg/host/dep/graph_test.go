@@ -0,0 +1,195 @@
+package dep
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+func findEdge(graph *DependencyGraph, from string, to string) *GraphEdge {
+	for _, edge := range graph.Edges {
+		if edge.From == from && edge.To == to {
+			return edge
+		}
+	}
+	return nil
+}
+
+func findInstance(graph *DependencyGraph, componentType string) *GraphInstance {
+	for _, instance := range graph.Instances {
+		if instance.ComponentType == componentType {
+			return instance
+		}
+	}
+	return nil
+}
+
+func TestExportGraph(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	AddConfig[MyConfig](cm, &MyConfig{value: 123})
+	RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+	RegisterScoped[FirstInterface, TestScope](cm, func(another AnotherInterface, config *MyConfig, second Optional[SecondInterface]) *ActualStruct {
+		return NewActualStruct()
+	})
+	RegisterMany[SecondInterface](cm, Lifetime_Transient, func(first Lazy[FirstInterface]) *ActualStruct { return NewActualStruct() })
+	RegisterMany[SecondInterface](cm, Lifetime_Transient, func(seconds []AnotherInterface) *ActualStruct { return NewActualStruct() })
+	Decorate[AnotherInterface](cm, func(inner AnotherInterface, downloader Downloader) AnotherInterface { return inner })
+
+	graph := cm.ExportGraph()
+
+	first := graph.GetNode("dep.FirstInterface")
+	if first == nil || first.Lifetime != "Scoped" || first.ScopeType != "TestScope" {
+		t.Fatalf("unexpected node of scoped component: %+v", first)
+	}
+	if config := graph.GetNode("dep.MyConfig"); config == nil || !config.IsConfiguration {
+		t.Errorf("configuration should be exported as node")
+	}
+	if missing := graph.GetNode("dep.Downloader"); missing == nil || !missing.Missing {
+		t.Errorf("dependency not registered should be exported as missing node")
+	}
+	if another := graph.GetNode("dep.AnotherInterface"); another == nil || len(another.Decorators) != 1 {
+		t.Errorf("decorators should be exported with the decorated node")
+	}
+
+	expectedEdges := []struct {
+		from string
+		to   string
+		kind string
+	}{
+		{"dep.FirstInterface", "dep.AnotherInterface", "Direct"},
+		{"dep.FirstInterface", "dep.MyConfig", GraphEdge_Configuration},
+		{"dep.FirstInterface", "dep.SecondInterface", "Optional"},
+		{"dep.SecondInterface", "dep.SecondInterface#0", GraphEdge_Implementation},
+		{"dep.SecondInterface", "dep.SecondInterface#1", GraphEdge_Implementation},
+		{"dep.SecondInterface#0", "dep.FirstInterface", "Lazy"},
+		{"dep.SecondInterface#1", "dep.AnotherInterface", "Many"},
+		{"dep.AnotherInterface", "dep.Downloader", "Direct"},
+	}
+	for _, expected := range expectedEdges {
+		edge := findEdge(graph, expected.from, expected.to)
+		if edge == nil || edge.Kind != expected.kind {
+			t.Errorf("edge %s -> %s of kind %s is expected, actual: %+v", expected.from, expected.to, expected.kind, edge)
+		}
+	}
+	// contextual dependency is not exported
+	if findEdge(graph, "dep.AnotherInterface", types.Get[Context]().FullName()) != nil {
+		t.Errorf("contextual dependency should not be exported as edge")
+	}
+
+	dot := graph.ToDOT()
+	if !strings.HasPrefix(dot, "digraph dependencies {") || !strings.Contains(dot, `"dep.FirstInterface" -> "dep.MyConfig" [label="Configuration(1)"];`) {
+		t.Errorf("unexpected DOT output: %s", dot)
+	}
+
+	data, err := graph.ToJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded DependencyGraph
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Nodes) != len(graph.Nodes) || len(decoded.Edges) != len(graph.Edges) {
+		t.Errorf("graph should be exported as JSON, err: %v", err)
+	}
+}
+
+func TestExportGraph_component_hub(t *testing.T) {
+	cm, ctxt := prepareComponentManager(true)
+	components, _ := createCollection(ctxt, cm)
+
+	RegisterComponent(
+		components,
+		func(props Properties) string { return GetProp[string](props, "type") },
+		func(comp CompImplCollection[Downloader, string]) {
+			comp.AddSingletonImpl("url", NewUrlDownloader)
+			comp.AddImpl("blob", NewBlobDownloader)
+		},
+	)
+
+	graph := cm.ExportGraph()
+	for _, key := range []string{"url", "blob"} {
+		id := "dep.Downloader[" + key + "]"
+		if node := graph.GetNode(id); node == nil || node.HubKey != key {
+			t.Errorf("implementation of component hub should be exported with key %s", key)
+		}
+		if findEdge(graph, "dep.Downloader", id) == nil {
+			t.Errorf("component hub should link to implementation %s", key)
+		}
+	}
+	if node := graph.GetNode("dep.Downloader[url]"); node == nil || node.Lifetime != "Singleton" {
+		t.Errorf("lifetime of hub implementation should be exported")
+	}
+}
+
+func TestExportGraph_child_container(t *testing.T) {
+	_, _, child := prepareChildContainer(
+		func(cm ComponentManager) {
+			RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+		},
+		func(components ComponentCollection) {
+			RegisterSingleton[FirstInterface](components, func(another AnotherInterface) *ActualStruct { return NewActualStruct() })
+		},
+	)
+
+	graph := child.GetComponentManager().ExportGraph()
+	if node := graph.GetNode("dep.AnotherInterface"); node == nil || !node.Inherited {
+		t.Errorf("dependency registered in parent should be exported as inherited node")
+	}
+	if node := graph.GetNode("dep.FirstInterface"); node == nil || node.Inherited {
+		t.Errorf("registration of child container should be exported as own node")
+	}
+}
+
+func TestExportRuntimeGraph(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	options.EnableDiagnostics = true
+	cm, ctxt := prepareComponentManagerWithScope(options, ScopeTest)
+
+	RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+	RegisterScoped[FirstInterface, TestScope](cm, func(another AnotherInterface) *ActualStruct { return NewActualStruct() })
+	RegisterTransient[SecondInterface](cm, func(another AnotherInterface) *ActualStruct { return NewActualStruct() })
+
+	scopedCtxt := createNewContext(ctxt, ScopeTest)
+	_ = GetComponentFrom[FirstInterface](cm, scopedCtxt, nil)
+	_ = GetComponentFrom[SecondInterface](cm, ctxt, nil)
+
+	graph := cm.ExportRuntimeGraph()
+	if findInstance(graph, "dep.SecondInterface") != nil {
+		t.Errorf("transient instance should not be exported")
+	}
+	first := findInstance(graph, "dep.FirstInterface")
+	if first == nil || first.NodeId != "dep.FirstInterface" || first.Lifetime != "Scoped" {
+		t.Fatalf("unexpected scoped instance: %+v", first)
+	}
+	another := findInstance(graph, "dep.AnotherInterface")
+	if another == nil {
+		t.Fatalf("singleton instance should be exported")
+	}
+	dependents := strings.Join(another.Dependents, ",")
+	if !strings.Contains(dependents, first.Id) || !strings.Contains(dependents, "Component[dep.SecondInterface]") {
+		t.Errorf("dependents of singleton should be exported, actual: %s", dependents)
+	}
+	if dot := graph.ToDOT(); !strings.Contains(dot, first.Id) {
+		t.Errorf("instances should be exported in DOT output: %s", dot)
+	}
+
+	// record of scoped instance is released with its scope
+	_ = scopedCtxt.GetScopeContext().GetScope().Dispose()
+	if findInstance(cm.ExportRuntimeGraph(), "dep.FirstInterface") != nil {
+		t.Errorf("instance of disposed scope should not be exported")
+	}
+}
+
+func TestExportRuntimeGraph_diagnostics_disabled(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+	_ = GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+
+	if graph := cm.ExportRuntimeGraph(); len(graph.Instances) != 0 {
+		t.Errorf("instances should be recorded only if EnableDiagnostics is on")
+	}
+}
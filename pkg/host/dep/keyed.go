@@ -0,0 +1,116 @@
+package dep
+
+import (
+	"fmt"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+// name of keyed dependency, implemented by marker type whose zero value provides the key, e.g.
+//
+//	type ArchiveStorage struct{}
+//
+//	func (ArchiveStorage) Key() any { return "archive" }
+//
+//	func NewBackup(storage dep.Keyed[Storage, ArchiveStorage]) *Backup
+type KeyName interface {
+	Key() any
+}
+
+// dependency registered by RegisterKeyed with the key of K, resolved eagerly
+type Keyed[T any, K KeyName] struct {
+	value T
+}
+
+func NewKeyed[T any, K KeyName](value T) Keyed[T, K] {
+	return Keyed[T, K]{value: value}
+}
+
+func (k Keyed[T, K]) Get() T {
+	return k.value
+}
+
+func (k Keyed[T, K]) getKey() any {
+	var name K
+	return name.Key()
+}
+func (k Keyed[T, K]) getElementType() types.DataType {
+	return types.Get[T]()
+}
+func (k Keyed[T, K]) getParamKind() ParamKind {
+	return ParamKind_Keyed
+}
+func (k Keyed[T, K]) wrap(resolve func() (any, error)) any {
+	value, err := resolve()
+	if err != nil {
+		panic(err)
+	}
+	return NewKeyed[T, K](value.(T))
+}
+
+// implemented by Keyed[T, K], whose dependency is resolved by key instead of type only
+type keyedWrapper interface {
+	getKey() any
+}
+
+// get key of Keyed[T, K] parameter, nil for other parameters
+func getParamKey(paramType types.DataType) any {
+	if wrapper, ok := getDependencyWrapper(paramType); ok {
+		if keyed, ok := wrapper.(keyedWrapper); ok {
+			return keyed.getKey()
+		}
+	}
+	return nil
+}
+
+// register component resolved by type and key, e.g. the Storage named "archive", key should be comparable.
+// keyed registrations are independent of the registration of the type itself, scope type is used for scoped component only
+func RegisterKeyed[T any](collection ComponentCollection, key any, createInstance FreeStyleFactoryMethod, lifetime Lifetime) {
+	collection.RegisterKeyedForType(createInstance, types.Get[T](), key, lifetime, ScopeType_Any)
+}
+func RegisterKeyedScoped[T any, S any](collection ComponentCollection, key any, createInstance FreeStyleFactoryMethod) {
+	collection.RegisterKeyedForType(createInstance, types.Get[T](), key, Lifetime_Scoped, types.Get[S]())
+}
+
+// implemented by providers which resolve components registered by RegisterKeyed, e.g. component context
+type KeyedComponentProvider interface {
+	GetKeyedComponent(interfaceType types.DataType, key any) any
+}
+
+func GetKeyed[T any](provider ComponentProvider, key any) T {
+	return getKeyedComponent(provider, types.Get[T](), key).(T)
+}
+func TryGetKeyed[T any](provider ComponentProvider, key any) (T, error) {
+	componentType := types.Get[T]()
+	return castResolved[T](CatchResolutionError(componentType, func() any {
+		return getKeyedComponent(provider, componentType, key)
+	}))
+}
+
+func GetKeyedFrom[T any](ctxtProvider ContextualProvider, dependent Context, key any) T {
+	return ctxtProvider.GetKeyedComponent(types.Get[T](), key, dependent).(T)
+}
+func ResolveKeyed[T any](ctxtProvider ContextualProvider, dependent Context, key any) (T, error) {
+	return castResolved[T](ctxtProvider.TryGetKeyedComponent(types.Get[T](), key, dependent))
+}
+
+func getKeyedComponent(provider ComponentProvider, interfaceType types.DataType, key any) any {
+	if keyed, ok := provider.(KeyedComponentProvider); ok {
+		return keyed.GetKeyedComponent(interfaceType, key)
+	}
+	// e.g. host and service contexts, resolved by the contextual provider with the context as dependent
+	if context, ok := provider.(Context); ok {
+		return GetComponent[ContextualProvider](context).GetKeyedComponent(interfaceType, key, context)
+	}
+	panic(fmt.Errorf("keyed component %s is not supported by provider %T", getKeyedName(interfaceType, key), provider))
+}
+
+// key of keyed component in component manager
+type keyedDependencyKey struct {
+	compKey any
+	key     any
+}
+
+func newKeyedDependencyKey(componentType types.DataType, key any) keyedDependencyKey {
+	return keyedDependencyKey{compKey: componentType.Key(), key: key}
+}
@@ -0,0 +1,177 @@
+package dep
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+type MirrorKey struct{}
+
+func (MirrorKey) Key() any { return "mirror" }
+
+type MirrorClient struct {
+	downloader Downloader
+}
+
+func NewMirrorClient(downloader Keyed[Downloader, MirrorKey]) *MirrorClient {
+	return &MirrorClient{downloader: downloader.Get()}
+}
+
+func TestRegisterKeyed(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterKeyed[Downloader](cm, "url", NewUrlDownloader, Lifetime_Singleton)
+	RegisterKeyed[Downloader](cm, "mirror", NewUrlDownloader, Lifetime_Singleton)
+	RegisterKeyed[Downloader](cm, "temp", NewUrlDownloader, Lifetime_Transient)
+
+	url := GetKeyedFrom[Downloader](cm, ctxt, "url")
+	mirror := GetKeyedFrom[Downloader](cm, ctxt, "mirror")
+	if url == mirror || url != GetKeyedFrom[Downloader](cm, ctxt, "url") {
+		t.Errorf("keyed singleton should be created once for each key")
+	}
+	if GetKeyedFrom[Downloader](cm, ctxt, "temp") == GetKeyedFrom[Downloader](cm, ctxt, "temp") {
+		t.Errorf("keyed transient should be created on each resolution")
+	}
+	if GetKeyed[Downloader](ctxt, "mirror") != mirror {
+		t.Errorf("keyed component should be resolved from context")
+	}
+	if _, err := ResolveComponent[Downloader](cm, ctxt, nil); err == nil {
+		t.Errorf("keyed registrations should not register the component type itself")
+	}
+}
+
+func TestRegisterKeyed_parameter(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType, StructPtrType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterKeyed[Downloader](cm, "mirror", NewUrlDownloader, Lifetime_Singleton)
+	RegisterTransient[*MirrorClient](cm, NewMirrorClient)
+
+	client := GetComponentFrom[*MirrorClient](cm, ctxt, nil)
+	if client.downloader == nil || client.downloader != GetKeyedFrom[Downloader](cm, ctxt, "mirror") {
+		t.Errorf("keyed dependency should be injected by the key of marker type")
+	}
+	if err := cm.Validate(); err != nil {
+		t.Errorf("keyed dependency should be validated as registered: %v", err)
+	}
+}
+
+func TestRegisterKeyed_scoped(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithScope(options, ScopeTest)
+
+	RegisterKeyedScoped[AnotherInterface, TestScope](cm, "first", NewAnotherStruct)
+	RegisterKeyedScoped[AnotherInterface, TestScope](cm, "second", NewAnotherStruct)
+
+	first := GetKeyedFrom[AnotherInterface](cm, ctxt, "first")
+	if first != GetKeyedFrom[AnotherInterface](cm, ctxt, "first") || first == GetKeyedFrom[AnotherInterface](cm, ctxt, "second") {
+		t.Errorf("keyed scoped component should be recorded for each key in the scope")
+	}
+	if first == GetKeyedFrom[AnotherInterface](cm, createNewContext(ctxt, ScopeTest), "first") {
+		t.Errorf("keyed scoped component should not be shared by other scopes")
+	}
+}
+
+func TestRegisterKeyed_not_registered(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType, StructPtrType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterKeyed[Downloader](cm, "url", NewUrlDownloader, Lifetime_Singleton)
+	RegisterTransient[*MirrorClient](cm, NewMirrorClient)
+
+	_, err := ResolveKeyed[Downloader](cm, ctxt, "mirror")
+	var notRegistered *ComponentNotRegisteredError
+	if !errors.As(err, &notRegistered) || notRegistered.Key != "mirror" {
+		t.Errorf("keyed component not registered should be reported with the key, actual: %v", err)
+	}
+	if _, err := TryGetKeyed[Downloader](ctxt, "mirror"); !errors.As(err, &notRegistered) {
+		t.Errorf("keyed component not registered should be returned as error, actual: %v", err)
+	}
+
+	err = cm.Validate()
+	expected := "component *dep.MirrorClient: parameter 0, keyed dependency dep.Downloader{mirror} is not registered"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("validation error should contain: %s, actual: %v", expected, err)
+	}
+}
+
+func TestRegisterKeyed_modes(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterKeyed[AnotherInterface](cm, "key", NewAnotherStruct, Lifetime_Singleton)
+	RegisterKeyed[AnotherInterface](TryRegister(cm), "key", func() *AnotherStruct {
+		return &AnotherStruct{value: 2}
+	}, Lifetime_Singleton)
+	if GetKeyedFrom[AnotherInterface](cm, ctxt, "key").(*AnotherStruct).value == 2 {
+		t.Errorf("keyed component should not be overridden by TryRegister")
+	}
+
+	RegisterKeyed[AnotherInterface](Replace(cm), "replaced", NewAnotherStruct, Lifetime_Transient)
+	RegisterKeyed[AnotherInterface](Replace(cm), "replaced", func() *AnotherStruct {
+		return &AnotherStruct{value: 1}
+	}, Lifetime_Transient)
+	if GetKeyedFrom[AnotherInterface](cm, ctxt, "replaced").(*AnotherStruct).value != 1 {
+		t.Errorf("keyed component should be replaced by Replace")
+	}
+	if registration := cm.GetRegistrations().GetKeyedRegistration(types.Get[AnotherInterface](), "replaced"); registration == nil || registration.Replaced == nil {
+		t.Errorf("replaced keyed registration should be recorded, actual: %v", registration)
+	}
+	if len(cm.GetRegistrations().GetKeyedRegistrations(types.Get[AnotherInterface]())) != 2 {
+		t.Errorf("registration of the same key should be replaced instead of added")
+	}
+
+	defer test.AssertPanicContent(t, "specified keyed component already exist: dep.AnotherInterface{key}", "panic content is not expected")
+	RegisterKeyed[AnotherInterface](cm, "key", NewAnotherStruct, Lifetime_Singleton)
+}
+
+func TestRegisterKeyed_invalid_key(t *testing.T) {
+	defer test.AssertPanicContent(t, "key of keyed component should be comparable and not nil", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterKeyed[AnotherInterface](cm, []string{"key"}, NewAnotherStruct, Lifetime_Singleton)
+}
+
+func TestRegisterKeyed_graph(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType, StructPtrType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterKeyed[Downloader](cm, "mirror", NewUrlDownloader, Lifetime_Singleton)
+	RegisterTransient[*MirrorClient](cm, NewMirrorClient)
+
+	graph := cm.ExportGraph()
+	if node := graph.GetNode("dep.Downloader{mirror}"); node == nil || node.Key != "mirror" || node.Lifetime != "Singleton" {
+		t.Errorf("keyed registration should be exported as node with key, actual: %+v", node)
+	}
+	if edge := findEdge(graph, "*dep.MirrorClient", "dep.Downloader{mirror}"); edge == nil || edge.Kind != "Keyed" {
+		t.Errorf("keyed dependency should be exported as keyed edge, actual: %+v", edge)
+	}
+	if graph.GetNode("dep.Downloader") != nil {
+		t.Errorf("component type of keyed registrations should not be exported as node")
+	}
+}
+
+func TestRegisterKeyed_child_container(t *testing.T) {
+	_, _, child := prepareChildContainer(
+		func(cm ComponentManager) {
+			RegisterKeyed[Downloader](cm, "mirror", NewUrlDownloader, Lifetime_Singleton)
+		},
+		func(components ComponentCollection) {
+			RegisterKeyed[Downloader](components, "url", NewUrlDownloader, Lifetime_Singleton)
+		},
+	)
+
+	if GetKeyed[Downloader](child, "mirror") == nil || GetKeyed[Downloader](child, "url") == nil {
+		t.Errorf("keyed component should be resolved from child container and its parent")
+	}
+	if err := child.GetComponentManager().Validate(); err != nil {
+		t.Errorf("child container should be valid: %v", err)
+	}
+}
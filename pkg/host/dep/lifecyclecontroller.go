@@ -2,6 +2,7 @@ package dep
 
 import (
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -25,6 +26,10 @@ type GenericActionMethod func(context Context, actionName string, deps ...*Depen
 type LifecycleController interface {
 	BuildSingletonFactoryMethod(compTypes []types.DataType, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod) FactoryMethod
 	BuildScopedFactoryMethod(compType types.DataType, scopeType types.DataType, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod) FactoryMethod
+	// scoped implementation of multi registrations, each implementation is recorded separately in the scope
+	BuildScopedImplFactoryMethod(compType types.DataType, implIndex int, scopeType types.DataType, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod) FactoryMethod
+	// scoped component registered by key, each key is recorded separately in the scope
+	BuildScopedKeyedFactoryMethod(compType types.DataType, key any, scopeType types.DataType, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod) FactoryMethod
 	BuildTransientFactoryMethod(compType types.DataType, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod) FactoryMethod
 
 	BuildActionMethod(processorFunc FreeStyleActionMethod) GenericActionMethod
@@ -50,12 +55,15 @@ func (lc *DefaultLifecycleController) buildDepInjector(compProvider ComponentPro
 	injector.Initialize(compProvider, contextualDeps)
 	return injector
 }
-func (lc *DefaultLifecycleController) getComponentFactoryMethod(factoryMethod FreeStyleFactoryMethod, createCtxt ContextFactoryMethod, transient bool) InternalFactoryMethod {
+func (lc *DefaultLifecycleController) getComponentFactoryMethod(factoryMethod FreeStyleFactoryMethod, createCtxt ContextFactoryMethod, lifetime Lifetime) InternalFactoryMethod {
 	options := lc.options
-	return func(dependent ContextEx, scopeCtxt ScopeContextEx, compType types.DataType, props Properties) (interface{}, ContextEx) {
+	factoryMethod = compileFactoryMethod(factoryMethod)
+	return func(dependent ContextEx, scopeCtxt ScopeContextEx, compType types.DataType, props Properties, frame *ResolutionFrame) (interface{}, ContextEx) {
 		compCtxt := createCtxt(scopeCtxt)
 		TrackDependent(compCtxt, dependent)
-		if transient {
+		TrackLifetime(compCtxt, dependent, compType, lifetime)
+		TrackResolution(compCtxt, frame)
+		if lifetime == Lifetime_Transient {
 			if options.PropertiesPassOver {
 				compCtxt.UpdateProperties(dependent.GetProperties())
 			}
@@ -64,27 +72,140 @@ func (lc *DefaultLifecycleController) getComponentFactoryMethod(factoryMethod Fr
 			compCtxt.UpdateProperties(props)
 		}
 		injector := lc.buildDepInjector(compCtxt, nil)
-		return injector.BuildComponent(factoryMethod, compType), compCtxt
+		// instance is decorated by the lifetime, see decorateComponent
+		instance := injector.BuildComponent(factoryMethod, compType)
+		if options.EnableDiagnostics {
+			RecordInstance(compCtxt, compType, lifetime)
+		}
+		return instance, compCtxt
+	}
+}
+
+// apply decorators in registration order, the decorated instance is cached as the lifetime requires.
+// return the decorated instance, and instances of all layers from the innermost to be tracked for disposal,
+// a decorator returning its inner instance adds no layer
+func (lc *DefaultLifecycleController) decorateComponent(compCtxt ContextEx, compType types.DataType, instance any) (any, []any) {
+	decorators := GetDecorators(compCtxt, compType)
+	layers := make([]any, 0, len(decorators)+1)
+	layers = append(layers, instance)
+	for _, decorator := range decorators {
+		validateInstanceType(instance, compType)
+		inner := instance
+		ctxtDeps := NewDependencyDictionary[ComponentGetter]()
+		ctxtDeps.AddDependency(func() any { return inner }, compType)
+		injector := lc.buildDepInjector(compCtxt, ctxtDeps)
+		instance = injector.BuildComponent(decorator.Decorator, compType)
+		layers = appendInstance(layers, instance)
+	}
+	return instance, layers
+}
+
+// append the instance unless it is in the list already
+func appendInstance(instances []any, instance any) []any {
+	for _, existing := range instances {
+		if isSameInstance(existing, instance) {
+			return instances
+		}
+	}
+	return append(instances, instance)
+}
+
+// instances of uncomparable types are never the same
+func isSameInstance(a any, b any) bool {
+	compType := reflect.TypeOf(a)
+	if compType == nil || compType != reflect.TypeOf(b) || !compType.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// inner instances are tracked before the decorated ones, so that they are disposed after them
+func trackInstances(scope ScopeDataEx, instances []any) {
+	for _, instance := range instances {
+		scope.TrackInstance(instance)
 	}
 }
 
+// instances of singleton by key of its registered types, decorators of each type apply to the instance of the type only
+type singletonInstances map[interface{}]any
+
+// decorate the singleton for each of its registered types on creation, return the instances of all layers,
+// the undecorated instance goes first and is included once
+func (lc *DefaultLifecycleController) decorateSingleton(compCtxt ContextEx, compTypes []types.DataType, instance any) (singletonInstances, []any) {
+	instances := make(singletonInstances, len(compTypes))
+	layers := []any{instance}
+	for _, compType := range compTypes {
+		decorated, typeLayers := lc.decorateComponent(compCtxt, compType, instance)
+		instances[compType.Key()] = decorated
+		for _, layer := range typeLayers {
+			layers = appendInstance(layers, layer)
+		}
+	}
+	return instances, layers
+}
+
+// get lifetime chain of the context, empty if the context is not created by lifecycle controller
+func GetLifetimeChain(context ContextEx) []LifetimeFrame {
+	if tracker, ok := context.(LifetimeTracker); ok {
+		return tracker.GetLifetimeChain()
+	}
+	return []LifetimeFrame{}
+}
+
+// track lifetime chain of the component context, transient lives as long as the component which captures it
+func TrackLifetime(compCtxt ContextEx, dependent ContextEx, compType types.DataType, lifetime Lifetime) {
+	tracker, ok := compCtxt.(LifetimeTracker)
+	if !ok {
+		return
+	}
+	frame := LifetimeFrame{ComponentType: compType, Lifetime: lifetime}
+	chain := []LifetimeFrame{frame}
+	if lifetime == Lifetime_Transient {
+		depChain := GetLifetimeChain(dependent)
+		if len(depChain) > 0 && depChain[0].Lifetime.Outlives(lifetime) {
+			chain = make([]LifetimeFrame, 0, len(depChain)+1)
+			chain = append(chain, depChain...)
+			chain = append(chain, frame)
+		}
+	}
+	tracker.SetLifetimeChain(chain)
+}
+
+func LifetimeChainToString(chain []LifetimeFrame) string {
+	names := make([]string, 0, len(chain))
+	for _, frame := range chain {
+		names = append(names, frame.String())
+	}
+	return strings.Join(names, " -> ")
+}
+
 type TransientFactoryMethod func(dependent ContextEx, interfaceType types.DataType, props Properties) (interface{}, ContextEx, bool)
 
 func (lc *DefaultLifecycleController) createRecurrenceManager(compType types.DataType) RecurrenceManager {
 	return NewRecurrenceManager(lc.options, compType)
 }
-func (lc *DefaultLifecycleController) getTransientFactoryMethod(compType types.DataType, createInstance InternalFactoryMethod) TransientFactoryMethod {
+func (lc *DefaultLifecycleController) getTransientFactoryMethod(compType types.DataType, createInstance InternalFactoryMethod, supplied bool) TransientFactoryMethod {
 	recurMgr := lc.createRecurrenceManager(compType)
 	return func(dependent ContextEx, interfaceType types.DataType, props Properties) (interface{}, ContextEx, bool) {
-		tracker := recurMgr.GetTracker()
+		// transient has no record to lock, but its frame keeps the chain to the dependent, where its recurrence is counted
+		dependentFrame := GetResolutionFrame(dependent)
+		tracker := recurMgr.GetTracker(dependentFrame)
 		return tracker.Execute(func() (interface{}, ContextEx) {
-			return createInstance(dependent, dependent.GetScopeContext(), interfaceType, props)
+			scopeCtxt := dependent.GetScopeContext()
+			frame := newOwnedResolutionFrame(dependentFrame, recurMgr)
+			instance, compCtxt := createInstance(dependent, scopeCtxt, interfaceType, props, frame)
+			instance, layers := lc.decorateComponent(compCtxt, interfaceType, instance)
+			// transient resolved from global scope is owned by the caller
+			if !supplied && !scopeCtxt.IsGlobal() {
+				trackInstances(scopeCtxt.GetScope(), layers)
+			}
+			return instance, compCtxt
 		})
 	}
 }
 func (lc *DefaultLifecycleController) BuildTransientFactoryMethod(compType types.DataType, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod) FactoryMethod {
-	createComponent := lc.getComponentFactoryMethod(createInstance, createCtxt, true)
-	createTransient := lc.getTransientFactoryMethod(compType, createComponent)
+	createComponent := lc.getComponentFactoryMethod(createInstance, createCtxt, Lifetime_Transient)
+	createTransient := lc.getTransientFactoryMethod(compType, createComponent, isSuppliedInstance(createInstance))
 	return func(depCtxt Context, interfaceType types.DataType, props Properties) interface{} {
 		dependent := depCtxt.(ContextEx)
 		instance, compCtxt, cycled_detected := createTransient(dependent, interfaceType, props)
@@ -114,18 +235,29 @@ func typesToString(compTypes []types.DataType) string {
 
 type SingletonFactoryMethod func(dependent ContextEx, interfaceType types.DataType, props Properties) (interface{}, ContextEx, bool, bool)
 
-func (lc *DefaultLifecycleController) getSingletonFactoryMethod(compTypes []types.DataType, createInstance InternalFactoryMethod) SingletonFactoryMethod {
+func (lc *DefaultLifecycleController) getSingletonFactoryMethod(compTypes []types.DataType, createInstance InternalFactoryMethod, supplied bool) SingletonFactoryMethod {
 	// get from global scope data? eaiser by create it directly here as below
 	scopedRecord := NewScopedCompRecord(typesToString(compTypes), lc.options.EnableSingletonConcurrency)
+	globalScope := lc.context.(ContextEx).GetScopeContext()
 	return func(dependent ContextEx, interfaceType types.DataType, props Properties) (interface{}, ContextEx, bool, bool) {
-		return scopedRecord.Execute(func() (interface{}, ContextEx) {
-			return createInstance(dependent, dependent.GetScopeContext(), interfaceType, props)
+		instances, compCtxt, exist, cycled := scopedRecord.Execute(dependent, func(frame *ResolutionFrame) (interface{}, ContextEx) {
+			instance, compCtxt := createInstance(dependent, dependent.GetScopeContext(), interfaceType, props, frame)
+			instances, layers := lc.decorateSingleton(compCtxt, compTypes, instance)
+			// singleton is disposed on host shutdown unless supplied by the caller
+			if !supplied {
+				trackInstances(globalScope.GetScope(), layers)
+			}
+			return instances, compCtxt
 		})
+		if cycled {
+			return nil, compCtxt, exist, cycled
+		}
+		return instances.(singletonInstances)[interfaceType.Key()], compCtxt, exist, cycled
 	}
 }
 func (lc *DefaultLifecycleController) BuildSingletonFactoryMethod(compTypes []types.DataType, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod) FactoryMethod {
-	createComponent := lc.getComponentFactoryMethod(createInstance, createCtxt, false)
-	createSingleton := lc.getSingletonFactoryMethod(compTypes, createComponent)
+	createComponent := lc.getComponentFactoryMethod(createInstance, createCtxt, Lifetime_Singleton)
+	createSingleton := lc.getSingletonFactoryMethod(compTypes, createComponent, isSuppliedInstance(createInstance))
 
 	return func(depCtxt Context, interfaceType types.DataType, props Properties) interface{} {
 		dependent := depCtxt.(ContextEx)
@@ -144,20 +276,59 @@ func (lc *DefaultLifecycleController) BuildSingletonFactoryMethod(compTypes []ty
 
 type ScopedFactoryMethod func(dependent ContextEx, scopeCtxt ScopeContextEx, interfaceType types.DataType, props Properties) (interface{}, ContextEx, bool, bool)
 
-func (lc *DefaultLifecycleController) getScopedFactoryMethod(compType types.DataType, createInstance InternalFactoryMethod) ScopedFactoryMethod {
+// key of scoped record for implementation of multi registrations, or component registered by key
+type implRecordKey struct {
+	compKey interface{}
+	// index of implementation, or keyedImpl
+	implKey any
+}
+
+// implementation key of component registered by key, distinguished from index of multi registrations
+type keyedImpl struct {
+	key any
+}
+
+func (ki keyedImpl) String() string {
+	return fmt.Sprintf("{%v}", ki.key)
+}
+
+// implKey is nil for the component type itself, see implRecordKey
+func (lc *DefaultLifecycleController) getScopedFactoryMethod(compType types.DataType, implKey any, createInstance InternalFactoryMethod, supplied bool) ScopedFactoryMethod {
 	return func(dependent ContextEx, scopeCtxt ScopeContextEx, interfaceType types.DataType, props Properties) (interface{}, ContextEx, bool, bool) {
-		scopedRecord := scopeCtxt.GetScope().GetCompRecord(interfaceType)
-		return scopedRecord.Execute(func() (interface{}, ContextEx) {
-			return createInstance(dependent, scopeCtxt, interfaceType, props)
+		var scopedRecord ScopedCompRecord
+		if implKey == nil {
+			scopedRecord = scopeCtxt.GetScope().GetCompRecord(interfaceType)
+		} else {
+			key := implRecordKey{compKey: interfaceType.Key(), implKey: implKey}
+			scopedRecord = scopeCtxt.GetScope().GetCompRecordByKey(key, fmt.Sprintf("%s#%v", interfaceType.Name(), implKey))
+		}
+		return scopedRecord.Execute(dependent, func(frame *ResolutionFrame) (interface{}, ContextEx) {
+			instance, compCtxt := createInstance(dependent, scopeCtxt, interfaceType, props, frame)
+			instance, layers := lc.decorateComponent(compCtxt, interfaceType, instance)
+			if !supplied {
+				trackInstances(scopeCtxt.GetScope(), layers)
+			}
+			return instance, compCtxt
 		})
 	}
 }
 func (lc *DefaultLifecycleController) BuildScopedFactoryMethod(compType types.DataType, scopeType types.DataType, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod) FactoryMethod {
-	createComponent := lc.getComponentFactoryMethod(createInstance, createCtxt, false)
-	createScoped := lc.getScopedFactoryMethod(compType, createComponent)
+	return lc.buildScopedFactoryMethod(compType, nil, scopeType, createInstance, createCtxt)
+}
+func (lc *DefaultLifecycleController) BuildScopedImplFactoryMethod(compType types.DataType, implIndex int, scopeType types.DataType, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod) FactoryMethod {
+	return lc.buildScopedFactoryMethod(compType, implIndex, scopeType, createInstance, createCtxt)
+}
+func (lc *DefaultLifecycleController) BuildScopedKeyedFactoryMethod(compType types.DataType, key any, scopeType types.DataType, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod) FactoryMethod {
+	return lc.buildScopedFactoryMethod(compType, keyedImpl{key: key}, scopeType, createInstance, createCtxt)
+}
+func (lc *DefaultLifecycleController) buildScopedFactoryMethod(compType types.DataType, implKey any, scopeType types.DataType, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod) FactoryMethod {
+	createComponent := lc.getComponentFactoryMethod(createInstance, createCtxt, Lifetime_Scoped)
+	createScoped := lc.getScopedFactoryMethod(compType, implKey, createComponent, isSuppliedInstance(createInstance))
 
 	return func(depCtxt Context, interfaceType types.DataType, props Properties) interface{} {
 		dependent := depCtxt.(ContextEx)
+		// scoped component captured by singleton lives forever
+		lc.checkCaptiveDependency(dependent, interfaceType, Lifetime_Scoped)
 		//fmt.Printf("search scope type %s for component %s\n", scopeType.FullName(), interfaceType.Name())
 		//PrintDependencyStack(depCtxt)
 		//PrintAncestorStack(depCtxt, fmt.Sprintf("CreateScoped[%s]", interfaceType.Name()))
@@ -165,7 +336,6 @@ func (lc *DefaultLifecycleController) BuildScopedFactoryMethod(compType types.Da
 		targetScope := lc.matchScopeForComponent(dependent.GetScopeContext(), interfaceType, scopeType)
 		if targetScope == nil {
 			lc.raiseScopeContextFailure(dependent, interfaceType, scopeType)
-			panic(fmt.Errorf("scoped component %s must be used in scope of type %s", interfaceType.FullName(), scopeType.Name()))
 		}
 		//fmt.Printf("target scope for %s is %s\n", interfaceType.Name(), targetScope.ScopeId())
 
@@ -181,6 +351,16 @@ func (lc *DefaultLifecycleController) BuildScopedFactoryMethod(compType types.Da
 		return instance
 	}
 }
+func (lc *DefaultLifecycleController) checkCaptiveDependency(dependent ContextEx, interfaceType types.DataType, lifetime Lifetime) {
+	chain := GetLifetimeChain(dependent)
+	if len(chain) == 0 || !chain[0].Lifetime.Outlives(lifetime) {
+		return
+	}
+	captiveChain := make([]LifetimeFrame, 0, len(chain)+1)
+	captiveChain = append(captiveChain, chain...)
+	captiveChain = append(captiveChain, LifetimeFrame{ComponentType: interfaceType, Lifetime: lifetime})
+	lc.raiseCaptiveDependencyFailure(dependent, captiveChain)
+}
 func (lc *DefaultLifecycleController) matchScopeForComponent(scopeCtxt ScopeContextEx, interfaceType types.DataType, scopeType types.DataType) ScopeContextEx {
 	// global scope is a virtual scope which maps to Singleton, never match any requested Scoped type including "ScopeType_Any"
 	if scopeCtxt.IsGlobal() {
@@ -251,9 +431,25 @@ func (lc *DefaultLifecycleController) raiseScopeContextFailure(depCtxt ContextEx
 	enableDiagnostics := lc.options.EnableDiagnostics
 	if depCtxt.IsDebug() && enableDiagnostics {
 		PrintAncestorStack(depCtxt, fmt.Sprintf("CreateScoped[%s]", interfaceType.Name()))
-		panic(fmt.Errorf("scoped component %s must be used in scope of type %s", interfaceType.FullName(), scopeType.Name()))
+		panic(NewScopeMismatchError(interfaceType, scopeType, "scoped component %s must be used in scope of type %s", interfaceType.FullName(), scopeType.Name()))
+	} else {
+		panic(NewScopeMismatchError(interfaceType, scopeType, "scoped component %s must be used in scope of type %s, turn on EnableDiagnostics in Debug mode to show more details", interfaceType.FullName(), scopeType.Name()))
+	}
+}
+
+func (lc *DefaultLifecycleController) raiseCaptiveDependencyFailure(depCtxt ContextEx, chain []LifetimeFrame) {
+	chainStr := LifetimeChainToString(chain)
+	if !depCtxt.IsDebug() {
+		// keep running in Release mode, the captive instance lives as long as its captor
+		lc.context.GetLogger().Warnw("captive dependency detected", "chain", chainStr)
+		return
+	}
+	fmt.Printf("captive dependency detected: %s\n", chainStr)
+	if lc.options.EnableDiagnostics {
+		PrintDependencyStack(depCtxt)
+		panic(NewCaptiveDependencyError(chain, "captive dependency detected: %s", chainStr))
 	} else {
-		panic(fmt.Errorf("scoped component %s must be used in scope of type %s, turn on EnableDiagnostics in Debug mode to show more details", interfaceType.FullName(), scopeType.Name()))
+		panic(NewCaptiveDependencyError(chain, "captive dependency detected: %s, turn on EnableDiagnostics in Debug mode to show more details", chainStr))
 	}
 }
 
@@ -268,9 +464,9 @@ func (lc *DefaultLifecycleController) raiseCyclicDependencyFailure(context Conte
 	enableDiagnostics := lc.options.EnableDiagnostics
 	if context.IsDebug() && enableDiagnostics {
 		PrintDependencyStack(context)
-		panic(fmt.Errorf("cyclic dependency detected on %s component %v", lifeType, interfaceType.FullName()))
+		panic(NewCyclicDependencyError(interfaceType, "cyclic dependency detected on %s component %v", lifeType, interfaceType.FullName()))
 	} else {
-		panic(fmt.Errorf("cyclic dependency detected on %s component %v, turn on EnableDiagnostics in Debug mode to show more details", lifeType, interfaceType.FullName()))
+		panic(NewCyclicDependencyError(interfaceType, "cyclic dependency detected on %s component %v, turn on EnableDiagnostics in Debug mode to show more details", lifeType, interfaceType.FullName()))
 	}
 }
 
@@ -278,8 +474,8 @@ func (lc *DefaultLifecycleController) raiseTransientCyclicDependencyFailure(cont
 	fmt.Printf("recursive dependency overflow(MaxAllowedRecurrence=%d) on transient component: %s\n", lc.options.MaxAllowedRecurrence, interfaceType.FullName())
 	if context.IsDebug() && lc.options.EnableDiagnostics {
 		PrintDependencyStack(context)
-		panic(fmt.Errorf("recursive dependency overflow on transient component %v", interfaceType.FullName()))
+		panic(NewCyclicDependencyError(interfaceType, "recursive dependency overflow on transient component %v", interfaceType.FullName()))
 	} else {
-		panic(fmt.Errorf("recursive dependency overflow on transient component %v, turn on EnableDiagnostics in Debug mode to show more details", interfaceType.FullName()))
+		panic(NewCyclicDependencyError(interfaceType, "recursive dependency overflow on transient component %v, turn on EnableDiagnostics in Debug mode to show more details", interfaceType.FullName()))
 	}
 }
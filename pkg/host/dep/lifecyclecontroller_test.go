@@ -0,0 +1,83 @@
+package dep
+
+import (
+	"testing"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+func prepareComponentManagerInMode(options *ComponentProviderOptions, debug bool, scopeTypes ...types.DataType) (ComponentManager, ContextEx) {
+	hostCtxt := NewMockContext(ContextType_Host, "Test", debug, nil)
+	globalScope := createTopScopeContext(hostCtxt, debug, options.EnableSingletonConcurrency, nil)
+	hostCtxt.SetScopeContext(globalScope)
+
+	cm := initComponentManager(hostCtxt, options)
+
+	ctxt := NewMockContext(ContextType_Component, "Starter", debug, nil)
+	scopeCtxt := createNewTestScopeFrom(ctxt, globalScope, nil, scopeTypes...)
+	ctxt.SetScopeContext(scopeCtxt)
+	ctxt.SetComponentManager(cm)
+	lf := ctxt.GetLoggerFactory()
+	lf.Initialize("Test", debug)
+
+	return cm, ctxt
+}
+
+func TestLifecycleController_captive_transient(t *testing.T) {
+	defer test.AssertPanicContent(t, "captive dependency detected: dep.FirstInterface[Singleton] -> dep.SecondInterface[Transient] -> dep.AnotherInterface[Scoped]", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerInMode(options, true, ScopeTest)
+
+	// singleton captures transient, which depends on scoped component
+	RegisterSingleton[FirstInterface](cm, NewFirstDepOnSecond)
+	RegisterTransient[SecondInterface](cm, func(another AnotherInterface) *ActualStruct { return NewActualStruct() })
+	RegisterScoped[AnotherInterface, TestScope](cm, NewAnotherStruct)
+
+	_ = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+}
+
+func TestLifecycleController_captive_context(t *testing.T) {
+	defer test.AssertPanicContent(t, "captive dependency detected: dep.FirstInterface[Singleton] -> dep.AnotherInterface[Scoped]", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerInMode(options, true, ScopeTest)
+
+	// singleton resolves scoped component from its context manually
+	RegisterSingleton[FirstInterface](cm, func(context Context) *ActualStruct {
+		_ = GetComponent[AnotherInterface](context)
+		return NewActualStruct()
+	})
+	RegisterScoped[AnotherInterface, TestScope](cm, NewAnotherStruct)
+
+	_ = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+}
+
+func TestLifecycleController_captive_release(t *testing.T) {
+	// captive dependency is only warned in Release mode, resolution continues as before
+	defer test.AssertPanicContent(t, "scoped component dep.SecondInterface must be used in scope of type TestScope", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerInMode(options, false, ScopeTest)
+
+	RegisterSingleton[FirstInterface](cm, NewFirstDepOnSecond)
+	RegisterScoped[SecondInterface, TestScope](cm, NewActualStruct)
+
+	_ = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+}
+
+func TestLifecycleController_no_captive(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerInMode(options, true, ScopeTest)
+
+	// scoped and transient could depend on components with any lifetime
+	RegisterSingleton[FirstInterface](cm, NewActualStruct)
+	RegisterTransient[SecondInterface](cm, func(another AnotherInterface, first FirstInterface) *ActualStruct { return NewActualStruct() })
+	RegisterScoped[AnotherInterface, TestScope](cm, func(first FirstInterface) *AnotherStruct { return NewAnotherStruct(nil) })
+
+	inst := GetComponentFrom[SecondInterface](cm, ctxt, nil)
+	if inst == nil {
+		t.Errorf("instance should be created")
+	}
+}
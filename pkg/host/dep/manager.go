@@ -2,6 +2,7 @@ package dep
 
 import (
 	"fmt"
+	"reflect"
 
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
 )
@@ -13,7 +14,7 @@ type FuncProcFactoryMethod func(context Context, interfaceType types.DataType, a
 
 type ContextFactoryMethod func(scopeCtxt ScopeContextEx) ContextEx
 
-type InternalFactoryMethod func(dependent ContextEx, scopeCtxt ScopeContextEx, interfaceType types.DataType, props Properties) (interface{}, ContextEx)
+type InternalFactoryMethod func(dependent ContextEx, scopeCtxt ScopeContextEx, interfaceType types.DataType, props Properties, frame *ResolutionFrame) (interface{}, ContextEx)
 
 type ComponentManager interface {
 	ContextualProvider
@@ -22,6 +23,20 @@ type ComponentManager interface {
 	Initialize()
 	GetOptions() *ComponentProviderOptions
 	PrintDiagnostics()
+	// dispose singleton components in reverse creation order
+	Dispose() error
+
+	// registration metadata of component types
+	GetRegistrations() RegistrationReader
+	// declare type which is provided by contexts instead of registered as component
+	AddContextualType(depType types.DataType)
+	// validate registered factory methods' dependencies are resolvable and acyclic
+	Validate() error
+	// registration graph with edges derived from factory method parameter types
+	ExportGraph() *DependencyGraph
+	// registration graph with singleton and scoped instances which exist, requires EnableDiagnostics,
+	// dependents of instances are tracked in Debug mode only
+	ExportRuntimeGraph() *DependencyGraph
 
 	// test used only, wrapped call to ContextualProvider::GetOrCreateWithProperties
 	GetComponent(interfaceType types.DataType, context Context) any
@@ -56,16 +71,18 @@ type DefaultComponentManager struct {
 	context             Context
 	options             *ComponentProviderOptions
 	dependencies        DepDict[FactoryMethod]
+	implementations     map[interface{}][]FactoryMethod
+	keyed               map[keyedDependencyKey]FactoryMethod
+	registrations       *DefaultRegistrationTable
 	lifecycleController LifecycleController
+	// types not registered are resolved from parent, nil if not a child component manager
+	parent ComponentManager
+	// existing instances recorded if EnableDiagnostics is on
+	instances *instanceRegistry
 }
 
 func NewDefaultComponentManager(hostCtxt HostContextEx, options *ComponentProviderOptions) *DefaultComponentManager {
-	cm := &DefaultComponentManager{
-		globalScope: hostCtxt.GetGlobalScope(),
-		options:     options,
-	}
-	// dependencies is pre-condition to register components
-	cm.dependencies = NewDependencyDictionary[FactoryMethod]()
+	cm := newComponentManager(hostCtxt.GetGlobalScope(), options)
 
 	hostCtxt.SetComponentManager(cm)
 
@@ -74,6 +91,34 @@ func NewDefaultComponentManager(hostCtxt HostContextEx, options *ComponentProvid
 	return cm
 }
 
+// child component manager owns its registrations, configurations and singletons in the global scope of its own,
+// types not registered in it are resolved from the parent
+func NewChildComponentManager(parent ComponentManager, globalScope ScopeContextEx, options *ComponentProviderOptions) *DefaultComponentManager {
+	cm := newComponentManager(globalScope, options)
+	cm.parent = parent
+
+	AddSingleton[ContextualProvider](cm, cm)
+
+	return cm
+}
+
+func newComponentManager(globalScope ScopeContextEx, options *ComponentProviderOptions) *DefaultComponentManager {
+	cm := &DefaultComponentManager{
+		globalScope: globalScope,
+		options:     options,
+	}
+	// dependencies is pre-condition to register components
+	cm.dependencies = NewDependencyDictionary[FactoryMethod]()
+	cm.implementations = make(map[interface{}][]FactoryMethod)
+	cm.keyed = make(map[keyedDependencyKey]FactoryMethod)
+	cm.registrations = NewRegistrationTable()
+	cm.instances = newInstanceRegistry()
+	for _, depType := range ComponentContextualTypes {
+		cm.AddContextualType(depType)
+	}
+	return cm
+}
+
 func (cm *DefaultComponentManager) Initialize() {
 	options := cm.options
 
@@ -103,6 +148,8 @@ func (cm *DefaultComponentManager) Initialize() {
 		scopeCtxt := NewScopeContext(parentScope, props)
 		compCtxt := NewComponentContext(scopeCtxt, cm, types.Of(new(Scope)))
 		TrackDependent(compCtxt, depCtxt.(ContextEx))
+		// scope opened by a component being created resolves in the chain of its creation
+		InheritResolution(compCtxt, depCtxt.(ContextEx))
 		return ScopeEx(NewScope(compCtxt))
 	})
 
@@ -124,30 +171,169 @@ func (cm *DefaultComponentManager) PrintDiagnostics() {
 		deps := cm.dependencies.GetAllDeps()
 		for _, dep := range deps {
 			fmt.Printf("\tDependency Type: %s\n", dep.FullName())
+			if registration := cm.registrations.GetRegistration(dep); registration != nil && registration.Mode != RegistrationMode_Default {
+				if registration.Replaced != nil {
+					fmt.Printf("\t\tRegistration Mode: %v, replaced: %v\n", registration.Mode, registration.Replaced)
+				} else {
+					fmt.Printf("\t\tRegistration Mode: %v\n", registration.Mode)
+				}
+			}
+			if decorators := cm.GetDecorators(dep); len(decorators) > 0 {
+				fmt.Printf("\t\tDecoration Chain: %s\n", DecorationChainToString(dep, decorators))
+			}
+		}
+		for _, registration := range cm.registrations.GetAllRegistrations() {
+			if registration.Key != nil {
+				fmt.Printf("\tKeyed Dependency: %v\n", registration)
+			}
 		}
 	}
 }
 
-func (cm *DefaultComponentManager) addComponent(getInstance FactoryMethod, componentType types.DataType) {
+func (cm *DefaultComponentManager) Dispose() error {
+	return cm.globalScope.GetScope().Dispose()
+}
+
+func (cm *DefaultComponentManager) Validate() error {
+	return NewDependencyValidator(cm.getRegistrationReader()).Validate()
+}
+
+func (cm *DefaultComponentManager) ExportGraph() *DependencyGraph {
+	return newGraphBuilder(cm.getRegistrationReader()).Build()
+}
+func (cm *DefaultComponentManager) ExportRuntimeGraph() *DependencyGraph {
+	graph := cm.ExportGraph()
+	addGraphInstances(graph, cm.instances.GetAll())
+	return graph
+}
+func (cm *DefaultComponentManager) RecordInstance(compCtxt ContextEx, compType types.DataType, lifetime Lifetime) {
+	record := &InstanceRecord{
+		ComponentType: compType,
+		Lifetime:      lifetime,
+		Context:       compCtxt,
+	}
+	cm.instances.Add(record)
+	// singleton lives until the manager is disposed, scoped until its scope is disposed
+	scopeCtxt := cm.globalScope
+	if lifetime == Lifetime_Scoped {
+		scopeCtxt = compCtxt.GetScopeContext()
+	}
+	scopeCtxt.GetScope().TrackDisposable(instanceRecordRelease(func() {
+		cm.instances.Remove(record)
+	}))
+}
+func (cm *DefaultComponentManager) addHubImplementation(registration *ComponentRegistration) {
+	cm.registrations.AddHubImplementation(registration)
+}
+
+// registrations of the parent are read as leaves by child component manager
+func (cm *DefaultComponentManager) getRegistrationReader() RegistrationReader {
+	if cm.parent != nil {
+		return newInheritedRegistrations(cm.registrations, cm.parent.GetRegistrations())
+	}
+	return cm.registrations
+}
+
+func (cm *DefaultComponentManager) GetRegistrations() RegistrationReader {
+	return cm.registrations
+}
+func (cm *DefaultComponentManager) AddContextualType(depType types.DataType) {
+	cm.registrations.AddContextualType(depType)
+}
+
+// view of the manager sharing the same registrations, whose registrations are handled by the mode
+func (cm *DefaultComponentManager) WithRegistrationMode(mode RegistrationMode) ComponentCollection {
+	return &registrationModeView{DefaultComponentManager: cm, mode: mode}
+}
+
+// registrationModeView registers components into the manager with the mode passed along the registration,
+// everything else including component contexts created by registrations is served by the manager itself
+type registrationModeView struct {
+	*DefaultComponentManager
+	mode RegistrationMode
+}
+
+func (view *registrationModeView) AddConfiguration(configuration interface{}) {
+	view.addConfiguration(view.mode, configuration)
+}
+func (view *registrationModeView) RegisterSingletonForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType) {
+	view.registerSingletonForTypes(view.mode, createInstance, interfaceType)
+}
+func (view *registrationModeView) RegisterSingletonForTypes(createInstance FreeStyleFactoryMethod, interfaceTypes ...types.DataType) {
+	view.registerSingletonForTypes(view.mode, createInstance, interfaceTypes...)
+}
+func (view *registrationModeView) AddSingletonForTypes(createInstance FreeStyleFactoryMethod, interfaceTypes ...types.DataType) {
+	view.addSingletonForTypes(view.mode, createInstance, interfaceTypes...)
+}
+func (view *registrationModeView) AddSingletonWithContext(createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod, interfaceTypes ...types.DataType) {
+	view.registerSingletonWithContext(view.mode, createInstance, createCtxt, interfaceTypes...)
+}
+func (view *registrationModeView) RegisterScopedForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType) {
+	view.registerScopedForType(view.mode, createInstance, interfaceType, ScopeType_Any)
+}
+func (view *registrationModeView) RegisterScopedForTypeEx(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, scopeType types.DataType) {
+	view.registerScopedForType(view.mode, createInstance, interfaceType, scopeType)
+}
+func (view *registrationModeView) AddScopedForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, scopeType types.DataType) {
+	view.addScopedForType(view.mode, createInstance, interfaceType, scopeType)
+}
+func (view *registrationModeView) RegisterTransientForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType) {
+	view.registerTransientForType(view.mode, createInstance, interfaceType)
+}
+func (view *registrationModeView) AddTransientForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType) {
+	view.addTransientForType(view.mode, createInstance, interfaceType)
+}
+func (view *registrationModeView) RegisterManyForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, lifetime Lifetime, scopeType types.DataType) {
+	view.registerManyForType(view.mode, createInstance, interfaceType, lifetime, scopeType)
+}
+func (view *registrationModeView) RegisterKeyedForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, key any, lifetime Lifetime, scopeType types.DataType) {
+	view.registerKeyedForType(view.mode, createInstance, interfaceType, key, lifetime, scopeType)
+}
+func (view *registrationModeView) AddComponent(factoryMethod FactoryMethod, interfaceType types.DataType) {
+	view.addFactoryMethod(view.mode, factoryMethod, interfaceType)
+}
+
+func (cm *DefaultComponentManager) addComponent(mode RegistrationMode, getInstance FactoryMethod, registration *ComponentRegistration) {
+	componentType := registration.ComponentType
+	registration.Mode = mode
 	if cm.IsComponentRegistered(componentType) {
-		panic(fmt.Errorf("specified component type already exist: %s", componentType.FullName()))
+		switch mode {
+		case RegistrationMode_TryRegister:
+			return
+		case RegistrationMode_Replace:
+			if len(cm.implementations[componentType.Key()]) > 0 {
+				panic(fmt.Errorf("not allowed to replace component type registered with multiple implementations: %s", componentType.FullName()))
+			}
+			registration.Replaced = cm.registrations.GetRegistration(componentType)
+			cm.dependencies.RemoveDependency(componentType)
+		default:
+			panic(fmt.Errorf("specified component type already exist: %s", componentType.FullName()))
+		}
 	}
 
 	cm.dependencies.AddDependency(getInstance, componentType)
+	cm.registrations.AddRegistration(registration)
 }
 func (cm *DefaultComponentManager) IsComponentRegistered(componentType types.DataType) bool {
 	return cm.dependencies.ExistDependency(componentType)
 }
 
 func (cm *DefaultComponentManager) AddConfiguration(configuration interface{}) {
+	cm.addConfiguration(RegistrationMode_Default, configuration)
+}
+func (cm *DefaultComponentManager) addConfiguration(mode RegistrationMode, configuration interface{}) {
 	if configuration == nil {
 		panic(fmt.Errorf("specified configuration is nil"))
 	}
 	configType := types.Of(configuration).ElementType()
 	cm.options.ValidateConfigurationTypeAllowed(configType)
-	cm.addComponent(func(Context, types.DataType, Properties) interface{} {
+	cm.addComponent(mode, func(Context, types.DataType, Properties) interface{} {
 		return configuration
-	}, configType)
+	}, &ComponentRegistration{
+		ComponentType:   configType,
+		Lifetime:        Lifetime_Singleton,
+		IsConfiguration: true,
+	})
 }
 
 func (cm *DefaultComponentManager) validateFreeStyleFactoryMethod(createInstance FreeStyleFactoryMethod, interfaceTypes ...types.DataType) {
@@ -186,32 +372,45 @@ func (cm *DefaultComponentManager) RegisterSingletonForType(createInstance FreeS
 	cm.RegisterSingletonForTypes(createInstance, interfaceType)
 }
 func (cm *DefaultComponentManager) RegisterSingletonForTypes(createInstance FreeStyleFactoryMethod, interfaceTypes ...types.DataType) {
+	cm.registerSingletonForTypes(RegistrationMode_Default, createInstance, interfaceTypes...)
+}
+func (cm *DefaultComponentManager) registerSingletonForTypes(mode RegistrationMode, createInstance FreeStyleFactoryMethod, interfaceTypes ...types.DataType) {
 	for _, interfaceType := range interfaceTypes {
 		cm.options.ValidateComponentTypeAllowed(interfaceType)
 	}
 	cm.validateFreeStyleFactoryMethod(createInstance, interfaceTypes...)
-	cm.AddSingletonForTypes(createInstance, interfaceTypes...)
+	cm.addSingletonForTypes(mode, createInstance, interfaceTypes...)
 }
 func (cm *DefaultComponentManager) AddSingletonForTypes(createInstance FreeStyleFactoryMethod, interfaceTypes ...types.DataType) {
+	cm.addSingletonForTypes(RegistrationMode_Default, createInstance, interfaceTypes...)
+}
+func (cm *DefaultComponentManager) addSingletonForTypes(mode RegistrationMode, createInstance FreeStyleFactoryMethod, interfaceTypes ...types.DataType) {
 	globalScope := cm.globalScope
 	// use the first interface type as the singleton's component context type
 	createCtxt := func(scopeCtxt ScopeContextEx) ContextEx {
 		return GetComponentContextFactory(cm, interfaceTypes[0])(globalScope)
 	}
-	cm.addSingletonWithContext(createInstance, createCtxt, interfaceTypes...)
+	cm.addSingletonWithContext(mode, createInstance, createCtxt, interfaceTypes...)
 }
 func (cm *DefaultComponentManager) AddSingletonWithContext(createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod, interfaceTypes ...types.DataType) {
+	cm.registerSingletonWithContext(RegistrationMode_Default, createInstance, createCtxt, interfaceTypes...)
+}
+func (cm *DefaultComponentManager) registerSingletonWithContext(mode RegistrationMode, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod, interfaceTypes ...types.DataType) {
 	for _, interfaceType := range interfaceTypes {
 		cm.options.ValidateComponentTypeAllowed(interfaceType)
 	}
 	cm.validateFreeStyleFactoryMethod(createInstance, interfaceTypes...)
-	cm.addSingletonWithContext(createInstance, createCtxt, interfaceTypes...)
+	cm.addSingletonWithContext(mode, createInstance, createCtxt, interfaceTypes...)
 }
-func (cm *DefaultComponentManager) addSingletonWithContext(createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod, interfaceTypes ...types.DataType) {
+func (cm *DefaultComponentManager) addSingletonWithContext(mode RegistrationMode, createInstance FreeStyleFactoryMethod, createCtxt ContextFactoryMethod, interfaceTypes ...types.DataType) {
 	factoryMethod := cm.lifecycleController.BuildSingletonFactoryMethod(interfaceTypes, createInstance, createCtxt)
 
 	for _, interfaceType := range interfaceTypes {
-		cm.addComponent(factoryMethod, interfaceType)
+		cm.addComponent(mode, factoryMethod, &ComponentRegistration{
+			ComponentType: interfaceType,
+			Lifetime:      Lifetime_Singleton,
+			FactoryMethod: createInstance,
+		})
 	}
 }
 
@@ -219,35 +418,204 @@ func (cm *DefaultComponentManager) RegisterScopedForType(createInstance FreeStyl
 	cm.RegisterScopedForTypeEx(createInstance, interfaceType, ScopeType_Any)
 }
 func (cm *DefaultComponentManager) RegisterScopedForTypeEx(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, scopeType types.DataType) {
+	cm.registerScopedForType(RegistrationMode_Default, createInstance, interfaceType, scopeType)
+}
+func (cm *DefaultComponentManager) registerScopedForType(mode RegistrationMode, createInstance FreeStyleFactoryMethod, interfaceType types.DataType, scopeType types.DataType) {
 	cm.options.ValidateComponentTypeAllowed(interfaceType)
 	cm.validateFreeStyleFactoryMethod(createInstance, interfaceType)
 
-	cm.AddScopedForType(createInstance, interfaceType, scopeType)
+	cm.addScopedForType(mode, createInstance, interfaceType, scopeType)
 }
 func (cm *DefaultComponentManager) AddScopedForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, scopeType types.DataType) {
+	cm.addScopedForType(RegistrationMode_Default, createInstance, interfaceType, scopeType)
+}
+func (cm *DefaultComponentManager) addScopedForType(mode RegistrationMode, createInstance FreeStyleFactoryMethod, interfaceType types.DataType, scopeType types.DataType) {
 	createCtxt := GetComponentContextFactory(cm, interfaceType)
 	factoryMethod := cm.lifecycleController.BuildScopedFactoryMethod(interfaceType, scopeType, createInstance, createCtxt)
 
-	cm.addComponent(factoryMethod, interfaceType)
+	// instance of typed scope is available as contextual dependency in the scope
+	if scopeType.Key() != ScopeType_Any.Key() {
+		cm.AddContextualType(scopeType)
+	}
+	cm.addComponent(mode, factoryMethod, &ComponentRegistration{
+		ComponentType: interfaceType,
+		Lifetime:      Lifetime_Scoped,
+		ScopeType:     scopeType,
+		FactoryMethod: createInstance,
+	})
 }
 
 func (cm *DefaultComponentManager) RegisterTransientForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType) {
+	cm.registerTransientForType(RegistrationMode_Default, createInstance, interfaceType)
+}
+func (cm *DefaultComponentManager) registerTransientForType(mode RegistrationMode, createInstance FreeStyleFactoryMethod, interfaceType types.DataType) {
 	cm.options.ValidateComponentTypeAllowed(interfaceType)
 	cm.validateFreeStyleFactoryMethod(createInstance, interfaceType)
-	cm.AddTransientForType(createInstance, interfaceType)
+	cm.addTransientForType(mode, createInstance, interfaceType)
 }
 func (cm *DefaultComponentManager) AddTransientForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType) {
+	cm.addTransientForType(RegistrationMode_Default, createInstance, interfaceType)
+}
+func (cm *DefaultComponentManager) addTransientForType(mode RegistrationMode, createInstance FreeStyleFactoryMethod, interfaceType types.DataType) {
 	createCtxt := GetComponentContextFactory(cm, interfaceType)
 	factoryMethod := cm.lifecycleController.BuildTransientFactoryMethod(interfaceType, createInstance, createCtxt)
-	cm.addComponent(factoryMethod, interfaceType)
+	cm.addComponent(mode, factoryMethod, &ComponentRegistration{
+		ComponentType: interfaceType,
+		Lifetime:      Lifetime_Transient,
+		FactoryMethod: createInstance,
+	})
+}
+
+func (cm *DefaultComponentManager) RegisterManyForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, lifetime Lifetime, scopeType types.DataType) {
+	cm.registerManyForType(RegistrationMode_Default, createInstance, interfaceType, lifetime, scopeType)
+}
+func (cm *DefaultComponentManager) registerManyForType(mode RegistrationMode, createInstance FreeStyleFactoryMethod, interfaceType types.DataType, lifetime Lifetime, scopeType types.DataType) {
+	cm.options.ValidateComponentTypeAllowed(interfaceType)
+	cm.validateFreeStyleFactoryMethod(createInstance, interfaceType)
+	if mode != RegistrationMode_Default {
+		panic(fmt.Errorf("registration mode %v is not supported for multiple implementations: %s", mode, interfaceType.FullName()))
+	}
+	if cm.IsComponentRegistered(interfaceType) && len(cm.implementations[interfaceType.Key()]) == 0 {
+		panic(fmt.Errorf("specified component type already registered as single implementation: %s", interfaceType.FullName()))
+	}
+
+	registration := &ComponentRegistration{
+		ComponentType: interfaceType,
+		Lifetime:      lifetime,
+		FactoryMethod: createInstance,
+	}
+	implIndex := len(cm.implementations[interfaceType.Key()])
+	createCtxt := GetComponentContextFactory(cm, interfaceType)
+	var factoryMethod FactoryMethod
+	switch lifetime {
+	case Lifetime_Singleton:
+		globalScope := cm.globalScope
+		factoryMethod = cm.lifecycleController.BuildSingletonFactoryMethod([]types.DataType{interfaceType}, createInstance, func(scopeCtxt ScopeContextEx) ContextEx {
+			return createCtxt(globalScope)
+		})
+	case Lifetime_Scoped:
+		registration.ScopeType = scopeType
+		if scopeType.Key() != ScopeType_Any.Key() {
+			cm.AddContextualType(scopeType)
+		}
+		factoryMethod = cm.lifecycleController.BuildScopedImplFactoryMethod(interfaceType, implIndex, scopeType, createInstance, createCtxt)
+	case Lifetime_Transient:
+		factoryMethod = cm.lifecycleController.BuildTransientFactoryMethod(interfaceType, createInstance, createCtxt)
+	default:
+		panic(fmt.Errorf("unexpected lifetime %v to register multiple implementations: %s", lifetime, interfaceType.FullName()))
+	}
+
+	cm.addImplementation(factoryMethod, interfaceType)
+	cm.registrations.AddImplementation(registration)
+}
+func (cm *DefaultComponentManager) addImplementation(factoryMethod FactoryMethod, interfaceType types.DataType) {
+	key := interfaceType.Key()
+	if len(cm.implementations[key]) == 0 {
+		// the last implementation is resolved if the component type is requested directly
+		cm.dependencies.AddDependency(func(context Context, interfaceType types.DataType, props Properties) any {
+			impls := cm.implementations[key]
+			return impls[len(impls)-1](context, interfaceType, props)
+		}, interfaceType)
+		// all implementations are resolved in registration order if collection of the component type is requested
+		cm.dependencies.AddDependency(func(context Context, sliceType types.DataType, props Properties) any {
+			impls := cm.implementations[key]
+			instances := make([]any, 0, len(impls))
+			for _, createInstance := range impls {
+				instance := createInstance(context, interfaceType, props)
+				validateInstanceType(instance, interfaceType)
+				instances = append(instances, instance)
+			}
+			return types.MakeSlice(sliceType, instances)
+		}, interfaceType.SliceType())
+	}
+	cm.implementations[key] = append(cm.implementations[key], factoryMethod)
+}
+
+func (cm *DefaultComponentManager) RegisterKeyedForType(createInstance FreeStyleFactoryMethod, interfaceType types.DataType, key any, lifetime Lifetime, scopeType types.DataType) {
+	cm.registerKeyedForType(RegistrationMode_Default, createInstance, interfaceType, key, lifetime, scopeType)
+}
+func (cm *DefaultComponentManager) registerKeyedForType(mode RegistrationMode, createInstance FreeStyleFactoryMethod, interfaceType types.DataType, key any, lifetime Lifetime, scopeType types.DataType) {
+	cm.options.ValidateComponentTypeAllowed(interfaceType)
+	cm.validateFreeStyleFactoryMethod(createInstance, interfaceType)
+	if key == nil || !reflect.TypeOf(key).Comparable() {
+		panic(fmt.Errorf("key of keyed component should be comparable and not nil: %s, key - %v", interfaceType.FullName(), key))
+	}
+
+	depKey := newKeyedDependencyKey(interfaceType, key)
+	registration := &ComponentRegistration{
+		ComponentType: interfaceType,
+		Lifetime:      lifetime,
+		FactoryMethod: createInstance,
+		Mode:          mode,
+		Key:           key,
+	}
+	if exist := cm.registrations.GetKeyedRegistration(interfaceType, key); exist != nil {
+		switch mode {
+		case RegistrationMode_TryRegister:
+			return
+		case RegistrationMode_Replace:
+			registration.Replaced = exist
+		default:
+			panic(fmt.Errorf("specified keyed component already exist: %s", registration.Name()))
+		}
+	}
+
+	createCtxt := GetComponentContextFactory(cm, interfaceType)
+	var factoryMethod FactoryMethod
+	switch lifetime {
+	case Lifetime_Singleton:
+		globalScope := cm.globalScope
+		factoryMethod = cm.lifecycleController.BuildSingletonFactoryMethod([]types.DataType{interfaceType}, createInstance, func(scopeCtxt ScopeContextEx) ContextEx {
+			return createCtxt(globalScope)
+		})
+	case Lifetime_Scoped:
+		registration.ScopeType = scopeType
+		if scopeType.Key() != ScopeType_Any.Key() {
+			cm.AddContextualType(scopeType)
+		}
+		factoryMethod = cm.lifecycleController.BuildScopedKeyedFactoryMethod(interfaceType, key, scopeType, createInstance, createCtxt)
+	case Lifetime_Transient:
+		factoryMethod = cm.lifecycleController.BuildTransientFactoryMethod(interfaceType, createInstance, createCtxt)
+	default:
+		panic(fmt.Errorf("unexpected lifetime %v to register keyed component: %s", lifetime, registration.Name()))
+	}
+
+	cm.keyed[depKey] = factoryMethod
+	cm.registrations.AddKeyedRegistration(registration)
+}
+
+func (cm *DefaultComponentManager) DecorateForType(decorator FreeStyleDecoratorMethod, interfaceType types.DataType) {
+	cm.options.ValidateComponentTypeAllowed(interfaceType)
+	if !cm.IsComponentRegistered(interfaceType) {
+		panic(fmt.Errorf("decorated component type is not registered: %s", interfaceType.FullName()))
+	}
+	funcType := types.GetFuncType(decorator)
+	if funcType.GetNumOfInput() < 1 || funcType.GetInput(0).Key() != interfaceType.Key() {
+		panic(fmt.Errorf("first parameter of decorator should be of decorated component type: %s", interfaceType.FullName()))
+	}
+	cm.validateFreeStyleFactoryMethod(decorator, interfaceType)
+
+	cm.registrations.AddDecorator(&DecoratorRegistration{
+		ComponentType: interfaceType,
+		Decorator:     decorator,
+	})
+}
+func (cm *DefaultComponentManager) GetDecorators(componentType types.DataType) []*DecoratorRegistration {
+	return cm.registrations.GetDecorators(componentType)
 }
 func (cm *DefaultComponentManager) AddComponent(factoryMethod FactoryMethod, interfaceType types.DataType) {
-	cm.addComponent(factoryMethod, interfaceType)
+	cm.addFactoryMethod(RegistrationMode_Default, factoryMethod, interfaceType)
+}
+func (cm *DefaultComponentManager) addFactoryMethod(mode RegistrationMode, factoryMethod FactoryMethod, interfaceType types.DataType) {
+	cm.addComponent(mode, factoryMethod, &ComponentRegistration{
+		ComponentType: interfaceType,
+		Lifetime:      Lifetime_Unknown,
+	})
 }
 
 func validateInstanceType(instance any, componentType types.DataType) {
 	if instance == nil {
-		panic(fmt.Errorf("created component instance is nil, type: %v, quit", componentType.FullName()))
+		panic(NewFactoryError(componentType, nil, "created component instance is nil, type: %v, quit", componentType.FullName()))
 	}
 
 	instanceType := types.Of(instance)
@@ -261,13 +629,20 @@ func validateInstanceType(instance any, componentType types.DataType) {
 		return
 	}
 
-	panic(fmt.Errorf("created component instance type does not match, instance type: %v, requested type: %v", instanceType.FullName(), componentType.FullName()))
+	panic(NewTypeConstraintError(componentType, "created component instance type does not match, instance type: %v, requested type: %v", instanceType.FullName(), componentType.FullName()))
 }
 func (cm *DefaultComponentManager) resolveInstance(interfaceType types.DataType, dependent Context, props Properties) any {
 	createInstance := cm.dependencies.GetDependency(interfaceType)
 	return createInstance(dependent, interfaceType, props)
 }
+// type not registered in child component manager is resolved from the parent
+func (cm *DefaultComponentManager) isInherited(componentType types.DataType) bool {
+	return cm.parent != nil && !cm.dependencies.ExistDependency(componentType)
+}
 func (cm *DefaultComponentManager) GetConfiguration(configType types.DataType, dependent Context) any {
+	if cm.isInherited(configType) {
+		return cm.parent.GetConfiguration(configType, dependent)
+	}
 	cm.options.ValidateConfigurationTypeAllowed(configType)
 
 	instance := cm.resolveInstance(configType, dependent, nil)
@@ -275,6 +650,9 @@ func (cm *DefaultComponentManager) GetConfiguration(configType types.DataType, d
 	return instance
 }
 func (cm *DefaultComponentManager) GetOrCreateWithProperties(interfaceType types.DataType, dependent Context, props Properties) any {
+	if cm.isInherited(interfaceType) {
+		return cm.parent.GetOrCreateWithProperties(interfaceType, dependent, props)
+	}
 	cm.options.ValidateComponentTypeAllowed(interfaceType)
 
 	instance := cm.resolveInstance(interfaceType, dependent, props)
@@ -282,6 +660,38 @@ func (cm *DefaultComponentManager) GetOrCreateWithProperties(interfaceType types
 	return instance
 }
 
+// keyed component not registered in child component manager is resolved from the parent
+func (cm *DefaultComponentManager) GetKeyedComponent(interfaceType types.DataType, key any, dependent Context) any {
+	createInstance, exist := cm.keyed[newKeyedDependencyKey(interfaceType, key)]
+	if !exist {
+		if cm.parent != nil {
+			return cm.parent.GetKeyedComponent(interfaceType, key, dependent)
+		}
+		panic(NewKeyedComponentNotRegisteredError(interfaceType, key))
+	}
+	cm.options.ValidateComponentTypeAllowed(interfaceType)
+
+	instance := createInstance(dependent, interfaceType, nil)
+	validateInstanceType(instance, interfaceType)
+	return instance
+}
+
+func (cm *DefaultComponentManager) TryGetConfiguration(configType types.DataType, dependent Context) (any, error) {
+	return CatchResolutionError(configType, func() any {
+		return cm.GetConfiguration(configType, dependent)
+	})
+}
+func (cm *DefaultComponentManager) TryGetOrCreateWithProperties(interfaceType types.DataType, dependent Context, props Properties) (any, error) {
+	return CatchResolutionError(interfaceType, func() any {
+		return cm.GetOrCreateWithProperties(interfaceType, dependent, props)
+	})
+}
+func (cm *DefaultComponentManager) TryGetKeyedComponent(interfaceType types.DataType, key any, dependent Context) (any, error) {
+	return CatchResolutionError(interfaceType, func() any {
+		return cm.GetKeyedComponent(interfaceType, key, dependent)
+	})
+}
+
 // test used only, wrapped call to ContextualProvider::GetOrCreateWithProperties
 func (cm *DefaultComponentManager) GetComponent(componentType types.DataType, dependent Context) any {
 	return cm.GetOrCreateWithProperties(componentType, dependent, nil)
@@ -1335,7 +1335,7 @@ func TestComponentManager_Scoped_lifetime(t *testing.T) {
 }
 
 func TestComponentManager_Scoped_lifetime_exceed(t *testing.T) {
-	defer test.AssertPanicContent(t, "must be used in scope of type", "panic content mis-match for Scoped_lifetime_exceed")
+	defer test.AssertPanicContent(t, "captive dependency detected: dep.FirstInterface[Singleton] -> dep.SecondInterface[Scoped]", "panic content mis-match for Scoped_lifetime_exceed")
 
 	options := NewComponentProviderOptions(InterfaceType, StructType)
 	options.AllowTypeAnyFromFactoryMethod = true
@@ -2429,16 +2429,3 @@ func TestSingletonTypeName(t *testing.T) {
 		t.Errorf("string value of single compTypes is not expected: %s, actual - %s", expected, strVal)
 	}
 }
-
-func Test_threadId(t *testing.T) {
-	//stackAsBytes := debug.Stack()
-	//stackAsString := string(stackAsBytes)
-	//fmt.Printf("stack: %s\n", stackAsString)
-
-	gid := goroutine_id()
-	if gid <= 0 {
-		t.Errorf("unexpected goroutine id: %d", gid)
-	} else {
-		t.Logf("goroutine id: %d\n", gid)
-	}
-}
@@ -0,0 +1,145 @@
+package dep
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+)
+
+func newAnotherStructWithValue(value int) func(context Context) *AnotherStruct {
+	return func(context Context) *AnotherStruct {
+		another := NewAnotherStruct(context)
+		another.value = value
+		return another
+	}
+}
+
+type ManyHolder struct {
+	ActualStruct
+	others []AnotherInterface
+}
+
+func TestRegisterMany(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterMany[AnotherInterface](cm, Lifetime_Singleton, newAnotherStructWithValue(1))
+	RegisterMany[AnotherInterface](cm, Lifetime_Transient, newAnotherStructWithValue(2))
+	RegisterMany[AnotherInterface](cm, Lifetime_Singleton, newAnotherStructWithValue(3))
+	RegisterTransient[FirstInterface](cm, func(others []AnotherInterface) *ManyHolder {
+		return &ManyHolder{others: others}
+	})
+
+	holder1 := GetComponentFrom[FirstInterface](cm, ctxt, nil).(*ManyHolder)
+	holder2 := GetComponentFrom[FirstInterface](cm, ctxt, nil).(*ManyHolder)
+	if len(holder1.others) != 3 {
+		t.Fatalf("all implementations should be injected, actual: %d", len(holder1.others))
+	}
+	for index, other := range holder1.others {
+		if other.(*AnotherStruct).value != index+1 {
+			t.Errorf("implementations should be injected in registration order, index: %d, value: %d", index, other.(*AnotherStruct).value)
+		}
+	}
+	if holder1.others[0] != holder2.others[0] || holder1.others[2] != holder2.others[2] {
+		t.Errorf("singleton implementation should be reused")
+	}
+	if holder1.others[1] == holder2.others[1] {
+		t.Errorf("transient implementation should be created on each injection")
+	}
+
+	// the last implementation wins if the component type is requested directly
+	another := GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	if another != holder1.others[2] {
+		t.Errorf("the last registered implementation should be resolved")
+	}
+}
+
+func TestRegisterMany_scoped(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithScope(options, ScopeTest)
+
+	RegisterManyScoped[AnotherInterface, TestScope](cm, newAnotherStructWithValue(1))
+	RegisterManyScoped[AnotherInterface, TestScope](cm, newAnotherStructWithValue(2))
+
+	inst1 := GetComponentFrom[[]AnotherInterface](cm, ctxt, nil)
+	inst2 := GetComponentFrom[[]AnotherInterface](cm, ctxt, nil)
+	if len(inst1) != 2 || inst1[0] == inst1[1] {
+		t.Fatalf("each scoped implementation should be created separately: %v", inst1)
+	}
+	if inst1[0] != inst2[0] || inst1[1] != inst2[1] {
+		t.Errorf("scoped implementations should be reused in the same scope")
+	}
+
+	ctxt1 := createNewContext(ctxt, ScopeTest)
+	inst3 := GetComponentFrom[[]AnotherInterface](cm, ctxt1, nil)
+	if inst1[0] == inst3[0] || inst1[1] == inst3[1] {
+		t.Errorf("scoped implementations should be created in new scope")
+	}
+}
+
+func TestRegisterMany_empty(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterTransient[FirstInterface](cm, func(others []AnotherInterface) *ManyHolder {
+		return &ManyHolder{others: others}
+	})
+
+	holder := GetComponentFrom[FirstInterface](cm, ctxt, nil).(*ManyHolder)
+	if holder.others == nil || len(holder.others) != 0 {
+		t.Errorf("empty collection should be injected if no implementation registered: %v", holder.others)
+	}
+	if err := cm.Validate(); err != nil {
+		t.Errorf("collection dependency should not be reported as missing: %v", err)
+	}
+}
+
+func TestRegisterMany_registered_as_single(t *testing.T) {
+	defer test.AssertPanicContent(t, "specified component type already registered as single implementation", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+	RegisterMany[AnotherInterface](cm, Lifetime_Singleton, NewAnotherStruct)
+}
+
+func TestRegisterMany_register_single(t *testing.T) {
+	defer test.AssertPanicContent(t, "already exist", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterMany[AnotherInterface](cm, Lifetime_Singleton, NewAnotherStruct)
+	RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+}
+
+func TestRegisterMany_type_not_allowed(t *testing.T) {
+	defer test.AssertPanicContent(t, "not allowed", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterMany[*AnotherStruct](cm, Lifetime_Singleton, NewAnotherStruct)
+}
+
+func TestComponentManager_Validate_many(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterMany[AnotherInterface](cm, Lifetime_Singleton, NewAnotherStruct)
+	RegisterMany[AnotherInterface](cm, Lifetime_Transient, func(first FirstInterface) *AnotherStruct { return NewAnotherStruct(nil) })
+	RegisterSingleton[FirstInterface](cm, func(others []AnotherInterface) *ActualStruct { return NewActualStruct() })
+
+	err := cm.Validate()
+	var validationErr *DependencyValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("cycle through implementation should be detected, actual: %v", err)
+	}
+	expected := "cyclic dependency detected: dep.AnotherInterface -> dep.FirstInterface -> dep.AnotherInterface"
+	if len(validationErr.Errors) != 1 || !strings.Contains(err.Error(), expected) {
+		t.Errorf("validation error should contain only: %s, actual: %v", expected, err)
+	}
+}
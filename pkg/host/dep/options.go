@@ -73,6 +73,9 @@ type ComponentProviderOptions struct {
 
 	// enable properties pass-over
 	PropertiesPassOver bool
+
+	// validate dependencies of registered components when building the host
+	ValidateOnBuild bool
 }
 
 func NewComponentProviderOptions(allowedComponentTypes ...TypeConstraint) *ComponentProviderOptions {
@@ -85,6 +88,7 @@ func NewComponentProviderOptions(allowedComponentTypes ...TypeConstraint) *Compo
 		TrackTransientRecurrence:      false,
 		MaxAllowedRecurrence:          2,
 		PropertiesPassOver:            false,
+		ValidateOnBuild:               false,
 	}
 	options.AllowedComponentTypes = append(options.AllowedComponentTypes, allowedComponentTypes...)
 	options.AllowedConfigurationTypes = append(options.AllowedConfigurationTypes, StructType)
@@ -108,13 +112,17 @@ func (cpo *ComponentProviderOptions) ValidateConfigurationTypeAllowed(configType
 			return
 		}
 	}
-	panic(fmt.Errorf("configuration type not allowed: %v, allowed types: %v", configType.FullName(), cpo.ToString(cpo.AllowedConfigurationTypes)))
+	panic(NewTypeConstraintError(configType, "configuration type not allowed: %v, allowed types: %v", configType.FullName(), cpo.ToString(cpo.AllowedConfigurationTypes)))
 }
 func (cpo *ComponentProviderOptions) ValidateComponentTypeAllowed(componentType types.DataType) {
+	// collection of multiple implementations
+	if componentType.IsSlice() {
+		componentType = componentType.ElementType()
+	}
 	for _, allowedType := range cpo.AllowedComponentTypes {
 		if matchTypeConstraint(componentType, allowedType) {
 			return
 		}
 	}
-	panic(fmt.Errorf("component type not allowed: %v, allowed types: %v", componentType.FullName(), cpo.ToString(cpo.AllowedComponentTypes)))
+	panic(NewTypeConstraintError(componentType, "component type not allowed: %v, allowed types: %v", componentType.FullName(), cpo.ToString(cpo.AllowedComponentTypes)))
 }
@@ -24,6 +24,26 @@ func CreateComponent[T any](provider ComponentProviderEx, props Properties) T {
 	return provider.CreateWithProperties(types.Get[T](), props).(T)
 }
 
+// error-returning variants of GetConfig, GetComponent and CreateComponent, for optional components
+func TryGetConfig[T any](provider ComponentProvider) (*T, error) {
+	configType := types.Get[T]()
+	return castResolved[*T](CatchResolutionError(configType, func() any {
+		return provider.GetConfiguration(configType)
+	}))
+}
+func TryGetComponent[T any](provider ComponentProvider) (T, error) {
+	componentType := types.Get[T]()
+	return castResolved[T](CatchResolutionError(componentType, func() any {
+		return provider.GetComponent(componentType)
+	}))
+}
+func TryCreateComponent[T any](provider ComponentProviderEx, props Properties) (T, error) {
+	componentType := types.Get[T]()
+	return castResolved[T](CatchResolutionError(componentType, func() any {
+		return provider.CreateWithProperties(componentType, props)
+	}))
+}
+
 
 type DefaultComponentProvider struct {
 	context  Context
@@ -38,7 +38,7 @@ type DefaultRecurrenceTracker struct {
 	data *TrackingData
 }
 type TrackingData struct {
-	gid            int32
+	// times the component is being created in the resolution chain of the dependent
 	recursiveDepth uint32
 }
 
@@ -50,7 +50,6 @@ func NewRecurrenceTracker(mgr RecurrenceManager, data *TrackingData) *DefaultRec
 }
 func (rt *DefaultRecurrenceTracker) Execute(createComponent FactoryAction) (interface{}, ContextEx, bool) {
 	if rt.IsNextRecurrenceAllowed() {
-		defer func() { rt.DecreaseRecurrence() }()
 		rt.IncreaseRecurrence()
 		instance, compCtxt := createComponent()
 		return instance, compCtxt, false
@@ -63,29 +62,20 @@ func (rt *DefaultRecurrenceTracker) IsNextRecurrenceAllowed() bool {
 }
 func (rt *DefaultRecurrenceTracker) IncreaseRecurrence() {
 	rt.data.recursiveDepth++
-	fmt.Printf("goroutine %d: recurrence on %s - %d\n", rt.data.gid, rt.mgr.GetComponentType().FullName(), rt.data.recursiveDepth)
-}
-func (rt *DefaultRecurrenceTracker) DecreaseRecurrence() {
-	rt.data.recursiveDepth--
-	if rt.data.recursiveDepth <= 0 {
-		rt.mgr.RemoveTracker(rt.data.gid)
-	}
+	fmt.Printf("recurrence on %s - %d\n", rt.mgr.GetComponentType().FullName(), rt.data.recursiveDepth)
 }
 
 type RecurrenceManager interface {
 	GetMaxAllowedRecursive() uint32
 	GetComponentType() types.DataType
 
-	GetTracker() RecurrenceTracker
-	RemoveTracker(gid int32)
+	// get tracker for the resolution chain of the dependent, whose frames created by the manager are counted
+	GetTracker(dependentFrame *ResolutionFrame) RecurrenceTracker
 }
 type DefaultRecurrenceManager struct {
 	maxRecursiveDepth uint32
 	trackRecurrence   bool
 	compType          types.DataType
-
-	mutex       sync.Mutex
-	recurrences map[int32]*TrackingData
 }
 
 func NewRecurrenceManager(options *LifecycleOptions, compType types.DataType) *DefaultRecurrenceManager {
@@ -93,7 +83,6 @@ func NewRecurrenceManager(options *LifecycleOptions, compType types.DataType) *D
 		maxRecursiveDepth: options.MaxAllowedRecurrence,
 		trackRecurrence:   options.TrackTransientRecurrence,
 		compType:          compType,
-		recurrences:       make(map[int32]*TrackingData),
 	}
 }
 func (rt *DefaultRecurrenceManager) GetMaxAllowedRecursive() uint32 {
@@ -102,33 +91,13 @@ func (rt *DefaultRecurrenceManager) GetMaxAllowedRecursive() uint32 {
 func (rt *DefaultRecurrenceManager) GetComponentType() types.DataType {
 	return rt.compType
 }
-func (rt *DefaultRecurrenceManager) GetTracker() RecurrenceTracker {
+func (rt *DefaultRecurrenceManager) GetTracker(dependentFrame *ResolutionFrame) RecurrenceTracker {
 	if rt.trackRecurrence {
-		gid := goroutine_id()
-		data := rt.getTrackingData(gid)
+		data := &TrackingData{
+			recursiveDepth: dependentFrame.CountOwnedBy(rt),
+		}
 		return NewRecurrenceTracker(rt, data)
 	} else {
 		return GetNonTracker()
 	}
 }
-func (rt *DefaultRecurrenceManager) RemoveTracker(gid int32) {
-	defer func() { rt.mutex.Unlock() }()
-	rt.mutex.Lock()
-
-	delete(rt.recurrences, gid)
-}
-func (rt *DefaultRecurrenceManager) getTrackingData(gid int32) *TrackingData {
-	defer func() { rt.mutex.Unlock() }()
-	rt.mutex.Lock()
-
-	data, exist := rt.recurrences[gid]
-	if !exist {
-		data = &TrackingData{
-			gid:            gid,
-			recursiveDepth: 0,
-		}
-		rt.recurrences[gid] = data
-	}
-
-	return data
-}
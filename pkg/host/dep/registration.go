@@ -0,0 +1,396 @@
+package dep
+
+import (
+	"fmt"
+	"sort"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+type Lifetime uint8
+
+const (
+	// registered by FactoryMethod directly, lifetime is managed by the factory method itself
+	Lifetime_Unknown Lifetime = iota
+	Lifetime_Singleton
+	Lifetime_Scoped
+	Lifetime_Transient
+)
+
+func (l Lifetime) String() string {
+	switch l {
+	case Lifetime_Unknown:
+		return "Unknown"
+	case Lifetime_Singleton:
+		return "Singleton"
+	case Lifetime_Scoped:
+		return "Scoped"
+	case Lifetime_Transient:
+		return "Transient"
+	default:
+		return fmt.Sprintf("Lifetime(%d)", l)
+	}
+}
+
+// check if instance of this lifetime lives longer than the other, unknown lifetime never outlives or is outlived
+func (l Lifetime) Outlives(other Lifetime) bool {
+	if l == Lifetime_Unknown || other == Lifetime_Unknown {
+		return false
+	}
+	// singleton > scoped > transient
+	return l < other
+}
+
+// how registration handles component type which is already registered
+type RegistrationMode uint8
+
+const (
+	// duplicated registration leads to panic
+	RegistrationMode_Default RegistrationMode = iota
+	// replace existing registration, or add if not registered, e.g. swap real dependency for fake in tests
+	RegistrationMode_Replace
+	// no-op if the component type is already registered, e.g. default implementation which can be overridden
+	RegistrationMode_TryRegister
+)
+
+func (rm RegistrationMode) String() string {
+	switch rm {
+	case RegistrationMode_Default:
+		return "Default"
+	case RegistrationMode_Replace:
+		return "Replace"
+	case RegistrationMode_TryRegister:
+		return "TryRegister"
+	default:
+		return fmt.Sprintf("RegistrationMode(%d)", rm)
+	}
+}
+
+// metadata of a registered component type
+type ComponentRegistration struct {
+	ComponentType types.DataType
+	Lifetime      Lifetime
+	// scope type of scoped component
+	ScopeType types.DataType
+	// free style factory method, nil if registered by FactoryMethod directly
+	FactoryMethod FreeStyleFactoryMethod
+	// configuration is registered as struct type but injected as pointer of struct
+	IsConfiguration bool
+	// one of multiple implementations registered by RegisterMany
+	IsMulti   bool
+	ImplIndex int
+	// mode of the registration, and the registration replaced by it if any
+	Mode     RegistrationMode
+	Replaced *ComponentRegistration
+	// key of implementation added to component hub by RegisterComponent, nil otherwise
+	HubKey any
+	// key of registration by RegisterKeyed, nil otherwise
+	Key any
+}
+
+// unique name of the registration, implementations of the same component type are distinguished by index, hub key or key
+func (cr *ComponentRegistration) Name() string {
+	name := cr.ComponentType.FullName()
+	if cr.IsMulti {
+		name = fmt.Sprintf("%s#%d", name, cr.ImplIndex)
+	}
+	if cr.HubKey != nil {
+		name = fmt.Sprintf("%s[%v]", name, cr.HubKey)
+	}
+	if cr.Key != nil {
+		name = getKeyedName(cr.ComponentType, cr.Key)
+	}
+	return name
+}
+
+// name of keyed component, e.g. dep.Storage{archive}
+func getKeyedName(componentType types.DataType, key any) string {
+	return fmt.Sprintf("%s{%v}", componentType.FullName(), key)
+}
+
+func (cr *ComponentRegistration) String() string {
+	if cr.IsConfiguration {
+		return fmt.Sprintf("%s[Configuration]", cr.ComponentType.FullName())
+	}
+	name := cr.Name()
+	if cr.Lifetime == Lifetime_Scoped && cr.ScopeType != nil {
+		return fmt.Sprintf("%s[%v@%s]", name, cr.Lifetime, cr.ScopeType.Name())
+	}
+	return fmt.Sprintf("%s[%v]", name, cr.Lifetime)
+}
+
+// get types of factory method parameters, or types of injected fields for struct registered by RegisterStruct,
+// empty if factory method is unknown
+func (cr *ComponentRegistration) GetParameterTypes() []types.DataType {
+	if cr.FactoryMethod == nil {
+		return []types.DataType{}
+	}
+	if factory, ok := cr.FactoryMethod.(structFactory); ok {
+		fields := factory.getInjectedFields()
+		fieldTypes := make([]types.DataType, 0, len(fields))
+		for _, field := range fields {
+			fieldTypes = append(fieldTypes, field.Type)
+		}
+		return fieldTypes
+	}
+	funcType := types.GetFuncType(cr.FactoryMethod)
+	paramTypes := make([]types.DataType, 0, funcType.GetNumOfInput())
+	for i := 0; i < funcType.GetNumOfInput(); i++ {
+		paramTypes = append(paramTypes, funcType.GetInput(i))
+	}
+	return paramTypes
+}
+
+// get dependencies of factory method parameters or injected fields
+func (cr *ComponentRegistration) GetParameters() []*Parameter {
+	if factory, ok := cr.FactoryMethod.(structFactory); ok {
+		return getFieldParameters(factory.getInjectedFields())
+	}
+	return getParameters(cr.GetParameterTypes(), 0)
+}
+
+type RegistrationReader interface {
+	// the last implementation is returned for multi registrations
+	GetRegistration(componentType types.DataType) *ComponentRegistration
+	// implementations registered by RegisterMany in registration order
+	GetImplementations(componentType types.DataType) []*ComponentRegistration
+	// all registrations sorted by component type name, multi registrations are in registration order
+	GetAllRegistrations() []*ComponentRegistration
+	// types provided by contexts instead of registered as components
+	GetContextualTypes() []types.DataType
+	// implementations added to component hub by RegisterComponent in registration order
+	GetHubImplementations(componentType types.DataType) []*ComponentRegistration
+	// registration by RegisterKeyed, nil if not registered
+	GetKeyedRegistration(componentType types.DataType, key any) *ComponentRegistration
+	// registrations by RegisterKeyed in registration order
+	GetKeyedRegistrations(componentType types.DataType) []*ComponentRegistration
+	// decorators of the component type in registration order
+	GetDecorators(componentType types.DataType) []*DecoratorRegistration
+	// all decorators sorted by component type name, decorators of the same type are in registration order
+	GetAllDecorators() []*DecoratorRegistration
+}
+
+type DefaultRegistrationTable struct {
+	registrations   map[interface{}]*ComponentRegistration
+	implementations map[interface{}][]*ComponentRegistration
+	hubImpls        map[interface{}][]*ComponentRegistration
+	keyed           map[interface{}][]*ComponentRegistration
+	decorators      map[interface{}][]*DecoratorRegistration
+	contextualTypes map[interface{}]types.DataType
+}
+
+func NewRegistrationTable() *DefaultRegistrationTable {
+	return &DefaultRegistrationTable{
+		registrations:   make(map[interface{}]*ComponentRegistration),
+		implementations: make(map[interface{}][]*ComponentRegistration),
+		hubImpls:        make(map[interface{}][]*ComponentRegistration),
+		keyed:           make(map[interface{}][]*ComponentRegistration),
+		decorators:      make(map[interface{}][]*DecoratorRegistration),
+		contextualTypes: make(map[interface{}]types.DataType),
+	}
+}
+
+func (rt *DefaultRegistrationTable) AddRegistration(registration *ComponentRegistration) {
+	rt.registrations[registration.ComponentType.Key()] = registration
+}
+
+// add implementation of multi registrations, implementation index is assigned by registration order
+func (rt *DefaultRegistrationTable) AddImplementation(registration *ComponentRegistration) {
+	key := registration.ComponentType.Key()
+	registration.IsMulti = true
+	registration.ImplIndex = len(rt.implementations[key])
+	rt.implementations[key] = append(rt.implementations[key], registration)
+}
+func (rt *DefaultRegistrationTable) AddHubImplementation(registration *ComponentRegistration) {
+	key := registration.ComponentType.Key()
+	rt.hubImpls[key] = append(rt.hubImpls[key], registration)
+}
+// add registration by RegisterKeyed, registration of the same key is replaced
+func (rt *DefaultRegistrationTable) AddKeyedRegistration(registration *ComponentRegistration) {
+	key := registration.ComponentType.Key()
+	for index, exist := range rt.keyed[key] {
+		if exist.Key == registration.Key {
+			rt.keyed[key][index] = registration
+			return
+		}
+	}
+	rt.keyed[key] = append(rt.keyed[key], registration)
+}
+func (rt *DefaultRegistrationTable) AddDecorator(decorator *DecoratorRegistration) {
+	key := decorator.ComponentType.Key()
+	rt.decorators[key] = append(rt.decorators[key], decorator)
+}
+func (rt *DefaultRegistrationTable) AddContextualType(depType types.DataType) {
+	rt.contextualTypes[depType.Key()] = depType
+}
+
+func (rt *DefaultRegistrationTable) GetRegistration(componentType types.DataType) *ComponentRegistration {
+	if impls := rt.implementations[componentType.Key()]; len(impls) > 0 {
+		return impls[len(impls)-1]
+	}
+	return rt.registrations[componentType.Key()]
+}
+func (rt *DefaultRegistrationTable) GetImplementations(componentType types.DataType) []*ComponentRegistration {
+	impls := rt.implementations[componentType.Key()]
+	result := make([]*ComponentRegistration, 0, len(impls))
+	return append(result, impls...)
+}
+func (rt *DefaultRegistrationTable) GetAllRegistrations() []*ComponentRegistration {
+	result := make([]*ComponentRegistration, 0, len(rt.registrations))
+	for _, registration := range rt.registrations {
+		result = append(result, registration)
+	}
+	for _, impls := range rt.implementations {
+		result = append(result, impls...)
+	}
+	for _, keyed := range rt.keyed {
+		result = append(result, keyed...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].ComponentType.FullName() == result[j].ComponentType.FullName() {
+			return result[i].ImplIndex < result[j].ImplIndex
+		}
+		return result[i].ComponentType.FullName() < result[j].ComponentType.FullName()
+	})
+	return result
+}
+func (rt *DefaultRegistrationTable) GetHubImplementations(componentType types.DataType) []*ComponentRegistration {
+	impls := rt.hubImpls[componentType.Key()]
+	result := make([]*ComponentRegistration, 0, len(impls))
+	return append(result, impls...)
+}
+func (rt *DefaultRegistrationTable) GetKeyedRegistration(componentType types.DataType, key any) *ComponentRegistration {
+	for _, registration := range rt.keyed[componentType.Key()] {
+		if registration.Key == key {
+			return registration
+		}
+	}
+	return nil
+}
+func (rt *DefaultRegistrationTable) GetKeyedRegistrations(componentType types.DataType) []*ComponentRegistration {
+	keyed := rt.keyed[componentType.Key()]
+	result := make([]*ComponentRegistration, 0, len(keyed))
+	return append(result, keyed...)
+}
+func (rt *DefaultRegistrationTable) GetDecorators(componentType types.DataType) []*DecoratorRegistration {
+	decorators := rt.decorators[componentType.Key()]
+	result := make([]*DecoratorRegistration, 0, len(decorators))
+	return append(result, decorators...)
+}
+func (rt *DefaultRegistrationTable) GetAllDecorators() []*DecoratorRegistration {
+	result := make([]*DecoratorRegistration, 0, len(rt.decorators))
+	for _, decorators := range rt.decorators {
+		result = append(result, decorators...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ComponentType.FullName() < result[j].ComponentType.FullName()
+	})
+	return result
+}
+func (rt *DefaultRegistrationTable) GetContextualTypes() []types.DataType {
+	result := make([]types.DataType, 0, len(rt.contextualTypes))
+	for _, depType := range rt.contextualTypes {
+		result = append(result, depType)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FullName() < result[j].FullName()
+	})
+	return result
+}
+func (rt *DefaultRegistrationTable) IsContextualType(depType types.DataType) bool {
+	_, exist := rt.contextualTypes[depType.Key()]
+	return exist
+}
+
+// registrations of child component manager, registrations of the parent are read as leaves
+// since their dependencies are resolved and validated by the parent
+type inheritedRegistrations struct {
+	own    RegistrationReader
+	parent RegistrationReader
+}
+
+func newInheritedRegistrations(own RegistrationReader, parent RegistrationReader) *inheritedRegistrations {
+	return &inheritedRegistrations{
+		own:    own,
+		parent: parent,
+	}
+}
+
+func toLeafRegistration(registration *ComponentRegistration) *ComponentRegistration {
+	leaf := *registration
+	leaf.FactoryMethod = nil
+	return &leaf
+}
+
+func (ir *inheritedRegistrations) GetRegistration(componentType types.DataType) *ComponentRegistration {
+	if registration := ir.own.GetRegistration(componentType); registration != nil {
+		return registration
+	}
+	if registration := ir.parent.GetRegistration(componentType); registration != nil {
+		return toLeafRegistration(registration)
+	}
+	return nil
+}
+func (ir *inheritedRegistrations) GetImplementations(componentType types.DataType) []*ComponentRegistration {
+	if ir.own.GetRegistration(componentType) != nil {
+		return ir.own.GetImplementations(componentType)
+	}
+	impls := ir.parent.GetImplementations(componentType)
+	for index, registration := range impls {
+		impls[index] = toLeafRegistration(registration)
+	}
+	return impls
+}
+func (ir *inheritedRegistrations) GetHubImplementations(componentType types.DataType) []*ComponentRegistration {
+	if ir.own.GetRegistration(componentType) != nil {
+		return ir.own.GetHubImplementations(componentType)
+	}
+	impls := ir.parent.GetHubImplementations(componentType)
+	for index, registration := range impls {
+		impls[index] = toLeafRegistration(registration)
+	}
+	return impls
+}
+func (ir *inheritedRegistrations) GetKeyedRegistration(componentType types.DataType, key any) *ComponentRegistration {
+	if registration := ir.own.GetKeyedRegistration(componentType, key); registration != nil {
+		return registration
+	}
+	if registration := ir.parent.GetKeyedRegistration(componentType, key); registration != nil {
+		return toLeafRegistration(registration)
+	}
+	return nil
+}
+func (ir *inheritedRegistrations) GetKeyedRegistrations(componentType types.DataType) []*ComponentRegistration {
+	result := ir.own.GetKeyedRegistrations(componentType)
+	for _, registration := range ir.parent.GetKeyedRegistrations(componentType) {
+		if ir.own.GetKeyedRegistration(componentType, registration.Key) == nil {
+			result = append(result, toLeafRegistration(registration))
+		}
+	}
+	return result
+}
+func (ir *inheritedRegistrations) GetAllRegistrations() []*ComponentRegistration {
+	return ir.own.GetAllRegistrations()
+}
+func (ir *inheritedRegistrations) GetContextualTypes() []types.DataType {
+	result := ir.own.GetContextualTypes()
+	exist := make(map[interface{}]bool)
+	for _, depType := range result {
+		exist[depType.Key()] = true
+	}
+	for _, depType := range ir.parent.GetContextualTypes() {
+		if !exist[depType.Key()] {
+			result = append(result, depType)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FullName() < result[j].FullName()
+	})
+	return result
+}
+func (ir *inheritedRegistrations) GetDecorators(componentType types.DataType) []*DecoratorRegistration {
+	return ir.own.GetDecorators(componentType)
+}
+func (ir *inheritedRegistrations) GetAllDecorators() []*DecoratorRegistration {
+	return ir.own.GetAllDecorators()
+}
@@ -0,0 +1,86 @@
+package dep
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// frame of a component creation, chained to the frame of the dependent which triggers the creation.
+// the chain from a component context back to the root resolution replaces goroutine identity to detect re-entrance:
+// a record being created by a frame in the chain of the dependent is requested again by the same resolution.
+type ResolutionFrame struct {
+	parent *ResolutionFrame
+	// creator of the frame, e.g. recurrence manager of transient component, nil for records
+	owner any
+}
+
+func NewResolutionFrame(parent *ResolutionFrame) *ResolutionFrame {
+	return &ResolutionFrame{parent: parent}
+}
+func newOwnedResolutionFrame(parent *ResolutionFrame, owner any) *ResolutionFrame {
+	return &ResolutionFrame{parent: parent, owner: owner}
+}
+
+// id of the current goroutine parsed from the head of its stack trace "goroutine 18 [running]:",
+// it is slow so it is used only to detect re-entrance from contexts which carry no resolution frame
+func currentGoroutineId() uint64 {
+	var buf [64]byte
+	head := buf[:runtime.Stack(buf[:], false)]
+	head = bytes.TrimPrefix(head, []byte("goroutine "))
+	if end := bytes.IndexByte(head, ' '); end >= 0 {
+		head = head[:end]
+	}
+	id, _ := strconv.ParseUint(string(head), 10, 64)
+	return id
+}
+
+// check if the frame is the given frame or one of its ancestors
+func (rf *ResolutionFrame) Contains(frame *ResolutionFrame) bool {
+	if frame == nil {
+		return false
+	}
+	for current := rf; current != nil; current = current.parent {
+		if current == frame {
+			return true
+		}
+	}
+	return false
+}
+
+// count the frame and its ancestors created by the owner
+func (rf *ResolutionFrame) CountOwnedBy(owner any) uint32 {
+	count := uint32(0)
+	for current := rf; current != nil; current = current.parent {
+		if current.owner == owner {
+			count++
+		}
+	}
+	return count
+}
+
+// implemented by contexts of components created by lifecycle controller, and contexts derived from them
+type ResolutionTracker interface {
+	GetResolutionFrame() *ResolutionFrame
+	SetResolutionFrame(frame *ResolutionFrame)
+}
+
+// get resolution frame of the context, nil if the context is not created by lifecycle controller nor derived from its context, e.g. host context
+func GetResolutionFrame(context ContextEx) *ResolutionFrame {
+	if tracker, ok := context.(ResolutionTracker); ok {
+		return tracker.GetResolutionFrame()
+	}
+	return nil
+}
+
+func TrackResolution(compCtxt ContextEx, frame *ResolutionFrame) {
+	if tracker, ok := compCtxt.(ResolutionTracker); ok {
+		tracker.SetResolutionFrame(frame)
+	}
+}
+
+// carry the resolution frame of the context to the context derived from it, e.g. scope opened by a component being created,
+// so that resolutions from the derived context are chained to the resolution of the component
+func InheritResolution(derived ContextEx, context ContextEx) {
+	TrackResolution(derived, GetResolutionFrame(context))
+}
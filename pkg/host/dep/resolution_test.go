@@ -0,0 +1,247 @@
+package dep
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+func newDependentStruct(another AnotherInterface) *ActualStruct {
+	return NewActualStruct()
+}
+
+func TestResolutionFrame_contains(t *testing.T) {
+	root := NewResolutionFrame(nil)
+	child := NewResolutionFrame(root)
+	other := NewResolutionFrame(nil)
+
+	if !child.Contains(child) || !child.Contains(root) {
+		t.Errorf("frame should contain itself and its ancestors")
+	}
+	if root.Contains(child) || child.Contains(other) || child.Contains(nil) {
+		t.Errorf("frame should not contain descendants, unrelated frames or nil")
+	}
+	var none *ResolutionFrame
+	if none.Contains(root) {
+		t.Errorf("nil frame should contain nothing")
+	}
+}
+
+func TestSingleton_concurrent_creation(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	options.EnableSingletonConcurrency = true
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	var created int32
+	RegisterSingleton[AnotherInterface](cm, func(context Context) *AnotherStruct {
+		atomic.AddInt32(&created, 1)
+		time.Sleep(10 * time.Millisecond)
+		return NewAnotherStruct(context)
+	})
+	RegisterSingleton[FirstInterface](cm, newDependentStruct)
+
+	size := 10
+	instances := make([]any, size*2)
+	var wg sync.WaitGroup
+	wg.Add(size * 2)
+	for i := 0; i < size; i++ {
+		go func(i int) {
+			defer wg.Done()
+			instances[i] = GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			_ = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+			instances[size+i] = GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	if created != 1 {
+		t.Errorf("singleton should be created once by concurrent resolutions, actual: %d", created)
+	}
+	for _, instance := range instances {
+		if instance != instances[0] {
+			t.Fatalf("concurrent resolutions should return the same singleton")
+		}
+	}
+}
+
+func TestSingleton_concurrent_creation_from_component_context(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	options.EnableSingletonConcurrency = true
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	var created int32
+	RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+	RegisterSingleton[SecondInterface](cm, func() *ActualStruct {
+		atomic.AddInt32(&created, 1)
+		time.Sleep(10 * time.Millisecond)
+		return NewActualStruct()
+	})
+
+	// frame of the created component context is not creating anything, resolutions from it should wait for each other
+	compCtxt := GetComponentFrom[AnotherInterface](cm, ctxt, nil).(*AnotherStruct).GetContext()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = compCtxt.GetComponent(types.Get[SecondInterface]())
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 {
+		t.Errorf("singleton should be created once by concurrent resolutions from the same context, actual: %d", created)
+	}
+}
+
+func TestSingleton_reentrance_by_context(t *testing.T) {
+	defer test.AssertPanicContent(t, "cyclic dependency detected on singleton component", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	options.EnableSingletonConcurrency = true
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	// resolving itself through its own context during creation is re-entrance instead of dead lock
+	RegisterSingleton[AnotherInterface](cm, func(context Context) *AnotherStruct {
+		_ = context.GetComponent(types.Get[AnotherInterface]())
+		return NewAnotherStruct(context)
+	})
+
+	_ = GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	t.Errorf("re-entrance should be detected as cyclic dependency")
+}
+
+// resolve itself in a scope opened by the factory, with the context of the component or of the scope action
+func newSelfResolvingStruct(scopeFactory ScopeFactory, context Context) *ActualStruct {
+	scope := scopeFactory.CreateScope(context, nil)
+	defer scope.Dispose()
+	scope.Execute("Resolve", func(first FirstInterface) {})
+	return NewActualStruct()
+}
+
+func resolveWithTimeout(t *testing.T, resolve func()) any {
+	failure := make(chan any, 1)
+	go func() {
+		defer func() { failure <- recover() }()
+		resolve()
+	}()
+	select {
+	case r := <-failure:
+		return r
+	case <-time.After(2 * time.Second):
+		t.Fatalf("resolution is dead locked")
+		return nil
+	}
+}
+
+func TestSingleton_reentrance_by_scope(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	options.EnableSingletonConcurrency = true
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	// scope opened by the component being created carries its resolution frame
+	RegisterSingleton[FirstInterface](cm, newSelfResolvingStruct)
+
+	r := resolveWithTimeout(t, func() { _ = GetComponentFrom[FirstInterface](cm, ctxt, nil) })
+	if !strings.Contains(fmt.Sprint(r), "cyclic dependency detected on singleton component dep.FirstInterface") {
+		t.Errorf("re-entrance through scope should be detected as cyclic dependency, actual: %v", r)
+	}
+}
+
+func TestSingleton_reentrance_by_captured_context(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	options.EnableSingletonConcurrency = true
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	// captured context carries no resolution frame, re-entrance is detected by the creating goroutine
+	RegisterSingleton[FirstInterface](cm, func() *ActualStruct {
+		_ = GetComponentFrom[FirstInterface](cm, ctxt, nil)
+		return NewActualStruct()
+	})
+
+	r := resolveWithTimeout(t, func() { _ = GetComponentFrom[FirstInterface](cm, ctxt, nil) })
+	var cyclicErr *CyclicDependencyError
+	if err, ok := r.(error); !ok || !errors.As(err, &cyclicErr) {
+		t.Errorf("re-entrance through captured context should be detected as cyclic dependency, actual: %v", r)
+	}
+}
+
+func TestTransient_recurrence_by_scope(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	options.TrackTransientRecurrence = true
+	options.MaxAllowedRecurrence = 4
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	RegisterTransient[FirstInterface](cm, newSelfResolvingStruct)
+
+	r := resolveWithTimeout(t, func() { _ = GetComponentFrom[FirstInterface](cm, ctxt, nil) })
+	if !strings.Contains(fmt.Sprint(r), "recursive dependency overflow on transient component dep.FirstInterface") {
+		t.Errorf("recurrence through scope should be counted, actual: %v", r)
+	}
+}
+
+func prepareBenchmark(b *testing.B, configure func(cm ComponentManager)) (ComponentManager, ContextEx) {
+	options := NewComponentProviderOptions(InterfaceType)
+	options.EnableSingletonConcurrency = true
+	cm, ctxt := prepareComponentManagerWithOptionsEx(options, false, nil)
+	configure(cm)
+	b.ReportAllocs()
+	b.ResetTimer()
+	return cm, ctxt
+}
+
+// existing singleton is returned without locking
+func BenchmarkSingleton_resolve_parallel(b *testing.B) {
+	cm, ctxt := prepareBenchmark(b, func(cm ComponentManager) {
+		RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+	})
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+		}
+	})
+}
+
+// scoped components with a scoped dependency are created in a new scope by each resolution
+func BenchmarkScoped_create_parallel(b *testing.B) {
+	cm, ctxt := prepareBenchmark(b, func(cm ComponentManager) {
+		RegisterScoped[AnotherInterface, TestScope](cm, NewAnotherStruct)
+		RegisterScoped[FirstInterface, TestScope](cm, newDependentStruct)
+	})
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = GetComponentFrom[FirstInterface](cm, createNewContext(ctxt, ScopeTest), nil)
+		}
+	})
+}
+
+// goroutines contend for the creation of the same scoped components
+func BenchmarkScoped_create_contended(b *testing.B) {
+	const concurrency = 8
+	cm, ctxt := prepareBenchmark(b, func(cm ComponentManager) {
+		RegisterScoped[AnotherInterface, TestScope](cm, NewAnotherStruct)
+		RegisterScoped[FirstInterface, TestScope](cm, newDependentStruct)
+	})
+	for i := 0; i < b.N; i++ {
+		scopeCtxt := createNewContext(ctxt, ScopeTest)
+		var wg sync.WaitGroup
+		wg.Add(concurrency)
+		for j := 0; j < concurrency; j++ {
+			go func() {
+				defer wg.Done()
+				_ = GetComponentFrom[FirstInterface](cm, scopeCtxt, nil)
+			}()
+		}
+		wg.Wait()
+	}
+}
@@ -3,6 +3,7 @@ package dep
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
 )
@@ -14,6 +15,8 @@ type DefaultScopeData struct {
 	concurrency bool
 	mutex       sync.Mutex
 	records     map[interface{}]ScopedCompRecord
+	disposables []any
+	observers   []InstanceObserver
 
 	properties Properties
 }
@@ -22,6 +25,8 @@ func NewScopeData(concurrency bool, props Properties) *DefaultScopeData {
 	return &DefaultScopeData{
 		concurrency: concurrency,
 		records:     make(map[interface{}]ScopedCompRecord),
+		disposables: make([]any, 0),
+		observers:   make([]InstanceObserver, 0),
 		properties:  props,
 	}
 }
@@ -70,23 +75,69 @@ func (sd *DefaultScopeData) Clear() {
 
 	sd.records = make(map[interface{}]ScopedCompRecord)
 }
-func (sd *DefaultScopeData) getRecord(compType types.DataType) ScopedCompRecord {
+func (sd *DefaultScopeData) TrackDisposable(instance any) {
+	if !IsDisposable(instance) {
+		return
+	}
+
+	defer sd.mutex.Unlock()
+	sd.mutex.Lock()
+
+	sd.disposables = append(sd.disposables, instance)
+}
+func (sd *DefaultScopeData) TrackInstance(instance any) {
+	sd.TrackDisposable(instance)
+
+	sd.mutex.Lock()
+	observers := sd.observers
+	sd.mutex.Unlock()
+
+	for _, observer := range observers {
+		observer(instance)
+	}
+}
+func (sd *DefaultScopeData) ObserveInstances(observer InstanceObserver) {
+	defer sd.mutex.Unlock()
+	sd.mutex.Lock()
+
+	// copy on write, observers are notified without lock
+	observers := make([]InstanceObserver, 0, len(sd.observers)+1)
+	sd.observers = append(append(observers, sd.observers...), observer)
+}
+func (sd *DefaultScopeData) takeDisposables() []any {
 	defer sd.mutex.Unlock()
 	sd.mutex.Lock()
 
-	record, exist := sd.records[compType.Key()]
+	disposables := sd.disposables
+	sd.disposables = make([]any, 0)
+	return disposables
+}
+func (sd *DefaultScopeData) Dispose() error {
+	// dispose in reverse creation order, dependencies are created before their dependents
+	err := DisposeAll(sd.takeDisposables())
+	sd.Clear()
+	return err
+}
+func (sd *DefaultScopeData) getRecord(key interface{}, name string) ScopedCompRecord {
+	defer sd.mutex.Unlock()
+	sd.mutex.Lock()
+
+	record, exist := sd.records[key]
 	if exist {
 		return record
 	}
 
 	// not exist, create new record and store into records
-	record = NewScopedCompRecord(compType.Name(), sd.concurrency)
-	sd.records[compType.Key()] = record
+	record = NewScopedCompRecord(name, sd.concurrency)
+	sd.records[key] = record
 
 	return record
 }
 func (sd *DefaultScopeData) GetCompRecord(compType types.DataType) ScopedCompRecord {
-	return sd.getRecord(compType)
+	return sd.getRecord(compType.Key(), compType.Name())
+}
+func (sd *DefaultScopeData) GetCompRecordByKey(key interface{}, name string) ScopedCompRecord {
+	return sd.getRecord(key, name)
 }
 
 func (sd *DefaultScopeData) CopyProperties() Properties {
@@ -97,8 +148,6 @@ func (sd *DefaultScopeData) CopyProperties() Properties {
 func NewScopedCompRecord(name string, concurrency bool) ScopedCompRecord {
 	if concurrency {
 		return &ScopedCompRecord_Lock{
-			lock:     NewThreadSafeLock(name),
-			creating: false,
 			instance: nil,
 			compCtxt: nil,
 		}
@@ -112,26 +161,45 @@ func NewScopedCompRecord(name string, concurrency bool) ScopedCompRecord {
 }
 
 type ScopedCompRecord_Lock struct {
-	lock     SingletonLock
-	creating bool
-	instance interface{}
+	mutex sync.Mutex
+	// set once the component is created, instance and compCtxt are read without lock afterwards
+	created uint32
+	// frame of the resolution creating the component, read without lock to detect re-entrance
+	creator atomic.Value
+	// goroutine creating the component, detects re-entrance from contexts not chained to the creator, e.g. captured host context
+	goroutine uint64
+	instance  interface{}
 	compCtxt ContextEx
 }
 
-func (scr *ScopedCompRecord_Lock) Execute(createComponent FactoryAction) (interface{}, ContextEx, bool, bool) {
-	if scr.instance == nil {
-		defer scr.lock.Unlock()
-		scr.lock.Lock()
-		if scr.instance == nil {
-			if !scr.creating {
-				defer func() { scr.creating = false }()
-				scr.creating = true
-				scr.instance, scr.compCtxt = createComponent()
-				return scr.instance, scr.compCtxt, false, false
-			} else {
+func (scr *ScopedCompRecord_Lock) getCreator() *ResolutionFrame {
+	creator, _ := scr.creator.Load().(*ResolutionFrame)
+	return creator
+}
+
+func (scr *ScopedCompRecord_Lock) Execute(dependent ContextEx, createComponent ResolutionAction) (interface{}, ContextEx, bool, bool) {
+	if atomic.LoadUint32(&scr.created) == 0 {
+		frame := GetResolutionFrame(dependent)
+		// the record is being created by the resolution of dependent, waiting for the lock would dead lock.
+		// goroutine is checked only while the record is being created, so it is not parsed by resolutions of created ones
+		if creator := scr.getCreator(); creator != nil {
+			if frame.Contains(creator) || atomic.LoadUint64(&scr.goroutine) == currentGoroutineId() {
 				return nil, nil, false, true
 			}
 		}
+		defer scr.mutex.Unlock()
+		scr.mutex.Lock()
+		if scr.created == 0 {
+			creator := NewResolutionFrame(frame)
+			// goroutine is set before the creator and reset after it, so it is valid once the creator is seen
+			atomic.StoreUint64(&scr.goroutine, currentGoroutineId())
+			defer atomic.StoreUint64(&scr.goroutine, 0)
+			defer scr.creator.Store((*ResolutionFrame)(nil))
+			scr.creator.Store(creator)
+			scr.instance, scr.compCtxt = createComponent(creator)
+			atomic.StoreUint32(&scr.created, 1)
+			return scr.instance, scr.compCtxt, false, false
+		}
 	}
 	return scr.instance, scr.compCtxt, true, false
 }
@@ -142,12 +210,12 @@ type ScopedCompRecord_NoLock struct {
 	compCtxt ContextEx
 }
 
-func (scr *ScopedCompRecord_NoLock) Execute(createComponent FactoryAction) (interface{}, ContextEx, bool, bool) {
+func (scr *ScopedCompRecord_NoLock) Execute(dependent ContextEx, createComponent ResolutionAction) (interface{}, ContextEx, bool, bool) {
 	if scr.instance == nil {
 		if !scr.creating {
 			defer func() { scr.creating = false }()
 			scr.creating = true
-			scr.instance, scr.compCtxt = createComponent()
+			scr.instance, scr.compCtxt = createComponent(NewResolutionFrame(GetResolutionFrame(dependent)))
 			return scr.instance, scr.compCtxt, false, false
 		} else {
 			return nil, nil, false, true
@@ -32,7 +32,7 @@ type ScopeEx interface {
 	Scope
 	Scopable
 
-	Dispose()
+	Dispose() error
 	Initialize(scopeType types.DataType, scopeInst Scopable)
 
 	BuildActionMethod(string, FreeStyleScopeActionMethod) ActionMethod
@@ -164,8 +164,12 @@ func (ds *DefaultScope) Execute(actionName string, actionFunc FreeStyleScopeActi
 	actionMethod()
 }
 
-func (ds *DefaultScope) Dispose() {
-	ds.scopeCtxt.GetScope().Clear()
+func (ds *DefaultScope) Dispose() error {
+	err := ds.scopeCtxt.GetScope().Dispose()
+	if err != nil {
+		ds.context.GetLogger().Errorw("failed disposing components of scope", "scope", ds.GetScopeId(), "error", err)
+	}
+	return err
 }
 
 // API to mimic using sytax using in dotnet
@@ -0,0 +1,124 @@
+package dep
+
+import (
+	"fmt"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+// tags of struct fields injected by DepInjector::BuildStruct, e.g.
+//
+//	type Foo struct {
+//		Logger logger.Logger `dep:""`
+//		Cache  Cache         `dep:"optional"`
+//		Config *FooConfig    `config:""`
+//	}
+const (
+	// `dep:""` for required dependency, `dep:"optional"` for dependency which is absent if not registered
+	Tag_Dependency = "dep"
+	// `config:""` for configuration, field type should be pointer of struct
+	Tag_Configuration = "config"
+
+	TagOption_Optional = "optional"
+)
+
+// exported struct field tagged for injection
+type InjectedField struct {
+	Name     string
+	Index    int
+	Type     types.DataType
+	Optional bool
+}
+
+// get fields tagged for injection in declaration order, panic if any tag is invalid
+func GetInjectedFields(structType types.DataType) []*InjectedField {
+	fields := make([]*InjectedField, 0)
+	for _, field := range types.GetStructFields(structType) {
+		depOption, isDep := field.Tag.Lookup(Tag_Dependency)
+		configOption, isConfig := field.Tag.Lookup(Tag_Configuration)
+		if !isDep && !isConfig {
+			continue
+		}
+		if !field.IsExported {
+			panic(fmt.Errorf("injected field should be exported: %s.%s", structType.FullName(), field.Name))
+		}
+		if isDep && isConfig {
+			panic(fmt.Errorf("injected field should not be tagged as both dependency and configuration: %s.%s", structType.FullName(), field.Name))
+		}
+
+		injected := &InjectedField{
+			Name:  field.Name,
+			Index: field.Index,
+			Type:  field.Type,
+		}
+		if isConfig {
+			if configOption != "" || !field.Type.IsPtr() || !field.Type.ElementType().IsStruct() {
+				panic(fmt.Errorf("configuration field should be pointer of struct without option: %s.%s", structType.FullName(), field.Name))
+			}
+		} else {
+			switch depOption {
+			case "":
+			case TagOption_Optional:
+				injected.Optional = true
+			default:
+				panic(fmt.Errorf("unknown option %q of dependency field: %s.%s", depOption, structType.FullName(), field.Name))
+			}
+		}
+		fields = append(fields, injected)
+	}
+	return fields
+}
+
+func getFieldParameters(fields []*InjectedField) []*Parameter {
+	params := make([]*Parameter, 0, len(fields))
+	for _, field := range fields {
+		paramKind, paramType := GetParamKind(field.Type)
+		if field.Optional {
+			paramKind = ParamKind_Optional
+		}
+		params = append(params, &Parameter{Index: field.Index, Field: field.Name, Kind: paramKind, Type: paramType, Key: getParamKey(field.Type)})
+	}
+	return params
+}
+
+// implemented by factory method of struct registered by RegisterStruct, whose injected fields are its dependencies
+type structFactory interface {
+	getInjectedFields() []*InjectedField
+}
+
+type structFactoryMethod[S any] func(provider ComponentProviderEx, injector DepInjector) *S
+
+func (sf structFactoryMethod[S]) getInjectedFields() []*InjectedField {
+	return GetInjectedFields(types.Get[S]())
+}
+
+func newStructFactoryMethod[S any]() structFactoryMethod[S] {
+	structType := types.Get[S]()
+	// validate tags on registration instead of first resolution
+	_ = GetInjectedFields(structType)
+	return func(provider ComponentProviderEx, injector DepInjector) *S {
+		injector.Initialize(provider, nil)
+		return injector.BuildStruct(structType).(*S)
+	}
+}
+
+// register pointer of struct S as component type T, tagged fields of S are injected by the same rules as factory method parameters,
+// scoped struct is registered in any scope
+func RegisterStruct[T any, S any](collection ComponentCollection, lifetime Lifetime) {
+	createInstance := newStructFactoryMethod[S]()
+	switch lifetime {
+	case Lifetime_Singleton:
+		collection.RegisterSingletonForType(createInstance, types.Get[T]())
+	case Lifetime_Scoped:
+		collection.RegisterScopedForType(createInstance, types.Get[T]())
+	case Lifetime_Transient:
+		collection.RegisterTransientForType(createInstance, types.Get[T]())
+	default:
+		panic(fmt.Errorf("unexpected lifetime %v to register struct %s: %s", lifetime, types.Get[S]().FullName(), types.Get[T]().FullName()))
+	}
+}
+
+// register pointer of struct S as component type T scoped in scope of type Scope
+func RegisterScopedStruct[T any, S any, Scope any](collection ComponentCollection) {
+	collection.RegisterScopedForTypeEx(newStructFactoryMethod[S](), types.Get[T](), types.Get[Scope]())
+}
@@ -0,0 +1,138 @@
+package dep
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+type InjectedStruct struct {
+	*ActualStruct
+
+	Context   Context                `dep:""`
+	Logger    logger.Logger          `dep:""`
+	Another   AnotherInterface       `dep:""`
+	Second    SecondInterface        `dep:"optional"`
+	Config    *MyConfig              `config:""`
+	Downloads []Downloader           `dep:""`
+	Lazy      Lazy[AnotherInterface] `dep:""`
+	Untagged  AnotherInterface
+}
+
+type InvalidTagStruct struct {
+	*ActualStruct
+
+	another AnotherInterface `dep:""`
+}
+
+func TestRegisterStruct(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithOptions(options)
+
+	config := &MyConfig{value: 123}
+	AddConfig[MyConfig](cm, config)
+	RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+	RegisterStruct[FirstInterface, InjectedStruct](cm, Lifetime_Singleton)
+
+	inst := GetComponentFrom[FirstInterface](cm, ctxt, nil).(*InjectedStruct)
+	if inst != GetComponentFrom[FirstInterface](cm, ctxt, nil) {
+		t.Errorf("struct registered as singleton should be created once")
+	}
+	if inst.Context == nil || inst.Context.Name() != "dep.FirstInterface" || inst.Logger == nil {
+		t.Errorf("contextual dependencies should be injected into fields")
+	}
+	another := GetComponentFrom[AnotherInterface](cm, ctxt, nil)
+	if inst.Another != another || inst.Lazy.Get() != another {
+		t.Errorf("dependency should be injected into fields")
+	}
+	if inst.Config != config {
+		t.Errorf("configuration should be injected into field")
+	}
+	if inst.Second != nil || inst.Downloads == nil || len(inst.Downloads) != 0 {
+		t.Errorf("optional field should be zero and collection field empty if not registered")
+	}
+	if inst.Untagged != nil {
+		t.Errorf("untagged field should not be injected")
+	}
+}
+
+func TestRegisterStruct_optional_present(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, ctxt := prepareComponentManagerWithScope(options, ScopeTest)
+
+	AddConfig[MyConfig](cm, &MyConfig{})
+	RegisterSingleton[AnotherInterface](cm, NewAnotherStruct)
+	RegisterTransient[SecondInterface](cm, NewActualStruct)
+	RegisterScopedStruct[FirstInterface, InjectedStruct, TestScope](cm)
+
+	inst := GetComponentFrom[FirstInterface](cm, ctxt, nil).(*InjectedStruct)
+	if inst.Second == nil {
+		t.Errorf("optional field should be injected if registered")
+	}
+	if inst != GetComponentFrom[FirstInterface](cm, ctxt, nil) || inst == GetComponentFrom[FirstInterface](cm, createNewContext(ctxt, ScopeTest), nil) {
+		t.Errorf("scoped struct should be reused in the same scope only")
+	}
+}
+
+func TestRegisterStruct_validate(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterStruct[FirstInterface, InjectedStruct](cm, Lifetime_Transient)
+
+	err := cm.Validate()
+	var validationErr *DependencyValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Errors) != 3 {
+		t.Fatalf("missing dependencies and configuration of required fields should be reported, actual: %v", err)
+	}
+	for _, expected := range []string{
+		"component dep.FirstInterface: field Another, dependency dep.AnotherInterface is not registered",
+		"component dep.FirstInterface: field Config, configuration dep.MyConfig is not registered",
+		"component dep.FirstInterface: field Lazy, dependency dep.AnotherInterface is not registered",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("validation error should contain: %s, actual: %v", expected, err)
+		}
+	}
+
+	if edge := findEdge(cm.ExportGraph(), "dep.FirstInterface", "dep.SecondInterface"); edge == nil || edge.Kind != "Optional" || edge.Field != "Second" {
+		t.Errorf("optional field should be exported as optional edge, actual: %+v", edge)
+	}
+}
+
+func TestRegisterStruct_unexported_field(t *testing.T) {
+	defer test.AssertPanicContent(t, "injected field should be exported: dep.InvalidTagStruct.another", "panic content is not expected")
+
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterStruct[FirstInterface, InvalidTagStruct](cm, Lifetime_Singleton)
+}
+
+func TestGetInjectedFields_invalid_tags(t *testing.T) {
+	type unknownOption struct {
+		Another AnotherInterface `dep:"lazy"`
+	}
+	type configNotPointer struct {
+		Config MyConfig `config:""`
+	}
+	type bothTags struct {
+		Config *MyConfig `dep:"" config:""`
+	}
+
+	cases := map[string]func(){
+		`unknown option "lazy" of dependency field`:                 func() { GetInjectedFields(types.Get[unknownOption]()) },
+		"configuration field should be pointer of struct":           func() { GetInjectedFields(types.Get[configNotPointer]()) },
+		"should not be tagged as both dependency and configuration": func() { GetInjectedFields(types.Get[bothTags]()) },
+	}
+	for expected, getFields := range cases {
+		func() {
+			defer test.AssertPanicContent(t, expected, "panic content is not expected")
+			getFields()
+		}()
+	}
+}
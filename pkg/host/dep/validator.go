@@ -0,0 +1,217 @@
+package dep
+
+import (
+	"fmt"
+	"strings"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+// error reported by dependency validation, listing every problem found
+type DependencyValidationError struct {
+	AggregateError
+}
+
+func (dve *DependencyValidationError) Error() string {
+	return fmt.Sprintf("dependency validation failed, %s", dve.AggregateError.Error())
+}
+
+type DependencyValidator interface {
+	Validate() error
+}
+
+// walks parameter types of registered free-style factory methods statically,
+// components registered by FactoryMethod directly are treated as leaves since their dependencies are unknown.
+type DefaultDependencyValidator struct {
+	registrations RegistrationReader
+
+	contextualTypes map[interface{}]bool
+	errors          []error
+	// reported cycles, keyed by sorted type names of the cycle
+	cycles map[string]bool
+}
+
+func NewDependencyValidator(registrations RegistrationReader) *DefaultDependencyValidator {
+	dv := &DefaultDependencyValidator{
+		registrations:   registrations,
+		contextualTypes: make(map[interface{}]bool),
+		errors:          make([]error, 0),
+		cycles:          make(map[string]bool),
+	}
+	for _, depType := range registrations.GetContextualTypes() {
+		dv.contextualTypes[depType.Key()] = true
+	}
+	return dv
+}
+
+func (dv *DefaultDependencyValidator) Validate() error {
+	registrations := dv.registrations.GetAllRegistrations()
+	for _, registration := range registrations {
+		name := registration.ComponentType.FullName()
+		if registration.Key != nil {
+			name = registration.Name()
+		}
+		dv.validateParameters(fmt.Sprintf("component %s", name), registration.GetParameters())
+	}
+	// the first parameter of decorator is the inner instance
+	for _, decorator := range dv.registrations.GetAllDecorators() {
+		owner := fmt.Sprintf("decorator %s of component %s", decorator, decorator.ComponentType.FullName())
+		dv.validateParameters(owner, decorator.GetParameters())
+	}
+
+	// keyed by registration since multiple implementations share the same component type
+	visiting := make(map[*ComponentRegistration]bool)
+	visited := make(map[*ComponentRegistration]bool)
+	for _, registration := range registrations {
+		dv.detectCycles(registration, make([]types.DataType, 0), visiting, visited)
+	}
+
+	for _, registration := range registrations {
+		if registration.Lifetime == Lifetime_Singleton {
+			dv.detectCaptives(registration, []LifetimeFrame{{ComponentType: registration.ComponentType, Lifetime: registration.Lifetime}})
+		}
+	}
+
+	if len(dv.errors) == 0 {
+		return nil
+	}
+	return &DependencyValidationError{AggregateError: AggregateError{Errors: dv.errors}}
+}
+
+func (dv *DefaultDependencyValidator) addError(err error) {
+	dv.errors = append(dv.errors, err)
+}
+
+func (dv *DefaultDependencyValidator) isContextual(depType types.DataType) bool {
+	return dv.contextualTypes[depType.Key()]
+}
+
+func (dv *DefaultDependencyValidator) validateParameters(owner string, params []*Parameter) {
+	for _, param := range params {
+		paramType := param.Type
+		// optional dependency is allowed to be absent, and collection is empty if no implementation registered
+		if param.Kind == ParamKind_Optional || param.Kind == ParamKind_Many {
+			continue
+		}
+		// config type is registered as struct type but used as pointer of struct
+		if paramType.IsPtr() {
+			config := dv.registrations.GetRegistration(paramType.ElementType())
+			if config == nil || !config.IsConfiguration {
+				dv.addError(fmt.Errorf("%s: %v, configuration %s is not registered", owner, param, paramType.ElementType().FullName()))
+			}
+			continue
+		}
+		if param.Kind == ParamKind_Keyed {
+			if dv.registrations.GetKeyedRegistration(paramType, param.Key) == nil {
+				dv.addError(fmt.Errorf("%s: %v, keyed dependency %s is not registered", owner, param, getKeyedName(paramType, param.Key)))
+			}
+			continue
+		}
+		if dv.isContextual(paramType) {
+			continue
+		}
+		if dv.registrations.GetRegistration(paramType) == nil {
+			dv.addError(fmt.Errorf("%s: %v, dependency %s is not registered", owner, param, paramType.FullName()))
+		}
+	}
+}
+
+// component dependencies of the registration and its decorators which forms edges of the dependency graph,
+// lazy dependencies are resolved on demand which breaks cycles explicitly
+func (dv *DefaultDependencyValidator) getDependencies(registration *ComponentRegistration) []*ComponentRegistration {
+	params := registration.GetParameters()
+	for _, decorator := range dv.registrations.GetDecorators(registration.ComponentType) {
+		params = append(params, decorator.GetParameters()...)
+	}
+	deps := make([]*ComponentRegistration, 0)
+	for _, param := range params {
+		paramType := param.Type
+		if param.Kind == ParamKind_Lazy || paramType.IsPtr() || dv.isContextual(paramType) {
+			continue
+		}
+		if param.Kind == ParamKind_Many {
+			deps = append(deps, dv.registrations.GetImplementations(paramType)...)
+			continue
+		}
+		if param.Kind == ParamKind_Keyed {
+			if dependency := dv.registrations.GetKeyedRegistration(paramType, param.Key); dependency != nil {
+				deps = append(deps, dependency)
+			}
+			continue
+		}
+		dependency := dv.registrations.GetRegistration(paramType)
+		if dependency != nil {
+			deps = append(deps, dependency)
+		}
+	}
+	return deps
+}
+
+func (dv *DefaultDependencyValidator) detectCycles(registration *ComponentRegistration, path []types.DataType, visiting map[*ComponentRegistration]bool, visited map[*ComponentRegistration]bool) {
+	key := registration
+	path = append(path, registration.ComponentType)
+	if visiting[key] {
+		dv.reportCycle(path)
+		return
+	}
+	if visited[key] {
+		return
+	}
+
+	visiting[key] = true
+	for _, dependency := range dv.getDependencies(registration) {
+		dv.detectCycles(dependency, path, visiting, visited)
+	}
+	visiting[key] = false
+	visited[key] = true
+}
+
+func (dv *DefaultDependencyValidator) reportCycle(path []types.DataType) {
+	// path ends with the type which starts the cycle
+	last := path[len(path)-1]
+	start := 0
+	for index, depType := range path {
+		if depType.Key() == last.Key() {
+			start = index
+			break
+		}
+	}
+	cycle := path[start:]
+
+	names := make([]string, 0, len(cycle))
+	for _, depType := range cycle {
+		names = append(names, depType.FullName())
+	}
+	cycleKey := typesToString(cycle[:len(cycle)-1])
+	if dv.cycles[cycleKey] {
+		return
+	}
+	dv.cycles[cycleKey] = true
+	dv.addError(fmt.Errorf("cyclic dependency detected: %s", strings.Join(names, " -> ")))
+}
+
+// walk dependencies captured by the component, transient dependencies live as long as the captor
+func (dv *DefaultDependencyValidator) detectCaptives(registration *ComponentRegistration, chain []LifetimeFrame) {
+	captor := chain[0]
+	for _, dependency := range dv.getDependencies(registration) {
+		depChain := make([]LifetimeFrame, 0, len(chain)+1)
+		depChain = append(depChain, chain...)
+		depChain = append(depChain, LifetimeFrame{ComponentType: dependency.ComponentType, Lifetime: dependency.Lifetime})
+		if dependency.Lifetime == Lifetime_Transient {
+			if !containsLifetimeFrame(chain, dependency.ComponentType) {
+				dv.detectCaptives(dependency, depChain)
+			}
+		} else if captor.Lifetime.Outlives(dependency.Lifetime) {
+			dv.addError(fmt.Errorf("captive dependency detected: %s", LifetimeChainToString(depChain)))
+		}
+	}
+}
+
+func containsLifetimeFrame(chain []LifetimeFrame, componentType types.DataType) bool {
+	for _, frame := range chain {
+		if frame.ComponentType.Key() == componentType.Key() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package dep
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+func TestComponentManager_Validate(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	AddConfig[MyConfig](cm, &MyConfig{})
+	RegisterSingleton[FirstInterface](cm, NewActualStruct)
+	RegisterSingleton[SecondInterface](cm, func(context Context, logger logger.Logger, config *MyConfig) *ActualStruct {
+		return NewActualStructWithContext(context)
+	})
+	RegisterScoped[AnotherInterface, TestScope](cm, func(scope TestScope, first FirstInterface) *AnotherStruct {
+		return NewAnotherStruct(scope.Context())
+	})
+
+	err := cm.Validate()
+	if err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
+func TestComponentManager_Validate_not_registered(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterSingleton[FirstInterface](cm, func(second SecondInterface) *ActualStruct { return NewActualStruct() })
+	RegisterTransient[AnotherInterface](cm, func(context Context, config *MyConfig) *AnotherStruct { return NewAnotherStruct(context) })
+
+	err := cm.Validate()
+	var validationErr *DependencyValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("validation error expected, actual: %v", err)
+	}
+	if len(validationErr.Errors) != 2 {
+		t.Errorf("all problems should be reported, actual: %v", err)
+	}
+	for _, expected := range []string{
+		"component dep.FirstInterface: parameter 0, dependency dep.SecondInterface is not registered",
+		"component dep.AnotherInterface: parameter 1, configuration dep.MyConfig is not registered",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("validation error should contain: %s, actual: %v", expected, err)
+		}
+	}
+}
+
+func TestComponentManager_Validate_cyclic(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterSingleton[FirstInterface](cm, NewFirstDepOnSecond)
+	RegisterTransient[SecondInterface](cm, NewSecondDepOnFirst)
+	RegisterTransient[AnotherInterface](cm, func(first FirstInterface) *AnotherStruct { return NewAnotherStruct(nil) })
+
+	err := cm.Validate()
+	if err == nil {
+		t.Fatal("cyclic dependency should be detected")
+	}
+
+	var validationErr *DependencyValidationError
+	if errors.As(err, &validationErr) && len(validationErr.Errors) != 1 {
+		t.Errorf("cycle should be reported once, actual: %v", err)
+	}
+	expected := "cyclic dependency detected: dep.FirstInterface -> dep.SecondInterface -> dep.FirstInterface"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("validation error should contain: %s, actual: %v", expected, err)
+	}
+}
+
+func TestComponentManager_Registrations(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	AddConfig[MyConfig](cm, &MyConfig{})
+	RegisterScoped[AnotherInterface, TestScope](cm, NewAnotherStruct)
+
+	registrations := cm.GetRegistrations()
+	config := registrations.GetRegistration(types.Get[MyConfig]())
+	if config == nil || !config.IsConfiguration || config.String() != "dep.MyConfig[Configuration]" {
+		t.Errorf("unexpected configuration registration: %v", config)
+	}
+	scoped := registrations.GetRegistration(types.Get[AnotherInterface]())
+	if scoped == nil || scoped.Lifetime != Lifetime_Scoped || scoped.String() != "dep.AnotherInterface[Scoped@TestScope]" {
+		t.Errorf("unexpected scoped registration: %v", scoped)
+	}
+	if len(scoped.GetParameterTypes()) != 1 {
+		t.Errorf("unexpected parameter types of scoped registration: %v", scoped.GetParameterTypes())
+	}
+	builtin := registrations.GetRegistration(types.Get[LifecycleController]())
+	if builtin == nil || builtin.Lifetime != Lifetime_Unknown || len(builtin.GetParameterTypes()) != 0 {
+		t.Errorf("unexpected builtin registration: %v", builtin)
+	}
+}
+
+func TestComponentManager_Validate_captive(t *testing.T) {
+	options := NewComponentProviderOptions(InterfaceType)
+	cm, _ := prepareComponentManagerWithOptions(options)
+
+	RegisterSingleton[FirstInterface](cm, NewFirstDepOnSecond)
+	RegisterTransient[SecondInterface](cm, func(another AnotherInterface) *ActualStruct { return NewActualStruct() })
+	RegisterScoped[AnotherInterface, TestScope](cm, NewAnotherStruct)
+
+	err := cm.Validate()
+	expected := "captive dependency detected: dep.FirstInterface[Singleton] -> dep.SecondInterface[Transient] -> dep.AnotherInterface[Scoped]"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("validation error should contain: %s, actual: %v", expected, err)
+	}
+}
@@ -61,18 +61,47 @@ func (ar *BasicAsyncAppRunner) SendStopSignal() {
 
 func (ar *BasicAsyncAppRunner) WaitForStop() {
 
-	signal.Notify(ar.done, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(ar.done, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	// nil channel never receives if host does not raise stop events
+	var stopEvents <-chan *StopEvent
+	if source, ok := ar.host.(StopEventSource); ok {
+		stopEvents = source.StopEvents()
+	}
 
 	for {
-		sig := <-ar.done
-		ar.logger.Debugw("Receiving server stop signal!", "Signal", sig.String())
+		var event *StopEvent
+		select {
+		case sig := <-ar.done:
+			if sig == syscall.SIGHUP {
+				ar.ReloadConfiguration()
+				continue
+			}
+			ar.logger.Debugw("Receiving server stop signal!", "Signal", sig.String())
+			event = &StopEvent{Type: EVENT_TYPE_SIGNAL, Data: sig}
+		case event = <-stopEvents:
+			ar.logger.Debugw("Receiving stop event from host", "Type", event.Type.String())
+		}
 
-		accept := ar.host.OnStopEvent(&StopEvent{Type: EVENT_TYPE_SIGNAL, Data: sig})
+		accept := ar.host.OnStopEvent(event)
 		if accept {
-			ar.logger.Infow("Stop signal is accepted", "Signal", sig.String())
+			ar.logger.Infow("Stop event is accepted", "Type", event.Type.String(), "Data", event.Data)
 			break
 		}
 
-		ar.logger.Debugw("Stop signal is ignored", "Signal", sig.String())
+		ar.logger.Debugw("Stop event is ignored", "Type", event.Type.String(), "Data", event.Data)
+	}
+}
+
+// reload configurations on SIGHUP, the host keeps running with current configurations if reload failed
+func (ar *BasicAsyncAppRunner) ReloadConfiguration() {
+	reloader, err := dep.TryGetComponent[ConfigurationReloader](ar.context)
+	if err != nil {
+		ar.logger.Warnw("Configuration reload is not supported", "error", err)
+		return
 	}
+
+	// failure is logged by the reloader
+	ar.logger.Infow("Reloading configuration", "Signal", syscall.SIGHUP.String())
+	_ = reloader.Reload()
 }
@@ -1,6 +1,8 @@
 package hosting
 
 import (
+	"fmt"
+
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
 )
@@ -26,3 +28,17 @@ func RegisterFuncProcessor[T FunctionProcessor](collection dep.ComponentCollecti
 	}
 	collection.RegisterTransientForType(createProcessor, types.Get[T]())
 }
+
+// child container owns its registrations, configurations and singletons, and falls back to components of the host,
+// e.g. each tenant has its own component set. dispose the container when the tenant is removed, the host is not affected
+func CreateChildContainer(host Host, props dep.Properties, configureComponents ConfigureComponentsMethod) dep.ChildContainer {
+	hostCtxt, ok := host.GetContext().(*DefaultHostContext)
+	if !ok {
+		panic(fmt.Errorf("child container is not supported by host %s, its context %T is not built by host builder", host.GetName(), host.GetContext()))
+	}
+	return dep.NewChildContainer(hostCtxt.ComponentManager, hostCtxt.GetGlobalScope(), props, func(components dep.ComponentCollection) {
+		if configureComponents != nil {
+			configureComponents(NewBuilderContext(hostCtxt.builderContext), components)
+		}
+	})
+}
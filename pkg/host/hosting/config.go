@@ -1,19 +1,86 @@
 package hosting
 
+import (
+	"sync"
+	"sync/atomic"
+)
+
 type Configuration interface {
 	Get() interface{}
 }
 
+// called after configuration is swapped by reload
+type ConfigurationChangeListener func(current interface{}, previous interface{})
+
+// configuration which can be swapped by reload, subscribers are notified after each swap
+type ReloadableConfiguration interface {
+	Configuration
+
+	OnChange(listener ConfigurationChangeListener) (unsubscribe func())
+}
+
+type configurationValue struct {
+	config interface{}
+}
+
 type DefaultConfiguration struct {
+	// Deprecated: Value is the configuration loaded at start, it is not updated by reload. Use Get for the current configuration
 	Value interface{}
+
+	value atomic.Value
+
+	mutex     sync.Mutex
+	nextId    int
+	listeners map[int]ConfigurationChangeListener
 }
 
 func NewDefaultConfiguration(config interface{}) *DefaultConfiguration {
-	return &DefaultConfiguration{
-		Value: config,
+	c := &DefaultConfiguration{
+		Value:     config,
+		listeners: make(map[int]ConfigurationChangeListener),
 	}
+	c.value.Store(configurationValue{config: config})
+	return c
 }
 
+// Value is returned if the configuration is not created by NewDefaultConfiguration nor reloaded
 func (c *DefaultConfiguration) Get() interface{} {
+	if current, ok := c.value.Load().(configurationValue); ok {
+		return current.config
+	}
 	return c.Value
 }
+
+func (c *DefaultConfiguration) OnChange(listener ConfigurationChangeListener) func() {
+	defer c.mutex.Unlock()
+	c.mutex.Lock()
+
+	if c.listeners == nil {
+		c.listeners = make(map[int]ConfigurationChangeListener)
+	}
+	id := c.nextId
+	c.nextId++
+	c.listeners[id] = listener
+	return func() {
+		defer c.mutex.Unlock()
+		c.mutex.Lock()
+		delete(c.listeners, id)
+	}
+}
+
+// replace current configuration, listeners are returned to be notified after all configurations are swapped
+func (c *DefaultConfiguration) swap(config interface{}) (interface{}, []ConfigurationChangeListener) {
+	previous := c.Get()
+	c.value.Store(configurationValue{config: config})
+
+	defer c.mutex.Unlock()
+	c.mutex.Lock()
+
+	listeners := make([]ConfigurationChangeListener, 0, len(c.listeners))
+	for id := 0; id < c.nextId; id++ {
+		if listener, exist := c.listeners[id]; exist {
+			listeners = append(listeners, listener)
+		}
+	}
+	return previous, listeners
+}
@@ -0,0 +1,596 @@
+package hosting
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+	"gopkg.in/yaml.v2"
+)
+
+// source of configuration values, bound into the fields of configuration struct
+type ConfigurationSource interface {
+	GetName() string
+	Bind(config interface{}) error
+}
+
+// configuration field which can't be bound from the value of source
+type ConfigurationFieldError struct {
+	Source string
+	Field  string
+	Reason string
+}
+
+func (e *ConfigurationFieldError) Error() string {
+	return fmt.Sprintf("invalid configuration from %s, field: %s, %s", e.Source, e.Field, e.Reason)
+}
+
+// bind sources into configuration struct pointer in order, values of later sources override earlier ones
+func BindConfiguration(config interface{}, sources ...ConfigurationSource) error {
+	if len(sources) == 0 {
+		return nil
+	}
+	configValue := reflect.ValueOf(config)
+	if configValue.Kind() != reflect.Ptr || configValue.IsNil() || configValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("configuration to bind should be pointer of struct: %T", config)
+	}
+
+	errs := make([]error, 0)
+	for _, source := range sources {
+		err := source.Bind(config)
+		if aggregate, ok := err.(*dep.AggregateError); ok {
+			errs = append(errs, aggregate.Errors...)
+		} else if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return dep.NewAggregateError(errs...)
+}
+
+// methods for file sources
+type ConfigurationFileFormat string
+
+const (
+	ConfigurationFileFormat_Json ConfigurationFileFormat = "json"
+	ConfigurationFileFormat_Yaml ConfigurationFileFormat = "yaml"
+)
+
+type FileConfigurationSource struct {
+	path   string
+	format ConfigurationFileFormat
+}
+
+func NewJsonFileSource(path string) *FileConfigurationSource {
+	return &FileConfigurationSource{path: path, format: ConfigurationFileFormat_Json}
+}
+func NewYamlFileSource(path string) *FileConfigurationSource {
+	return &FileConfigurationSource{path: path, format: ConfigurationFileFormat_Yaml}
+}
+
+// format is decided by file extension, .json or .yaml/.yml
+func NewFileSource(path string) *FileConfigurationSource {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return NewYamlFileSource(path)
+	case ".json":
+		return NewJsonFileSource(path)
+	default:
+		return &FileConfigurationSource{path: path}
+	}
+}
+
+func (fs *FileConfigurationSource) GetName() string {
+	return fmt.Sprintf("%s file %s", fs.format, fs.path)
+}
+func (fs *FileConfigurationSource) Bind(config interface{}) error {
+	data, err := os.ReadFile(fs.path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file %s: %w", fs.path, err)
+	}
+
+	node, err := fs.decode(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse configuration file %s: %w", fs.path, err)
+	}
+
+	binder := &nodeBinder{source: fs.GetName(), tag: string(fs.format)}
+	binder.bind("", reflect.ValueOf(config).Elem(), node)
+	return dep.NewAggregateError(binder.errs...)
+}
+func (fs *FileConfigurationSource) decode(data []byte) (interface{}, error) {
+	var node interface{}
+	switch fs.format {
+	case ConfigurationFileFormat_Json:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&node); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case ConfigurationFileFormat_Yaml:
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, err
+		}
+		return normalizeYamlNode(node), nil
+	default:
+		return nil, fmt.Errorf("unsupported configuration file format, only json and yaml are supported")
+	}
+}
+
+// yaml decodes objects as map[interface{}]interface{}, convert them to map[string]interface{} as json does
+func normalizeYamlNode(node interface{}) interface{} {
+	switch value := node.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[fmt.Sprint(k)] = normalizeYamlNode(v)
+		}
+		return result
+	case []interface{}:
+		for i, v := range value {
+			value[i] = normalizeYamlNode(v)
+		}
+		return value
+	default:
+		return node
+	}
+}
+
+// binds decoded file content into fields, the keys are matched by tag of file format or field name, case insensitive
+type nodeBinder struct {
+	source string
+	tag    string
+	errs   []error
+}
+
+func (nb *nodeBinder) fail(field string, reason string, args ...any) {
+	nb.errs = append(nb.errs, &ConfigurationFieldError{Source: nb.source, Field: field, Reason: fmt.Sprintf(reason, args...)})
+}
+func (nb *nodeBinder) bind(field string, target reflect.Value, node interface{}) {
+	if node == nil {
+		return
+	}
+	if text, ok := node.(string); ok && isTextUnmarshaler(target) {
+		if err := setText(target, text); err != nil {
+			nb.fail(field, err.Error())
+		}
+		return
+	}
+
+	switch target.Kind() {
+	case reflect.Ptr:
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		nb.bind(field, target.Elem(), node)
+	case reflect.Struct:
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			nb.fail(field, "cannot use %s as %s", describeNode(node), target.Type())
+			return
+		}
+		nb.bindStruct(field, target, object)
+	case reflect.Slice:
+		array, ok := node.([]interface{})
+		if !ok {
+			nb.fail(field, "cannot use %s as %s", describeNode(node), target.Type())
+			return
+		}
+		slice := reflect.MakeSlice(target.Type(), len(array), len(array))
+		for i, item := range array {
+			nb.bind(fmt.Sprintf("%s[%d]", field, i), slice.Index(i), item)
+		}
+		target.Set(slice)
+	case reflect.Map:
+		object, ok := node.(map[string]interface{})
+		if !ok || target.Type().Key().Kind() != reflect.String {
+			nb.fail(field, "cannot use %s as %s", describeNode(node), target.Type())
+			return
+		}
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+		for key, item := range object {
+			elem := reflect.New(target.Type().Elem()).Elem()
+			nb.bind(fmt.Sprintf("%s[%s]", field, key), elem, item)
+			target.SetMapIndex(reflect.ValueOf(key).Convert(target.Type().Key()), elem)
+		}
+	case reflect.Interface:
+		if reflect.TypeOf(node).AssignableTo(target.Type()) {
+			target.Set(reflect.ValueOf(node))
+		} else {
+			nb.fail(field, "cannot use %s as %s", describeNode(node), target.Type())
+		}
+	default:
+		if err := setScalar(target, node); err != nil {
+			nb.fail(field, err.Error())
+		}
+	}
+}
+func (nb *nodeBinder) bindStruct(field string, target reflect.Value, object map[string]interface{}) {
+	targetType := target.Type()
+	for i := 0; i < targetType.NumField(); i++ {
+		structField := targetType.Field(i)
+		name, tagged, skip := getFieldName(structField, nb.tag)
+		if skip {
+			continue
+		}
+		// fields of embedded struct are promoted as json does
+		if structField.Anonymous && !tagged && indirectType(structField.Type).Kind() == reflect.Struct {
+			nb.bind(field, target.Field(i), object)
+			continue
+		}
+
+		node, exist := object[name]
+		if !exist {
+			for key, value := range object {
+				if strings.EqualFold(key, name) {
+					node, exist = value, true
+					break
+				}
+			}
+		}
+		if exist {
+			nb.bind(joinFieldPath(field, structField.Name), target.Field(i), node)
+		}
+	}
+}
+
+func describeNode(node interface{}) string {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return fmt.Sprintf("string %q", value)
+	case bool:
+		return fmt.Sprintf("bool %v", value)
+	default:
+		return fmt.Sprintf("number %v", value)
+	}
+}
+
+// set number, string or bool of file content into scalar field
+func setScalar(target reflect.Value, node interface{}) error {
+	mismatch := fmt.Errorf("cannot use %s as %s", describeNode(node), target.Type())
+	if target.Type() == durationType {
+		// duration is specified as string like "1m30s", or number of nanoseconds
+		if text, ok := node.(string); ok {
+			return setText(target, text)
+		}
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		text, ok := node.(string)
+		if !ok {
+			return mismatch
+		}
+		target.SetString(text)
+	case reflect.Bool:
+		value, ok := node.(bool)
+		if !ok {
+			return mismatch
+		}
+		target.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		text, ok := getNumberText(node)
+		if !ok {
+			return mismatch
+		}
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil || target.OverflowInt(value) {
+			return mismatch
+		}
+		target.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		text, ok := getNumberText(node)
+		if !ok {
+			return mismatch
+		}
+		value, err := strconv.ParseUint(text, 10, 64)
+		if err != nil || target.OverflowUint(value) {
+			return mismatch
+		}
+		target.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		text, ok := getNumberText(node)
+		if !ok {
+			return mismatch
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil || target.OverflowFloat(value) {
+			return mismatch
+		}
+		target.SetFloat(value)
+	default:
+		return fmt.Errorf("unsupported field type %s", target.Type())
+	}
+	return nil
+}
+
+// json numbers are decoded as json.Number, yaml numbers as int or float64
+func getNumberText(node interface{}) (string, bool) {
+	switch value := node.(type) {
+	case json.Number:
+		return value.String(), true
+	case int, int64, uint64:
+		return fmt.Sprint(value), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// methods for text sources, i.e. environment variables and command line flags
+var durationType = reflect.TypeOf(time.Duration(0))
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func isTextUnmarshaler(target reflect.Value) bool {
+	return target.CanAddr() && target.Addr().Type().Implements(textUnmarshalerType)
+}
+
+// parse text into field, items of slice are separated by comma
+func setText(target reflect.Value, text string) error {
+	if isTextUnmarshaler(target) {
+		return target.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+	if target.Type() == durationType {
+		value, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as %s", text, target.Type())
+		}
+		target.SetInt(int64(value))
+		return nil
+	}
+
+	mismatch := fmt.Errorf("cannot parse %q as %s", text, target.Type())
+	switch target.Kind() {
+	case reflect.Ptr:
+		value := reflect.New(target.Type().Elem())
+		if err := setText(value.Elem(), text); err != nil {
+			return err
+		}
+		target.Set(value)
+	case reflect.Slice:
+		items := make([]string, 0)
+		if text != "" {
+			items = strings.Split(text, ",")
+		}
+		slice := reflect.MakeSlice(target.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setText(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+	case reflect.String:
+		target.SetString(text)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return mismatch
+		}
+		target.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(text, 10, target.Type().Bits())
+		if err != nil {
+			return mismatch
+		}
+		target.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(text, 10, target.Type().Bits())
+		if err != nil {
+			return mismatch
+		}
+		target.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(text, target.Type().Bits())
+		if err != nil {
+			return mismatch
+		}
+		target.SetFloat(value)
+	default:
+		return fmt.Errorf("unsupported field type %s", target.Type())
+	}
+	return nil
+}
+
+// field which can be set from text, located by field indexes from configuration struct
+type configLeaf struct {
+	field string
+	names []string
+	index []int
+	kind  reflect.Kind
+}
+
+// get the field, nil pointers of struct on the path are allocated
+func (cl *configLeaf) resolve(root reflect.Value) reflect.Value {
+	value := root
+	for _, i := range cl.index {
+		for value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(i)
+	}
+	return value
+}
+
+// collect fields settable from text, field names are taken from the tag if specified
+func collectConfigLeaves(configType reflect.Type, tag string) []*configLeaf {
+	leaves := make([]*configLeaf, 0)
+	var collect func(structType reflect.Type, parent *configLeaf, visiting map[reflect.Type]bool)
+	collect = func(structType reflect.Type, parent *configLeaf, visiting map[reflect.Type]bool) {
+		if visiting[structType] {
+			return
+		}
+		visiting[structType] = true
+		defer delete(visiting, structType)
+
+		for i := 0; i < structType.NumField(); i++ {
+			structField := structType.Field(i)
+			name, tagged, skip := getFieldName(structField, tag)
+			if skip {
+				continue
+			}
+
+			leaf := &configLeaf{
+				field: joinFieldPath(parent.field, structField.Name),
+				names: append(append([]string{}, parent.names...), name),
+				index: append(append([]int{}, parent.index...), i),
+				kind:  indirectType(structField.Type).Kind(),
+			}
+			fieldType := indirectType(structField.Type)
+			if fieldType.Kind() == reflect.Struct && fieldType != durationType && !reflect.PtrTo(fieldType).Implements(textUnmarshalerType) {
+				if structField.Anonymous && !tagged {
+					leaf.field, leaf.names = parent.field, parent.names
+				}
+				collect(fieldType, leaf, visiting)
+				continue
+			}
+			switch fieldType.Kind() {
+			case reflect.Map, reflect.Interface, reflect.Func, reflect.Chan:
+				continue
+			}
+			leaves = append(leaves, leaf)
+		}
+	}
+	collect(indirectType(configType), &configLeaf{}, make(map[reflect.Type]bool))
+	return leaves
+}
+
+// name of field from tag, or field name if not tagged, unexported fields and fields tagged "-" are skipped
+func getFieldName(structField reflect.StructField, tag string) (string, bool, bool) {
+	if !structField.IsExported() {
+		return "", false, true
+	}
+	name := strings.Split(structField.Tag.Get(tag), ",")[0]
+	if name == "-" {
+		return "", true, true
+	}
+	if name == "" {
+		return structField.Name, false, false
+	}
+	return name, true, false
+}
+
+func indirectType(dataType reflect.Type) reflect.Type {
+	for dataType.Kind() == reflect.Ptr {
+		dataType = dataType.Elem()
+	}
+	return dataType
+}
+
+func joinFieldPath(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// environment variables named by prefix and field names in upper case joined by underscore, e.g. APP_LOG_LEVEL,
+// or the name from tag "env" for each field
+type EnvironmentConfigurationSource struct {
+	prefix string
+	lookup func(name string) (string, bool)
+}
+
+func NewEnvironmentSource(prefix string) *EnvironmentConfigurationSource {
+	return NewEnvironmentSourceEx(prefix, os.LookupEnv)
+}
+func NewEnvironmentSourceEx(prefix string, lookup func(name string) (string, bool)) *EnvironmentConfigurationSource {
+	return &EnvironmentConfigurationSource{prefix: prefix, lookup: lookup}
+}
+
+func (es *EnvironmentConfigurationSource) GetName() string {
+	return "environment"
+}
+func (es *EnvironmentConfigurationSource) GetVariableName(names []string) string {
+	segments := make([]string, 0, len(names)+1)
+	if es.prefix != "" {
+		segments = append(segments, es.prefix)
+	}
+	segments = append(segments, names...)
+	return strings.ToUpper(strings.Join(segments, "_"))
+}
+func (es *EnvironmentConfigurationSource) Bind(config interface{}) error {
+	root := reflect.ValueOf(config).Elem()
+	errs := make([]error, 0)
+	for _, leaf := range collectConfigLeaves(root.Type(), "env") {
+		name := es.GetVariableName(leaf.names)
+		text, exist := es.lookup(name)
+		if !exist {
+			continue
+		}
+		if err := setText(leaf.resolve(root), text); err != nil {
+			errs = append(errs, &ConfigurationFieldError{Source: "environment variable " + name, Field: leaf.field, Reason: err.Error()})
+		}
+	}
+	return dep.NewAggregateError(errs...)
+}
+
+// command line flags named by field names in lower case joined by dot, e.g. --log.level=debug or --log.level debug,
+// or the name from tag "flag" for each field. bool flags can be specified without value, unknown flags are ignored
+type CommandLineConfigurationSource struct {
+	args []string
+}
+
+func NewCommandLineSource(args []string) *CommandLineConfigurationSource {
+	return &CommandLineConfigurationSource{args: args}
+}
+
+func (cs *CommandLineConfigurationSource) GetName() string {
+	return "command line"
+}
+func (cs *CommandLineConfigurationSource) Bind(config interface{}) error {
+	root := reflect.ValueOf(config).Elem()
+	flags := make(map[string]*configLeaf)
+	for _, leaf := range collectConfigLeaves(root.Type(), "flag") {
+		flags[strings.ToLower(strings.Join(leaf.names, "."))] = leaf
+	}
+
+	errs := make([]error, 0)
+	for i := 0; i < len(cs.args); i++ {
+		arg := cs.args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		name, text, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		leaf, exist := flags[strings.ToLower(name)]
+		if !exist {
+			continue
+		}
+		if !hasValue {
+			if leaf.kind == reflect.Bool {
+				text = "true"
+			} else if i+1 < len(cs.args) {
+				i++
+				text = cs.args[i]
+			} else {
+				errs = append(errs, &ConfigurationFieldError{Source: "command line flag " + arg, Field: leaf.field, Reason: "value is not specified"})
+				continue
+			}
+		}
+		if err := setText(leaf.resolve(root), text); err != nil {
+			errs = append(errs, &ConfigurationFieldError{Source: "command line flag " + arg, Field: leaf.field, Reason: err.Error()})
+		}
+	}
+	return dep.NewAggregateError(errs...)
+}
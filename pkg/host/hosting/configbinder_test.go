@@ -0,0 +1,189 @@
+package hosting
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+)
+
+type BindLogConfig struct {
+	Level string `json:"level" yaml:"level"`
+	Paths []string
+}
+
+type BindConfig struct {
+	Name    string        `json:"name" yaml:"name"`
+	Port    int           `json:"port" yaml:"port" env:"HTTP_PORT"`
+	Verbose bool          `flag:"v"`
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+	Ratio   float64
+	Log     BindLogConfig  `json:"log" yaml:"log"`
+	Tls     *BindLogConfig `json:"tls" yaml:"tls"`
+	Labels  map[string]string
+	secret  string
+}
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func newLookup(variables map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, exist := variables[name]
+		return value, exist
+	}
+}
+
+func TestBindConfiguration_json(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{
+		"name": "app", "port": 8080, "timeout": "1m30s", "ratio": 0.5,
+		"log": {"level": "debug", "paths": ["a", "b"]},
+		"labels": {"zone": "west"}, "secret": "ignored"
+	}`)
+
+	config := &BindConfig{Name: "default", Verbose: true}
+	if err := BindConfiguration(config, NewFileSource(path)); err != nil {
+		t.Fatalf("failed to bind json file: %v", err)
+	}
+	if config.Name != "app" || config.Port != 8080 || config.Timeout != 90*time.Second || config.Ratio != 0.5 || !config.Verbose {
+		t.Errorf("fields are not bound from json file: %+v", config)
+	}
+	if config.Log.Level != "debug" || len(config.Log.Paths) != 2 || config.Labels["zone"] != "west" || config.secret != "" {
+		t.Errorf("nested fields are not bound from json file: %+v", config)
+	}
+	if config.Tls != nil {
+		t.Errorf("pointer field absent in json file should not be allocated")
+	}
+}
+
+func TestBindConfiguration_yaml(t *testing.T) {
+	path := writeConfigFile(t, "config.yml", "name: app\nport: 8080\ntimeout: 10s\nlog:\n  level: info\ntls:\n  paths:\n  - cert\n")
+
+	config := &BindConfig{}
+	if err := BindConfiguration(config, NewFileSource(path)); err != nil {
+		t.Fatalf("failed to bind yaml file: %v", err)
+	}
+	if config.Name != "app" || config.Port != 8080 || config.Timeout != 10*time.Second || config.Log.Level != "info" {
+		t.Errorf("fields are not bound from yaml file: %+v", config)
+	}
+	if config.Tls == nil || len(config.Tls.Paths) != 1 || config.Tls.Paths[0] != "cert" {
+		t.Errorf("pointer field should be allocated and bound from yaml file: %+v", config.Tls)
+	}
+}
+
+func TestBindConfiguration_type_mismatch(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{"port": "abc", "log": {"level": 1}, "ratio": true}`)
+
+	err := BindConfiguration(&BindConfig{}, NewJsonFileSource(path))
+	var fieldErr *ConfigurationFieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("type mismatch should be reported as field error, actual: %v", err)
+	}
+	for _, expected := range []string{
+		`field: Port, cannot use string "abc" as int`,
+		"field: Log.Level, cannot use number 1 as string",
+		"field: Ratio, cannot use bool true as float64",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("error should contain: %s, actual: %v", expected, err)
+		}
+	}
+}
+
+func TestBindConfiguration_environment(t *testing.T) {
+	source := NewEnvironmentSourceEx("app", newLookup(map[string]string{
+		"APP_NAME":      "env",
+		"APP_HTTP_PORT": "9090",
+		"APP_LOG_PATHS": "a, b,c",
+		"APP_TLS_LEVEL": "warn",
+		"APP_TIMEOUT":   "5s",
+	}))
+
+	config := &BindConfig{Port: 80}
+	if err := BindConfiguration(config, source); err != nil {
+		t.Fatalf("failed to bind environment variables: %v", err)
+	}
+	if config.Name != "env" || config.Port != 9090 || config.Timeout != 5*time.Second {
+		t.Errorf("fields are not bound from environment variables: %+v", config)
+	}
+	if len(config.Log.Paths) != 3 || config.Log.Paths[1] != "b" || config.Tls == nil || config.Tls.Level != "warn" {
+		t.Errorf("nested fields are not bound from environment variables: %+v", config)
+	}
+
+	source = NewEnvironmentSourceEx("app", newLookup(map[string]string{"APP_HTTP_PORT": "http"}))
+	err := BindConfiguration(config, source)
+	expected := `invalid configuration from environment variable APP_HTTP_PORT, field: Port, cannot parse "http" as int`
+	if err == nil || err.Error() != expected {
+		t.Errorf("error is not expected: %v", err)
+	}
+}
+
+func TestBindConfiguration_command_line(t *testing.T) {
+	source := NewCommandLineSource([]string{"run", "--name=flag", "-port", "7070", "--v", "--log.level", "error", "--unknown", "--", "--ratio=1"})
+
+	config := &BindConfig{}
+	if err := BindConfiguration(config, source); err != nil {
+		t.Fatalf("failed to bind command line flags: %v", err)
+	}
+	if config.Name != "flag" || config.Port != 7070 || !config.Verbose || config.Log.Level != "error" || config.Ratio != 0 {
+		t.Errorf("fields are not bound from command line flags: %+v", config)
+	}
+
+	err := BindConfiguration(config, NewCommandLineSource([]string{"--v=maybe", "--port"}))
+	aggregate := &dep.AggregateError{}
+	if !errors.As(err, &aggregate) || len(aggregate.Errors) != 2 {
+		t.Fatalf("each invalid flag should be reported, actual: %v", err)
+	}
+	if !strings.Contains(err.Error(), `field: Verbose, cannot parse "maybe" as bool`) || !strings.Contains(err.Error(), "field: Port, value is not specified") {
+		t.Errorf("error is not expected: %v", err)
+	}
+}
+
+func TestBindConfiguration_invalid_target(t *testing.T) {
+	err := BindConfiguration(BindConfig{}, NewCommandLineSource(nil))
+	if err == nil || !strings.Contains(err.Error(), "configuration to bind should be pointer of struct") {
+		t.Errorf("error is not expected: %v", err)
+	}
+}
+
+func TestConfigurationBuilder_precedence(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "name: file\nport: 1\nlog:\n  level: info\n")
+	t.Setenv("PRECEDENCE_HTTP_PORT", "2")
+	t.Setenv("PRECEDENCE_LOG_LEVEL", "warn")
+
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureHostConfiguration(func(configBuilder ConfigurationBuilder) {
+		// flags override environment variables, which override files regardless of the order added
+		configBuilder.AddCommandLine([]string{"--log.level=error"})
+		configBuilder.AddEnvironmentVariables("PRECEDENCE")
+		configBuilder.SetConfigurationFilePath(path)
+		configBuilder.SetConfiguration(&BindConfig{Name: "default", Ratio: 0.1})
+	})
+
+	host := builder.Build()
+	config := dep.GetConfig[BindConfig](host.GetComponentProvider())
+	if config.Name != "file" || config.Port != 2 || config.Log.Level != "error" || config.Ratio != 0.1 {
+		t.Errorf("configuration is not merged in precedence: %+v", config)
+	}
+}
+
+func TestConfigurationBuilder_bind_error(t *testing.T) {
+	defer test.AssertPanicContent(t, "failed to bind configuration: invalid configuration from command line flag --port=abc, field: Port", "panic content is not expected")
+
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureAppConfiguration(func(context dep.Context, configBuilder ConfigurationBuilder) {
+		configBuilder.SetConfiguration(&BindConfig{})
+		configBuilder.AddCommandLine([]string{"--port=abc"})
+	})
+	builder.Build()
+}
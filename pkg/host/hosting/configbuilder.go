@@ -1,20 +1,47 @@
 package hosting
 
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
 type LoadConfigurationMethod func(configFilePath string) interface{}
 
 type ConfigurationBuilder interface {
 	SetConfigurationFilePath(configFilePath string)
 	SetConfigurationLoader(configLoader LoadConfigurationMethod)
+
+	// pointer of configuration struct with default values, bound from the added sources without loader.
+	// sources are applied in precedence: files, environment variables, command line flags, the later overrides the former
+	SetConfiguration(config interface{})
+	AddJsonFile(path string)
+	AddYamlFile(path string)
+	AddEnvironmentVariables(prefix string)
+	AddCommandLine(args []string)
+
+	// reload configurations once any of the configuration files is changed, polled by the interval
+	ReloadOnChange(pollInterval time.Duration)
 }
 
 type DefaultConfigurationBuilder struct {
 	configFilePath string
 	configLoader   LoadConfigurationMethod
+
+	config      interface{}
+	fileSources []*FileConfigurationSource
+	envSources  []ConfigurationSource
+	flagSources []ConfigurationSource
+
+	reloadInterval time.Duration
 }
 
 func NewDefaultConfigurationBuilder() *DefaultConfigurationBuilder {
 	return &DefaultConfigurationBuilder{
 		configFilePath: "",
+		fileSources:    make([]*FileConfigurationSource, 0),
+		envSources:     make([]ConfigurationSource, 0),
+		flagSources:    make([]ConfigurationSource, 0),
 	}
 }
 
@@ -25,3 +52,116 @@ func (cb *DefaultConfigurationBuilder) SetConfigurationFilePath(configFilePath s
 func (cb *DefaultConfigurationBuilder) SetConfigurationLoader(configLoader LoadConfigurationMethod) {
 	cb.configLoader = configLoader
 }
+
+func (cb *DefaultConfigurationBuilder) SetConfiguration(config interface{}) {
+	cb.config = config
+}
+func (cb *DefaultConfigurationBuilder) AddJsonFile(path string) {
+	cb.fileSources = append(cb.fileSources, NewJsonFileSource(path))
+}
+func (cb *DefaultConfigurationBuilder) AddYamlFile(path string) {
+	cb.fileSources = append(cb.fileSources, NewYamlFileSource(path))
+}
+func (cb *DefaultConfigurationBuilder) AddEnvironmentVariables(prefix string) {
+	cb.envSources = append(cb.envSources, NewEnvironmentSource(prefix))
+}
+func (cb *DefaultConfigurationBuilder) AddCommandLine(args []string) {
+	cb.flagSources = append(cb.flagSources, NewCommandLineSource(args))
+}
+
+func (cb *DefaultConfigurationBuilder) ReloadOnChange(pollInterval time.Duration) {
+	cb.reloadInterval = pollInterval
+}
+
+// configuration files to watch if reload on change is enabled
+func (cb *DefaultConfigurationBuilder) getWatchedFiles() []string {
+	files := make([]string, 0)
+	if cb.reloadInterval <= 0 {
+		return files
+	}
+	if cb.configFilePath != "" {
+		files = append(files, cb.configFilePath)
+	}
+	for _, source := range cb.fileSources {
+		files = append(files, source.path)
+	}
+	return files
+}
+
+func (cb *DefaultConfigurationBuilder) isConfigured() bool {
+	return cb.configLoader != nil || cb.config != nil
+}
+
+// load configuration by loader or from configuration file path, then apply the sources in precedence
+func (cb *DefaultConfigurationBuilder) build() interface{} {
+	// defaults are not modified, so the configuration can be built again by reload
+	config := cloneConfiguration(cb.config)
+	sources := make([]ConfigurationSource, 0)
+	if cb.configLoader != nil {
+		config = cb.configLoader(cb.configFilePath)
+	} else if cb.configFilePath != "" {
+		sources = append(sources, NewFileSource(cb.configFilePath))
+	}
+	if config == nil {
+		return nil
+	}
+
+	for _, source := range cb.fileSources {
+		sources = append(sources, source)
+	}
+	sources = append(sources, cb.envSources...)
+	sources = append(sources, cb.flagSources...)
+	if err := BindConfiguration(config, sources...); err != nil {
+		panic(fmt.Errorf("failed to bind configuration: %w", err))
+	}
+	return config
+}
+
+// deep copy of exported fields, unexported fields are copied as they are
+func cloneConfiguration(config interface{}) interface{} {
+	if config == nil {
+		return nil
+	}
+	return cloneValue(reflect.ValueOf(config)).Interface()
+}
+func cloneValue(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value
+		}
+		clone := reflect.New(value.Type().Elem())
+		clone.Elem().Set(cloneValue(value.Elem()))
+		return clone
+	case reflect.Struct:
+		clone := reflect.New(value.Type()).Elem()
+		clone.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				clone.Field(i).Set(cloneValue(value.Field(i)))
+			}
+		}
+		return clone
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		clone := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			clone.Index(i).Set(cloneValue(value.Index(i)))
+		}
+		return clone
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		clone := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			clone.SetMapIndex(iter.Key(), cloneValue(iter.Value()))
+		}
+		return clone
+	default:
+		return value
+	}
+}
@@ -0,0 +1,243 @@
+package hosting
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+// reloads host and application configurations by their configured loaders, registered by host builder.
+// new configurations are validated and swapped only if all of them are loaded successfully
+type ConfigurationReloader interface {
+	Reload() error
+
+	// host or application configuration of the type, nil if not found
+	GetConfiguration(configType types.DataType) ReloadableConfiguration
+}
+
+type reloadableRecord struct {
+	name          string
+	configuration *DefaultConfiguration
+	load          func() interface{}
+}
+
+type DefaultConfigurationReloader struct {
+	logger  logger.Logger
+	mutex   sync.Mutex
+	records []*reloadableRecord
+}
+
+func NewDefaultConfigurationReloader(logger logger.Logger) *DefaultConfigurationReloader {
+	return &DefaultConfigurationReloader{
+		logger:  logger,
+		records: make([]*reloadableRecord, 0),
+	}
+}
+
+// configuration without loader is not reloaded, but still found by GetConfiguration
+func (cr *DefaultConfigurationReloader) AddConfiguration(name string, configuration *DefaultConfiguration, load func() interface{}) {
+	cr.records = append(cr.records, &reloadableRecord{name: name, configuration: configuration, load: load})
+}
+
+func (cr *DefaultConfigurationReloader) GetConfiguration(configType types.DataType) ReloadableConfiguration {
+	for _, record := range cr.records {
+		config := record.configuration.Get()
+		if config != nil && types.Of(config).ElementType().Key() == configType.Key() {
+			return record.configuration
+		}
+	}
+	return nil
+}
+
+func (cr *DefaultConfigurationReloader) Reload() error {
+	defer cr.mutex.Unlock()
+	cr.mutex.Lock()
+
+	loaded := make([]interface{}, len(cr.records))
+	errs := make([]error, 0)
+	for i, record := range cr.records {
+		config, err := cr.load(record)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to reload %s configuration: %w", record.name, err))
+		}
+		loaded[i] = config
+	}
+	if len(errs) > 0 {
+		err := dep.NewAggregateError(errs...)
+		cr.logger.Errorw("reload configuration failed, current configuration is kept", "error", err)
+		return err
+	}
+
+	// swap all configurations before notifying, so listeners always see consistent configurations
+	notifications := make([]func(), 0)
+	for i, record := range cr.records {
+		if loaded[i] == nil {
+			continue
+		}
+		current := loaded[i]
+		previous, listeners := record.configuration.swap(current)
+		for _, listener := range listeners {
+			listener := listener
+			notifications = append(notifications, func() { cr.notify(record.name, listener, current, previous) })
+		}
+	}
+	for _, notify := range notifications {
+		notify()
+	}
+
+	cr.logger.Infow("configuration reloaded", "listeners", len(notifications))
+	return nil
+}
+func (cr *DefaultConfigurationReloader) load(record *reloadableRecord) (config interface{}, err error) {
+	current := record.configuration.Get()
+	if record.load == nil || current == nil {
+		return nil, nil
+	}
+
+	// loader of configuration builder panics on binding failure
+	defer func() {
+		if r := recover(); r != nil {
+			config, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+
+	config = record.load()
+	if config == nil {
+		return nil, fmt.Errorf("loader returned nil configuration")
+	}
+	if types.Of(config).Key() != types.Of(current).Key() {
+		return nil, fmt.Errorf("type of configuration is changed from %s to %s", types.Of(current).FullName(), types.Of(config).FullName())
+	}
+	if err := ValidateConfiguration(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+func (cr *DefaultConfigurationReloader) notify(name string, listener ConfigurationChangeListener, current interface{}, previous interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			cr.logger.Errorw("panic from configuration change listener", "configuration", name, "panic", r)
+		}
+	}()
+	listener(current, previous)
+}
+
+// current value of reloadable configuration T, injectable after registered by AddOptionsMonitor
+type OptionsMonitor[T any] interface {
+	Get() *T
+	OnChange(listener func(current *T, previous *T)) (unsubscribe func())
+}
+
+type DefaultOptionsMonitor[T any] struct {
+	configuration ReloadableConfiguration
+}
+
+func NewOptionsMonitor[T any](configuration ReloadableConfiguration) *DefaultOptionsMonitor[T] {
+	return &DefaultOptionsMonitor[T]{configuration: configuration}
+}
+
+func (om *DefaultOptionsMonitor[T]) Get() *T {
+	return om.configuration.Get().(*T)
+}
+func (om *DefaultOptionsMonitor[T]) OnChange(listener func(current *T, previous *T)) func() {
+	return om.configuration.OnChange(func(current interface{}, previous interface{}) {
+		listener(current.(*T), previous.(*T))
+	})
+}
+
+// register OptionsMonitor[T] for host or application configuration of type T
+func AddOptionsMonitor[T any](components dep.ComponentCollection) {
+	dep.RegisterSingleton[OptionsMonitor[T]](components, func(reloader ConfigurationReloader) (*DefaultOptionsMonitor[T], error) {
+		configType := types.Get[T]()
+		configuration := reloader.GetConfiguration(configType)
+		if configuration == nil {
+			return nil, fmt.Errorf("configuration %s is not reloadable, only host and application configurations are supported", configType.FullName())
+		}
+		return NewOptionsMonitor[T](configuration), nil
+	})
+}
+
+// service polling modification time of configuration files, reloads configurations once any of them is changed
+type ConfigurationWatcher struct {
+	logger   logger.Logger
+	reloader ConfigurationReloader
+	files    []string
+	interval time.Duration
+	modTimes map[string]time.Time
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// modification time of files are recorded when the watcher is created, i.e. after configurations are loaded
+func NewConfigurationWatcher(logger logger.Logger, reloader ConfigurationReloader, files []string, interval time.Duration) *ConfigurationWatcher {
+	cw := &ConfigurationWatcher{
+		logger:   logger,
+		reloader: reloader,
+		files:    files,
+		interval: interval,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	cw.modTimes = cw.getModTimes()
+	return cw
+}
+
+func (cw *ConfigurationWatcher) getModTimes() map[string]time.Time {
+	modTimes := make(map[string]time.Time, len(cw.files))
+	for _, file := range cw.files {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[file] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+func (cw *ConfigurationWatcher) isChanged(previous map[string]time.Time, current map[string]time.Time) bool {
+	if len(previous) != len(current) {
+		return true
+	}
+	for file, modTime := range current {
+		if !previous[file].Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cw *ConfigurationWatcher) Run() {
+	defer close(cw.stopped)
+
+	ticker := time.NewTicker(cw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cw.done:
+			return
+		case <-ticker.C:
+			current := cw.getModTimes()
+			if !cw.isChanged(cw.modTimes, current) {
+				continue
+			}
+			cw.modTimes = current
+
+			cw.logger.Infow("configuration file changed, reloading", "files", cw.files)
+			_ = cw.reloader.Reload()
+		}
+	}
+}
+func (cw *ConfigurationWatcher) Stop(ctx context.Context) error {
+	close(cw.done)
+	select {
+	case <-cw.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
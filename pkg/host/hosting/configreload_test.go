@@ -0,0 +1,204 @@
+package hosting
+
+import (
+	"context"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+)
+
+type ReloadConfig struct {
+	Interval time.Duration `yaml:"interval" validate:"min=1ms"`
+	Tags     []string
+}
+
+type ReloadConsumer struct {
+	monitor OptionsMonitor[ReloadConfig]
+}
+
+func buildReloadHost(t *testing.T, configure ConfigureAppMethod) (Host, ConfigurationReloader, *ReloadConsumer) {
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureAppConfiguration(configure)
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		AddOptionsMonitor[ReloadConfig](components)
+		dep.RegisterSingleton[*ReloadConsumer](components, func(monitor OptionsMonitor[ReloadConfig]) *ReloadConsumer {
+			return &ReloadConsumer{monitor: monitor}
+		})
+	})
+	builder.UseComponentProvider(func(context BuilderContext, options *dep.ComponentProviderOptions) {
+		options.AllowedComponentTypes = append(options.AllowedComponentTypes, dep.StructPtrType)
+	})
+
+	host := builder.Build()
+	provider := host.GetComponentProvider()
+	return host, dep.GetComponent[ConfigurationReloader](provider), dep.GetComponent[*ReloadConsumer](provider)
+}
+
+func TestConfigurationReloader_reload(t *testing.T) {
+	interval := time.Second
+	_, reloader, consumer := buildReloadHost(t, func(context dep.Context, configBuilder ConfigurationBuilder) {
+		configBuilder.SetConfigurationLoader(func(string) interface{} {
+			return &ReloadConfig{Interval: interval}
+		})
+	})
+
+	initial := consumer.monitor.Get()
+	changes := make([]*ReloadConfig, 0)
+	unsubscribe := consumer.monitor.OnChange(func(current *ReloadConfig, previous *ReloadConfig) {
+		if previous != initial {
+			t.Errorf("previous configuration should be notified")
+		}
+		changes = append(changes, current)
+	})
+
+	interval = time.Minute
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if consumer.monitor.Get().Interval != time.Minute || initial.Interval != time.Second {
+		t.Errorf("reloaded configuration should be swapped instead of modified")
+	}
+	if len(changes) != 1 || changes[0] != consumer.monitor.Get() {
+		t.Errorf("listener should be notified with the current configuration, actual: %v", changes)
+	}
+
+	unsubscribe()
+	interval = time.Hour
+	_ = reloader.Reload()
+	if len(changes) != 1 || consumer.monitor.Get().Interval != time.Hour {
+		t.Errorf("unsubscribed listener should not be notified")
+	}
+}
+
+func TestDefaultConfiguration_Value(t *testing.T) {
+	initial := &ReloadConfig{Interval: time.Second}
+	if config := (&DefaultConfiguration{Value: initial}); config.Get() != initial {
+		t.Errorf("Value should be returned by configuration created without constructor")
+	}
+
+	config := NewDefaultConfiguration(initial)
+	current := &ReloadConfig{Interval: time.Minute}
+	config.swap(current)
+	if config.Value != initial || config.Get() != current {
+		t.Errorf("Value should keep the configuration loaded at start, actual: %v, current: %v", config.Value, config.Get())
+	}
+}
+
+func TestConfigurationReloader_invalid(t *testing.T) {
+	interval := time.Second
+	host, reloader, consumer := buildReloadHost(t, func(context dep.Context, configBuilder ConfigurationBuilder) {
+		configBuilder.SetConfigurationLoader(func(string) interface{} {
+			return &ReloadConfig{Interval: interval}
+		})
+	})
+	consumer.monitor.OnChange(func(current *ReloadConfig, previous *ReloadConfig) {
+		t.Errorf("listener should not be notified if reload failed")
+	})
+
+	interval = 0
+	err := reloader.Reload()
+	if err == nil || !strings.Contains(err.Error(), "failed to reload application configuration: invalid configuration field: Interval") {
+		t.Errorf("invalid configuration should fail the reload, actual: %v", err)
+	}
+	if consumer.monitor.Get().Interval != time.Second {
+		t.Errorf("current configuration should be kept if reload failed")
+	}
+	if dep.GetConfig[ReloadConfig](host.GetComponentProvider()) != consumer.monitor.Get() {
+		t.Errorf("registered configuration should be the one loaded at start")
+	}
+}
+
+func TestConfigurationReloader_builder_defaults(t *testing.T) {
+	t.Setenv("RELOAD_TAGS", "a,b")
+	_, reloader, consumer := buildReloadHost(t, func(context dep.Context, configBuilder ConfigurationBuilder) {
+		configBuilder.SetConfiguration(&ReloadConfig{Interval: time.Second, Tags: []string{"default"}})
+		configBuilder.AddEnvironmentVariables("RELOAD")
+	})
+	if tags := consumer.monitor.Get().Tags; len(tags) != 2 {
+		t.Errorf("configuration should be bound from environment, actual: %v", tags)
+	}
+
+	// variable removed from environment falls back to the default value
+	os.Unsetenv("RELOAD_TAGS")
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if tags := consumer.monitor.Get().Tags; len(tags) != 1 || tags[0] != "default" {
+		t.Errorf("defaults should not be modified by binding, actual: %v", tags)
+	}
+}
+
+func TestConfigurationWatcher(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "interval: 1s\n")
+	host, _, consumer := buildReloadHost(t, func(context dep.Context, configBuilder ConfigurationBuilder) {
+		configBuilder.SetConfiguration(&ReloadConfig{})
+		configBuilder.SetConfigurationFilePath(path)
+		configBuilder.ReloadOnChange(10 * time.Millisecond)
+	})
+
+	changed := make(chan *ReloadConfig, 1)
+	consumer.monitor.OnChange(func(current *ReloadConfig, previous *ReloadConfig) {
+		changed <- current
+	})
+
+	watcher := host.GetServices()["Service:ConfigurationWatcher"]
+	go watcher.Run()
+	defer stopService(t, watcher)
+
+	if err := os.WriteFile(path, []byte("interval: 2s\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	// make sure modification time is changed on file systems with coarse time resolution
+	_ = os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+
+	select {
+	case current := <-changed:
+		if current.Interval != 2*time.Second {
+			t.Errorf("configuration should be reloaded from changed file, actual: %v", current.Interval)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("configuration should be reloaded once file is changed")
+	}
+}
+
+func TestBasicAsyncAppRunner_reload_by_signal(t *testing.T) {
+	interval := time.Second
+	host, _, consumer := buildReloadHost(t, func(context dep.Context, configBuilder ConfigurationBuilder) {
+		configBuilder.SetConfigurationLoader(func(string) interface{} {
+			return &ReloadConfig{Interval: interval}
+		})
+	})
+	changed := make(chan bool, 1)
+	consumer.monitor.OnChange(func(current *ReloadConfig, previous *ReloadConfig) {
+		changed <- true
+	})
+
+	runner := dep.GetComponent[AsyncAppRunner](host.GetComponentProvider()).(*BasicAsyncAppRunner)
+	go func() {
+		interval = time.Minute
+		runner.done <- syscall.SIGHUP
+		select {
+		case <-changed:
+		case <-time.After(2 * time.Second):
+			t.Errorf("configuration should be reloaded by SIGHUP")
+		}
+		runner.SendStopSignal()
+	}()
+	host.Run()
+
+	if consumer.monitor.Get().Interval != time.Minute {
+		t.Errorf("configuration is not reloaded: %v", consumer.monitor.Get().Interval)
+	}
+}
+
+func stopService(t *testing.T, service Service) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := service.Stop(ctx); err != nil {
+		t.Errorf("stop service failed: %v", err)
+	}
+}
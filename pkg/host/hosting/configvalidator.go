@@ -0,0 +1,190 @@
+package hosting
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+)
+
+// implemented by configuration which validates itself, called after the rules of its fields
+type Validatable interface {
+	Validate() error
+}
+
+// configuration field which violates validation rule, field is empty for the configuration itself
+type ConfigurationValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ConfigurationValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("invalid configuration, %s", e.Reason)
+	}
+	return fmt.Sprintf("invalid configuration field: %s, %s", e.Field, e.Reason)
+}
+
+// validate configuration by rules of tag "validate" and Validatable, all invalid fields are aggregated.
+// rules are separated by comma, e.g. `validate:"required,min=1s,max=1h"`:
+//   - required: value should not be zero, or empty for slice and map
+//   - min=N, max=N: range of number and duration, or length of string, slice and map
+//   - oneof=a b c: value should be one of the space separated values
+func ValidateConfiguration(config interface{}) error {
+	if config == nil {
+		return nil
+	}
+	validator := &configValidator{}
+	validator.validate("", reflect.ValueOf(config))
+	return dep.NewAggregateError(validator.errs...)
+}
+
+// validate configurations registered as components, e.g. by ComponentCollection.AddConfiguration,
+// invalid fields of all configurations are aggregated, field paths start with name of the configuration type
+func validateRegisteredConfigurations(provider dep.ComponentProvider, registrations dep.RegistrationReader) error {
+	validator := &configValidator{}
+	for _, registration := range registrations.GetAllRegistrations() {
+		if registration.IsConfiguration {
+			config := provider.GetConfiguration(registration.ComponentType)
+			validator.validate(registration.ComponentType.Name(), reflect.ValueOf(config))
+		}
+	}
+	return dep.NewAggregateError(validator.errs...)
+}
+
+type configValidator struct {
+	errs []error
+}
+
+func (cv *configValidator) fail(field string, reason string, args ...any) {
+	cv.errs = append(cv.errs, &ConfigurationValidationError{Field: field, Reason: fmt.Sprintf(reason, args...)})
+}
+func (cv *configValidator) validate(field string, value reflect.Value) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			cv.validate(field, value.Elem())
+		}
+		return
+	case reflect.Struct:
+		valueType := value.Type()
+		for i := 0; i < valueType.NumField(); i++ {
+			structField := valueType.Field(i)
+			if !structField.IsExported() {
+				continue
+			}
+			fieldPath := joinFieldPath(field, structField.Name)
+			if rules, exist := structField.Tag.Lookup("validate"); exist {
+				cv.validateRules(fieldPath, value.Field(i), rules)
+			}
+			cv.validate(fieldPath, value.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			cv.validate(fmt.Sprintf("%s[%d]", field, i), value.Index(i))
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			cv.validate(fmt.Sprintf("%s[%v]", field, iter.Key()), iter.Value())
+		}
+	}
+
+	target := value.Interface()
+	if value.CanAddr() {
+		target = value.Addr().Interface()
+	}
+	if validatable, ok := target.(Validatable); ok {
+		if err := validatable.Validate(); err != nil {
+			cv.fail(field, err.Error())
+		}
+	}
+}
+func (cv *configValidator) validateRules(field string, value reflect.Value, rules string) {
+	// rules other than required don't apply to absent value
+	isNil := (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) && value.IsNil()
+	if !isNil {
+		value = reflect.Indirect(value)
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+		case "required":
+			if isNil || isEmptyValue(value) {
+				cv.fail(field, "value is required")
+			}
+		case "min", "max":
+			if isNil {
+				continue
+			}
+			actual, bound, err := getRangeValues(value, arg)
+			if err != nil {
+				cv.fail(field, "invalid rule %s: %s", rule, err.Error())
+			} else if name == "min" && actual < bound {
+				cv.fail(field, "value %v should not be less than %s", describeRangeValue(value), arg)
+			} else if name == "max" && actual > bound {
+				cv.fail(field, "value %v should not be greater than %s", describeRangeValue(value), arg)
+			}
+		case "oneof":
+			if isNil {
+				continue
+			}
+			actual := fmt.Sprint(value.Interface())
+			options := strings.Fields(arg)
+			found := false
+			for _, option := range options {
+				found = found || option == actual
+			}
+			if !found {
+				cv.fail(field, "value %q should be one of [%s]", actual, strings.Join(options, " "))
+			}
+		default:
+			cv.fail(field, "unknown validation rule: %s", rule)
+		}
+	}
+}
+
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	default:
+		return value.IsZero()
+	}
+}
+
+// compare duration by duration bound like 1m, length of string, slice and map, and numbers otherwise
+func getRangeValues(value reflect.Value, bound string) (float64, float64, error) {
+	if value.Type() == durationType {
+		duration, err := time.ParseDuration(bound)
+		return float64(value.Int()), float64(duration), err
+	}
+
+	limit, err := strconv.ParseFloat(bound, 64)
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len()), limit, err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), limit, err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), limit, err
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), limit, err
+	default:
+		return 0, 0, fmt.Errorf("range is not supported by type %s", value.Type())
+	}
+}
+
+func describeRangeValue(value reflect.Value) interface{} {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return fmt.Sprintf("of length %d", value.Len())
+	default:
+		return value.Interface()
+	}
+}
@@ -0,0 +1,169 @@
+package hosting
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+)
+
+type ValidatedEndpoint struct {
+	Url     string        `validate:"required"`
+	Timeout time.Duration `validate:"min=1s,max=1m"`
+}
+
+func (e ValidatedEndpoint) Validate() error {
+	if strings.HasPrefix(e.Url, "http:") {
+		return fmt.Errorf("insecure url: %s", e.Url)
+	}
+	return nil
+}
+
+type ValidatedConfig struct {
+	Name      string   `validate:"required,max=8"`
+	Workers   int      `validate:"min=1,max=16"`
+	Mode      string   `validate:"oneof=local onebox production"`
+	Tags      []string `validate:"required"`
+	Ratio     *float64 `validate:"min=0,max=1"`
+	Primary   ValidatedEndpoint
+	Fallbacks []*ValidatedEndpoint
+}
+
+func (c *ValidatedConfig) Validate() error {
+	if c.Primary.Url != "" && c.Primary.Url == c.Name {
+		return fmt.Errorf("name should not be the same as primary url")
+	}
+	return nil
+}
+
+func newValidConfig() *ValidatedConfig {
+	return &ValidatedConfig{
+		Name:    "agent",
+		Workers: 4,
+		Mode:    "local",
+		Tags:    []string{"a"},
+		Primary: ValidatedEndpoint{Url: "https://primary", Timeout: time.Second},
+	}
+}
+
+func TestValidateConfiguration(t *testing.T) {
+	if err := ValidateConfiguration(newValidConfig()); err != nil {
+		t.Errorf("valid configuration should pass validation: %v", err)
+	}
+	if err := ValidateConfiguration(nil); err != nil {
+		t.Errorf("absent configuration should pass validation: %v", err)
+	}
+}
+
+func TestValidateConfiguration_rules(t *testing.T) {
+	ratio := 1.5
+	config := &ValidatedConfig{
+		Name:    "too-long-name",
+		Workers: 0,
+		Mode:    "cloud",
+		Ratio:   &ratio,
+		Primary: ValidatedEndpoint{Timeout: time.Hour},
+		Fallbacks: []*ValidatedEndpoint{
+			nil,
+			{Url: "http://fallback", Timeout: time.Millisecond},
+		},
+	}
+
+	err := ValidateConfiguration(config)
+	aggregate := &dep.AggregateError{}
+	if !errors.As(err, &aggregate) {
+		t.Fatalf("invalid fields should be aggregated, actual: %v", err)
+	}
+	expected := []string{
+		"invalid configuration field: Name, value of length 13 should not be greater than 8",
+		"invalid configuration field: Workers, value 0 should not be less than 1",
+		`invalid configuration field: Mode, value "cloud" should be one of [local onebox production]`,
+		"invalid configuration field: Tags, value is required",
+		"invalid configuration field: Ratio, value 1.5 should not be greater than 1",
+		"invalid configuration field: Primary.Url, value is required",
+		"invalid configuration field: Primary.Timeout, value 1h0m0s should not be greater than 1m",
+		"invalid configuration field: Fallbacks[1].Timeout, value 1ms should not be less than 1s",
+		"invalid configuration field: Fallbacks[1], insecure url: http://fallback",
+	}
+	if len(aggregate.Errors) != len(expected) {
+		t.Errorf("count of errors is not expected: %v", err)
+	}
+	for _, message := range expected {
+		if !strings.Contains(err.Error(), message) {
+			t.Errorf("error should contain: %s, actual: %v", message, err)
+		}
+	}
+}
+
+func TestValidateConfiguration_validatable(t *testing.T) {
+	config := newValidConfig()
+	config.Name = config.Primary.Url[:8]
+	config.Primary.Url = config.Name
+
+	err := ValidateConfiguration(config)
+	if err == nil || err.Error() != "invalid configuration, name should not be the same as primary url" {
+		t.Errorf("error of Validate should be reported for the configuration itself, actual: %v", err)
+	}
+}
+
+func TestValidateConfiguration_invalid_rule(t *testing.T) {
+	config := &struct {
+		Flag  bool   `validate:"min=1"`
+		Count int    `validate:"min=one"`
+		Name  string `validate:"unique"`
+	}{}
+
+	err := ValidateConfiguration(config)
+	for _, message := range []string{
+		"field: Flag, invalid rule min=1: range is not supported by type bool",
+		"field: Count, invalid rule min=one",
+		"field: Name, unknown validation rule: unique",
+	} {
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("error should contain: %s, actual: %v", message, err)
+		}
+	}
+}
+
+func TestHostBuilder_invalid_host_configuration(t *testing.T) {
+	defer test.AssertPanicContent(t, "host configuration is invalid: invalid configuration field: Workers", "panic content is not expected")
+
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureHostConfiguration(func(configBuilder ConfigurationBuilder) {
+		config := newValidConfig()
+		config.Workers = 32
+		configBuilder.SetConfiguration(config)
+	})
+	builder.Build()
+}
+
+func TestHostBuilder_invalid_app_configuration(t *testing.T) {
+	defer test.AssertPanicContent(t, "application configuration is invalid: invalid configuration field: Mode", "panic content is not expected")
+
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureAppConfigurationEx(func(hostCtxt dep.HostContext) interface{} {
+		config := newValidConfig()
+		config.Mode = ""
+		return config
+	})
+	builder.Build()
+}
+
+func TestHostBuilder_invalid_registered_configurations(t *testing.T) {
+	defer test.AssertPanicContent(t, "registered configuration is invalid: 2 errors occurred:\n"+
+		"\tinvalid configuration field: ValidatedConfig.Workers, value 32 should not be greater than 16\n"+
+		"\tinvalid configuration field: ValidatedEndpoint.Timeout, value 1ms should not be less than 1s", "panic content is not expected")
+
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		config := newValidConfig()
+		config.Workers = 32
+		dep.AddConfig(components, config)
+		dep.AddConfig(components, &ValidatedEndpoint{Url: "https://endpoint", Timeout: time.Millisecond})
+	})
+	builder.Build()
+}
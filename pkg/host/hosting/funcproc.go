@@ -1,6 +1,7 @@
 package hosting
 
 import (
+	"errors"
 	"fmt"
 
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
@@ -12,6 +13,7 @@ import (
 func createFuncProcessor(context dep.Context, procType types.DataType, processorFunc dep.GenericActionMethod) FunctionProcessor {
 	provider := dep.GetComponent[dep.ContextualProvider](context)
 	fpCtxt := dep.NewComponentContext(context.(dep.ContextEx).GetScopeContext(), provider, procType)
+	dep.InheritResolution(fpCtxt, context.(dep.ContextEx))
 	processor := dep.GetComponent[FunctionProcessor](fpCtxt)
 	processor.Initialize(fpCtxt, procType, func(ctxt dep.Context, interfaceType types.DataType, scopeCtxt ScopeContext) {
 		processorFunc(ctxt, fmt.Sprintf("Processor(%s)", interfaceType.FullName()), dep.DepInst[ScopeContext](scopeCtxt))
@@ -25,6 +27,8 @@ type FunctionProcessor interface {
 	Initialize(fpCtxt dep.Context, dt types.DataType, proc ProcessorMethod) FunctionProcessor
 
 	Run(ctxt ScopeContext)
+	// error returned by the function is returned instead of raised as panic
+	RunE(ctxt ScopeContext) error
 }
 
 type DefaultFuncProcessor struct {
@@ -61,3 +65,18 @@ func (fp *DefaultFuncProcessor) Run(scopeCtxt ScopeContext) {
 
 	fp.logger.Debugw("FunctionProcessor run complete", "type", fp.procType.Name())
 }
+func (fp *DefaultFuncProcessor) RunE(scopeCtxt ScopeContext) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var actionErr *dep.ActionError
+			if e, ok := r.(error); ok && errors.As(e, &actionErr) {
+				err = actionErr.Err
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	fp.Run(scopeCtxt)
+	return nil
+}
@@ -3,8 +3,10 @@ package hosting
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/clock"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
 )
@@ -59,6 +61,12 @@ type HostAsyncOperator interface {
 	Shutdown(timeout time.Duration) error
 }
 
+// host raising stop events by itself, e.g. services not ready on start, or service down escalated by its restart policy.
+// app runner handles them the same as stop signals
+type StopEventSource interface {
+	StopEvents() <-chan *StopEvent
+}
+
 type Host interface {
 	GetRawContext() context.Context
 	GetName() string
@@ -83,6 +91,13 @@ type DefaultGenericHost struct {
 	provider    dep.ComponentProvider
 	LogFactory  logger.LoggerFactory
 	Logger      logger.Logger
+
+	supervisor *ServiceSupervisor
+	stopEvents chan *StopEvent
+	// services not started by Start for failure of their dependencies, they are not stopped on shutdown
+	notStarted map[string]bool
+	// memory monitor on the host clock, nil if memory statistics is not enabled
+	memoryMonitor MemoryMonitor
 }
 
 func NewDefaultGenericHost(ctxt *DefaultHostContext) *DefaultGenericHost {
@@ -91,8 +106,10 @@ func NewDefaultGenericHost(ctxt *DefaultHostContext) *DefaultGenericHost {
 		hostContext: ctxt,
 		provider:    ctxt.ComponentProvider,
 		LogFactory:  logFactory,
+		stopEvents:  make(chan *StopEvent, 1),
 	}
 	host.Logger = logFactory.GetLogger(dep.GetDefaultLoggerNameForComponent(host))
+	host.supervisor = NewServiceSupervisor(host.Logger, ctxt.RestartPolicies, host.raiseStopEvent)
 	return host
 }
 
@@ -120,16 +137,28 @@ func (h *DefaultGenericHost) GetServices() map[string]Service {
 	return h.hostContext.Services
 }
 
+func (h *DefaultGenericHost) StopEvents() <-chan *StopEvent {
+	return h.stopEvents
+}
+
+// pending event is kept if any, since the host is going to stop anyway
+func (h *DefaultGenericHost) raiseStopEvent(event *StopEvent) {
+	select {
+	case h.stopEvents <- event:
+	default:
+		h.Logger.Warnw("stop event is dropped, another one is pending", "type", event.Type.String())
+	}
+}
+
 func (h *DefaultGenericHost) startMemoryMonitor() {
 	if h.hostContext.builderContext.EnableMemoryStatistics {
-		mon := GetMemoryMonitor()
-		mon.Start()
+		h.memoryMonitor = NewMemoryMonitor(dep.GetComponent[clock.Clock](h.provider))
+		h.memoryMonitor.Start()
 	}
 }
 func (h *DefaultGenericHost) stopMemoryMonitor() {
-	if h.hostContext.builderContext.EnableMemoryStatistics {
-		mon := GetMemoryMonitor()
-		mon.Stop()
+	if h.memoryMonitor != nil {
+		h.memoryMonitor.Stop()
 	}
 }
 
@@ -143,22 +172,75 @@ func (h *DefaultGenericHost) Run() {
 	runner.Execute()
 }
 
+// data of stop event EVENT_TYPE_READINESS, raised when services are not all ready on host start
+type ServicesNotReady struct {
+	// errors of services failed to be ready, in order of services
+	Error error
+}
+
+// services are started in order of dependencies, each of them is started after its dependencies are ready.
+// the host is shut down if services are not all ready
 func (h *DefaultGenericHost) Start() {
 	h.Logger.Infow("Hosted services starting")
 
-	for name, service := range h.hostContext.Services {
-		h.Logger.Debug("starting service: ", name)
-		go service.Run()
+	// services are started concurrently once their dependencies are ready
+	var mutex sync.Mutex
+	started := make(map[string]bool)
+	errs := runServiceGraph(h.hostContext.ServiceOrder, h.hostContext.ServiceDependencies, true, func(name string) error {
+		mutex.Lock()
+		started[name] = true
+		mutex.Unlock()
+		return h.StartService(name, h.hostContext.Services[name])
+	})
+	if len(errs) > 0 {
+		failures := make([]error, 0, len(errs))
+		for _, name := range h.hostContext.ServiceOrder {
+			if err, failed := errs[name]; failed {
+				h.Logger.Errorw("starting service failed", "service", name, "error", err)
+				failures = append(failures, err)
+			}
+		}
+		h.notStarted = make(map[string]bool)
+		for _, name := range h.hostContext.ServiceOrder {
+			if !started[name] {
+				h.notStarted[name] = true
+			}
+		}
+		err := dep.NewAggregateError(failures...)
+		h.Logger.Errorw("Hosted services are not all ready, shutting down host", "failed", len(errs), "error", err)
+		h.raiseStopEvent(&StopEvent{Type: EVENT_TYPE_READINESS, Data: &ServicesNotReady{Error: err}})
+		return
 	}
 
-	// after all services started
-	h.hostContext.Lifecycle.OnAppStarted(h.hostContext)
+	// after all services are ready
+	// failures of hooks are logged by lifecycle, services keep running
+	_ = h.hostContext.Lifecycle.OnAppStarted(h.hostContext)
 
 	h.Logger.Debug("Hosted services started Successfully!")
 }
 
+// run the service supervised by its restart policy, and wait until it is ready if it is a ReadyWaiter
+func (h *DefaultGenericHost) StartService(name string, service Service) error {
+	h.Logger.Debug("starting service: ", name)
+	h.supervisor.Run(name, service)
+
+	waiter, ok := service.(ReadyWaiter)
+	if !ok {
+		return nil
+	}
+	ctxt, cancel := context.WithTimeout(h.hostContext.RawContext, h.hostContext.ServiceReadyTimeout)
+	defer cancel()
+	if err := waiter.WaitReady(ctxt); err != nil {
+		return fmt.Errorf("service %s is not ready: %w", name, err)
+	}
+	h.Logger.Debug("service is ready: ", name)
+	return nil
+}
+
+// hooks are notified of readiness failure, but the host is stopped anyway
 func (h *DefaultGenericHost) OnStopEvent(event *StopEvent) bool {
-	return h.hostContext.Lifecycle.OnStopEvent(h.hostContext, event)
+	accept := h.hostContext.Lifecycle.OnStopEvent(h.hostContext, event)
+	return accept || event.Type == EVENT_TYPE_READINESS
 }
 func (h *DefaultGenericHost) StopService(name string, service Service, ctxt context.Context) error {
 	panicErr := error(nil)
@@ -201,29 +283,35 @@ func (h *DefaultGenericHost) StopServiceWithTimeout(name string, service Service
 
 func (h *DefaultGenericHost) Shutdown(timeout time.Duration) error {
 	// before shuting down
-	h.hostContext.Lifecycle.OnAppStopping(h.hostContext)
+	lastError := h.hostContext.Lifecycle.OnAppStopping(h.hostContext)
 
-	// shut down services registered on the host
-	lastError := error(nil)
-	serviceCount := len(h.hostContext.Services)
-	done := make(chan error, serviceCount)
-	for name, service := range h.hostContext.Services {
+	// shut down services registered on the host, each of them after its dependents are stopped
+	dependents := getServiceDependents(h.hostContext.ServiceDependencies)
+	errs := runServiceGraph(h.hostContext.ServiceOrder, dependents, false, func(name string) error {
+		if h.notStarted[name] {
+			return nil
+		}
 		h.Logger.Debug("shutting down service: ", name)
-		go func(name string, srv Service) {
-			err := h.StopServiceWithTimeout(name, srv, timeout)
-			done <- err
-		}(name, service)
-	}
-	// wait for all complete
-	for i := 0; i < serviceCount; i++ {
-		err := <-done
-		if err != nil {
+		h.supervisor.Stopping(name)
+		return h.StopServiceWithTimeout(name, h.hostContext.Services[name], timeout)
+	})
+	for _, name := range h.hostContext.ServiceOrder {
+		if err, failed := errs[name]; failed {
 			lastError = err
 		}
 	}
 
 	// after shuting down
-	h.hostContext.Lifecycle.OnAppStopped(h.hostContext)
+	if err := h.hostContext.Lifecycle.OnAppStopped(h.hostContext); err != nil {
+		lastError = err
+	}
+
+	// release singleton components after all services stopped
+	err := h.hostContext.ComponentManager.Dispose()
+	if err != nil {
+		h.Logger.Errorw("disposing singleton components failed", "error", err)
+		lastError = err
+	}
 
 	h.Logger.Info("Hosted services were shut down complete")
 	return lastError
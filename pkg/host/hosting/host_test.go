@@ -1,6 +1,7 @@
 package hosting
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -197,6 +198,55 @@ func Test_Host_lifecycle(t *testing.T) {
 	}
 }
 
+type DisposableComponent interface {
+	IsDisposed() bool
+}
+type DefaultDisposableComponent struct {
+	disposed bool
+}
+
+func (dc *DefaultDisposableComponent) IsDisposed() bool {
+	return dc.disposed
+}
+func (dc *DefaultDisposableComponent) Dispose() error {
+	dc.disposed = true
+	return nil
+}
+
+func Test_Host_dispose_singleton(t *testing.T) {
+	builder := createHostBuilder()
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterSingleton[DisposableComponent](components, func() *DefaultDisposableComponent {
+			return &DefaultDisposableComponent{}
+		})
+	})
+
+	disposedBeforeStopped := true
+	builder.ConfigureLifecycle(func(hostContext dep.Context, appLifecycle ApplicationLifecycle) {
+		appLifecycle.RegisterOnAppStopped(func(ctx dep.Context) {
+			disposedBeforeStopped = dep.GetComponent[DisposableComponent](ctx).IsDisposed()
+		})
+	})
+
+	host := builder.Build()
+	provider := host.GetComponentProvider()
+	comp := dep.GetComponent[DisposableComponent](provider)
+	runner := dep.GetComponent[AsyncAppRunner](provider)
+
+	go func() {
+		runner.SendStopSignal()
+	}()
+
+	host.Run()
+
+	if disposedBeforeStopped {
+		t.Error("singleton should not be disposed before OnAppStopped")
+	}
+	if !comp.IsDisposed() {
+		t.Error("singleton is not disposed on host shutdown")
+	}
+}
+
 func Test_Host_components(t *testing.T) {
 	hostName := "Test"
 
@@ -443,9 +493,11 @@ func NewFakeLoopRunContext() *FakeLoopRunContext {
 	return &FakeLoopRunContext{}
 }
 
-func (rc *FakeLoopRunContext) LooperName() string { return "TestLoop" }
-func (rc *FakeLoopRunContext) IsStopped() bool    { return false }
-func (rc *FakeLoopRunContext) SetStopped()        {}
+func (rc *FakeLoopRunContext) LooperName() string  { return "TestLoop" }
+func (rc *FakeLoopRunContext) IsStopped() bool     { return false }
+func (rc *FakeLoopRunContext) SetStopped()         {}
+func (rc *FakeLoopRunContext) SetFailed(err error) {}
+func (rc *FakeLoopRunContext) GetError() error     { return nil }
 
 type FakeScopeContext struct {
 }
@@ -456,6 +508,7 @@ func NewFakeScopeContext() *FakeScopeContext {
 
 func (sc *FakeScopeContext) GetLoopRunContext() LoopRunContext            { return NewFakeLoopRunContext() }
 func (sc *FakeScopeContext) GetLooperContext() ServiceContext             { return nil }
+func (sc *FakeScopeContext) Context() context.Context                     { return context.Background() }
 func (sc *FakeScopeContext) HasVariable(key string, localScope bool) bool { return false }
 func (sc *FakeScopeContext) GetVariable(key string) interface{}           { return nil }
 func (sc *FakeScopeContext) SetVariable(key string, value interface{})    {}
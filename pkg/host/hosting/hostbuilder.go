@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/clock"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
@@ -52,6 +53,13 @@ type HostBuilder interface {
 	UseService(serviceType types.DataType, createService FreeStyleServiceFactoryMethod) HostBuilder
 	UseLoop(name string, configure ConfigureLoopMethod) HostBuilder
 
+	// service named by GetServiceName or GetLooperName is started after services it depends on are ready
+	AddServiceDependencies(name string, dependsOn ...string) HostBuilder
+	// time to wait for a ReadyWaiter service to be ready on start, DefaultServiceReadyTimeout by default
+	SetServiceReadyTimeout(timeout time.Duration) HostBuilder
+	// restart policy of service named by GetServiceName or GetLooperName, services are never restarted by default
+	SetRestartPolicy(name string, policy RestartPolicy) HostBuilder
+
 	Build() Host
 }
 
@@ -74,6 +82,14 @@ type DefaultHostBuilder struct {
 	Loopers                 map[string]*LooperSettings
 	ConfigServices          map[interface{}]FreeStyleServiceFactoryMethod
 	ConfigAppRunner         ConfigureAppRunnerMethod
+
+	// configuration files watched for reload
+	watchedFiles  []string
+	watchInterval time.Duration
+
+	serviceDependencies map[string][]string
+	serviceReadyTimeout time.Duration
+	restartPolicies     map[string]RestartPolicy
 }
 
 func NewDefaultHostBuilder() *DefaultHostBuilder {
@@ -81,6 +97,10 @@ func NewDefaultHostBuilder() *DefaultHostBuilder {
 		HostName:       "Default",
 		Loopers:        make(map[string]*LooperSettings),
 		ConfigServices: make(map[interface{}]FreeStyleServiceFactoryMethod),
+
+		serviceDependencies: make(map[string][]string),
+		serviceReadyTimeout: DefaultServiceReadyTimeout,
+		restartPolicies:     make(map[string]RestartPolicy),
 	}
 }
 
@@ -176,14 +196,29 @@ func (hb *DefaultHostBuilder) ConfigureServices(configure ConfigureServicesMetho
 }
 func (hb *DefaultHostBuilder) UseLoop(name string, configure ConfigureLoopMethod) HostBuilder {
 	hb.Loopers[name] = &LooperSettings{
-		Name:      name,
-		Interval:  time.Duration(60) * time.Second,
-		Recover:   true,
-		Configure: configure,
+		Name:        name,
+		Interval:    time.Duration(60) * time.Second,
+		MinInterval: DefaultMinLoopInterval,
+		Recover:     true,
+		Configure:   configure,
 	}
 	return hb
 }
 
+func (hb *DefaultHostBuilder) AddServiceDependencies(name string, dependsOn ...string) HostBuilder {
+	hb.serviceDependencies[name] = append(hb.serviceDependencies[name], dependsOn...)
+	return hb
+}
+func (hb *DefaultHostBuilder) SetServiceReadyTimeout(timeout time.Duration) HostBuilder {
+	hb.serviceReadyTimeout = timeout
+	return hb
+}
+
+func (hb *DefaultHostBuilder) SetRestartPolicy(name string, policy RestartPolicy) HostBuilder {
+	hb.restartPolicies[name] = policy
+	return hb
+}
+
 func (hb *DefaultHostBuilder) addService(serviceType types.DataType, createService FreeStyleServiceFactoryMethod) {
 	_, exist := hb.ConfigServices[serviceType.Key()]
 	if exist {
@@ -208,10 +243,11 @@ func (hb *DefaultHostBuilder) buildHostConfiguration(context *HostBuilderContext
 	if hb.ConfigHostConfiguration != nil {
 		configBuilder := NewDefaultConfigurationBuilder()
 		hb.ConfigHostConfiguration(configBuilder)
+		hb.watchConfigurationFiles(configBuilder)
 
-		if configBuilder.configLoader != nil {
+		if configBuilder.isConfigured() {
 			hb.HostConfigLoader = func(host HostSettings) interface{} {
-				return configBuilder.configLoader(configBuilder.configFilePath)
+				return configBuilder.build()
 			}
 		}
 	}
@@ -224,6 +260,9 @@ func (hb *DefaultHostBuilder) buildHostConfiguration(context *HostBuilderContext
 			if !configType.IsPtr() {
 				panic(fmt.Errorf("type of configured host config is not pointer type: %s", configType.FullName()))
 			}
+			if err := ValidateConfiguration(config); err != nil {
+				panic(fmt.Errorf("host configuration is invalid: %w", err))
+			}
 		}
 	}
 
@@ -285,27 +324,68 @@ func (hb *DefaultHostBuilder) buildAppConfiguration(context *DefaultHostContext)
 	if hb.ConfigAppConfiguration != nil {
 		configBuilder := NewDefaultConfigurationBuilder()
 		hb.ConfigAppConfiguration(context, configBuilder)
+		hb.watchConfigurationFiles(configBuilder)
 
-		if configBuilder.configLoader != nil {
+		if configBuilder.isConfigured() {
 			hb.AppConfigLoader = func(hostCtxt dep.HostContext) interface{} {
-				return configBuilder.configLoader(configBuilder.configFilePath)
+				return configBuilder.build()
 			}
 		}
 	}
 
 	if hb.AppConfigLoader != nil {
 		config = hb.AppConfigLoader(context)
+		if err := ValidateConfiguration(config); err != nil {
+			panic(fmt.Errorf("application configuration is invalid: %w", err))
+		}
 	}
 
 	context.Application.Configuration = NewDefaultConfiguration(config)
 }
 
-func (hb *DefaultHostBuilder) buildLifecycleConfiguration(context *DefaultHostContext) *DefaultLifecycle {
+func (hb *DefaultHostBuilder) watchConfigurationFiles(configBuilder *DefaultConfigurationBuilder) {
+	files := configBuilder.getWatchedFiles()
+	if len(files) == 0 {
+		return
+	}
+	hb.watchedFiles = append(hb.watchedFiles, files...)
+	if hb.watchInterval == 0 || configBuilder.reloadInterval < hb.watchInterval {
+		hb.watchInterval = configBuilder.reloadInterval
+	}
+}
+func (hb *DefaultHostBuilder) registerConfigurationReloader(context *DefaultHostContext) {
+	reloader := NewDefaultConfigurationReloader(context.GetLoggerWithName(dep.GetDefaultLoggerNameForComponentType(types.Get[*DefaultConfigurationReloader]())))
+
+	var loadHostConfig, loadAppConfig func() interface{}
+	if hb.HostConfigLoader != nil {
+		loadHostConfig = func() interface{} {
+			// host settings are applied only when the host is built
+			return hb.HostConfigLoader(NewDefaultHostSettings(&HostBuilderContext{}))
+		}
+	}
+	if hb.AppConfigLoader != nil {
+		loadAppConfig = func() interface{} {
+			return hb.AppConfigLoader(context)
+		}
+	}
+	reloader.AddConfiguration("host", context.builderContext.Configuration.(*DefaultConfiguration), loadHostConfig)
+	reloader.AddConfiguration("application", context.Application.Configuration.(*DefaultConfiguration), loadAppConfig)
+
+	dep.RegisterInstance[ConfigurationReloader](context.ComponentCollection, reloader)
+}
+
+// lifecycle is created before any component, so singletons subscribing lifecycle events are all tracked
+func (hb *DefaultHostBuilder) buildLifecycle(context *DefaultHostContext) {
 	appLifecycle := NewDefaultLifecycle()
-	if hb.ConfigLifecycle != nil {
+	context.GetScopeContext().GetScope().ObserveInstances(appLifecycle.TrackComponent)
+	context.Lifecycle = appLifecycle
+}
+
+func (hb *DefaultHostBuilder) buildLifecycleConfiguration(context *DefaultHostContext) {
+	appLifecycle, ok := context.Lifecycle.(ApplicationLifecycle)
+	if hb.ConfigLifecycle != nil && ok {
 		hb.ConfigLifecycle(context, appLifecycle)
 	}
-	return appLifecycle
 }
 
 func (hb *DefaultHostBuilder) registerAppComponents(context *DefaultHostContext) {
@@ -314,6 +394,8 @@ func (hb *DefaultHostBuilder) registerAppComponents(context *DefaultHostContext)
 		context.ComponentCollection.AddConfiguration(context.Application.Configuration.Get())
 	}
 
+	hb.registerConfigurationReloader(context)
+
 	// register other application components
 	if hb.ConfigComponents != nil {
 		builderContext := NewBuilderContext(context.builderContext)
@@ -346,8 +428,15 @@ func (hb *DefaultHostBuilder) registerServiceComponents(context *DefaultHostCont
 		})
 	}
 
+	// services are created with service context
+	context.ComponentManager.AddContextualType(types.Get[ServiceContext]())
+
 	// register generic components
 	dep.RegisterTransient[FunctionProcessor](context.ComponentCollection, NewFunctionProcessor)
+	// clock registered by user, e.g. fake clock in tests, takes precedence
+	if !context.ComponentCollection.IsComponentRegistered(types.Get[clock.Clock]()) {
+		dep.RegisterInstance[clock.Clock](context.ComponentCollection, clock.NewSystemClock())
+	}
 
 	// register platform specifics
 	registerPlatformComponents(context.ComponentCollection)
@@ -371,9 +460,28 @@ func (hb *DefaultHostBuilder) registerAppRunner(context *DefaultHostContext) {
 		}
 	}
 }
+func (hb *DefaultHostBuilder) validateConfigurations(context *DefaultHostContext) {
+	err := validateRegisteredConfigurations(context, context.ComponentManager.GetRegistrations())
+	if err != nil {
+		hb.Logger.Errorw("validate registered configurations failed", "error", err)
+		panic(fmt.Errorf("registered configuration is invalid: %w", err))
+	}
+}
+func (hb *DefaultHostBuilder) validateComponents(context *DefaultHostContext) {
+	if !context.ComponentManager.GetOptions().ValidateOnBuild {
+		return
+	}
+
+	err := context.ComponentManager.Validate()
+	if err != nil {
+		hb.Logger.Errorw("validate registered components failed", "error", err)
+		panic(err)
+	}
+	hb.Logger.Debugw("validate registered components complete", "count", context.ComponentCollection.Count())
+}
 func (hb *DefaultHostBuilder) buildHostedServices(context *DefaultHostContext) {
 	for typeKey, _ := range hb.ConfigServices {
-		serviceName := "Service:" + types.FromKey(typeKey).FullName()
+		serviceName := GetServiceName(types.FromKey(typeKey))
 
 		hb.Logger.Debug("Building service: " + serviceName)
 		service := context.GetComponent(types.FromKey(typeKey)).(Service)
@@ -381,8 +489,15 @@ func (hb *DefaultHostBuilder) buildHostedServices(context *DefaultHostContext) {
 		context.Services[serviceName] = service
 	}
 
+	if len(hb.watchedFiles) > 0 {
+		hb.Logger.Debugw("Building configuration watcher", "files", hb.watchedFiles)
+		reloader := dep.GetComponent[ConfigurationReloader](context)
+		watcherLogger := context.GetLoggerWithName(dep.GetDefaultLoggerNameForComponentType(types.Get[*ConfigurationWatcher]()))
+		context.Services["Service:ConfigurationWatcher"] = NewConfigurationWatcher(watcherLogger, reloader, hb.watchedFiles, hb.watchInterval)
+	}
+
 	for _, settings := range hb.Loopers {
-		serviceName := "Looper:" + settings.Name
+		serviceName := GetLooperName(settings.Name)
 
 		hb.Logger.Debugw("Building looper", "name", settings.Name)
 		looper := dep.GetComponent[Looper](context).(*DefaultLooper)
@@ -391,6 +506,22 @@ func (hb *DefaultHostBuilder) buildHostedServices(context *DefaultHostContext) {
 		context.Services[serviceName] = looper
 	}
 }
+func (hb *DefaultHostBuilder) buildServiceDependencies(context *DefaultHostContext) {
+	order, dependencies, err := resolveServiceDependencies(context.Services, hb.serviceDependencies)
+	if err != nil {
+		panic(fmt.Errorf("invalid service dependencies: %w", err))
+	}
+	context.ServiceOrder = order
+	context.ServiceDependencies = dependencies
+	context.ServiceReadyTimeout = hb.serviceReadyTimeout
+	for name, policy := range hb.restartPolicies {
+		if _, exist := context.Services[name]; !exist {
+			panic(fmt.Errorf("restart policy is set for unknown service: %s", name))
+		}
+		context.RestartPolicies[name] = policy
+	}
+	hb.Logger.Debugw("resolved order of services", "order", order)
+}
 
 func (hb *DefaultHostBuilder) Build() Host {
 	//
@@ -414,6 +545,7 @@ func (hb *DefaultHostBuilder) Build() Host {
 	hb.buildLoggerFactory(hostContext, builderContext)
 	// host context ready: logger Factory is initialized ready here
 	hostContext.Initialize()
+	hb.buildLifecycle(hostContext)
 
 	hb.Logger = hostContext.GetLoggerWithName(dep.GetDefaultLoggerNameForComponent(hb))
 	// host created from host context
@@ -428,20 +560,29 @@ func (hb *DefaultHostBuilder) Build() Host {
 	hb.registerServiceComponents(hostContext)
 	hb.registerAppRunner(hostContext)
 
+	//
+	// Stage 3: validate registered configurations and dependencies of registered components before any of them is built
+	//
+	hb.validateConfigurations(hostContext)
+	hb.validateComponents(hostContext)
+
 	//
 	// Stage 4: build hosted services and their dependencies with DI
 	//
 	hb.buildHostedServices(hostContext)
+	hb.buildServiceDependencies(hostContext)
 
 	//
 	// Stage 5: prepare lifecycle for running the host
 	//
-	hostContext.Lifecycle = hb.buildLifecycleConfiguration(hostContext)
+	hb.buildLifecycleConfiguration(hostContext)
 
 	//
 	// Stage 6: Host ready, execute hooks
 	//
-	hostContext.Lifecycle.OnHostReady(hostContext)
+	if err := hostContext.Lifecycle.OnHostReady(hostContext); err != nil {
+		panic(fmt.Errorf("host ready hooks failed: %w", err))
+	}
 
 	// print diagnostic info for registered components
 	if hostContext.IsDebug() {
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/clock"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
@@ -122,6 +123,25 @@ func Test_HostBuilder_HostConfiguration_memstats(t *testing.T) {
 	host.Run()
 }
 
+func Test_HostBuilder_memstats_clock(t *testing.T) {
+	fake := test.NewFakeClock(time.Now())
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureHostConfigurationEx(func(hs HostSettings) interface{} {
+		hs.EnableMemoryStatistics(true)
+		return nil
+	})
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterInstance[clock.Clock](components, fake)
+	})
+	host := builder.Build().(*DefaultGenericHost)
+
+	host.startMemoryMonitor()
+	defer host.stopMemoryMonitor()
+	if monitor := host.memoryMonitor.(*DefaultMemoryMonitor); monitor.clock != fake {
+		t.Errorf("memory monitor should run on the clock component of the host")
+	}
+}
+
 type MyAppConfig struct {
 	value int
 }
@@ -457,3 +477,89 @@ func Test_HostBuilder_ConfigureAppRunner_not_exist(t *testing.T) {
 
 	builder.Build()
 }
+
+func Test_HostBuilder_ValidateOnBuild(t *testing.T) {
+	hostName := "Test"
+
+	builder := NewDefaultHostBuilder()
+	builder.SetHostName(hostName)
+	builder.UseComponentProvider(func(context BuilderContext, options *dep.ComponentProviderOptions) {
+		options.ValidateOnBuild = true
+	})
+	builder.UseDefaultAppRunner()
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		components.RegisterSingletonForTypes(NewTestResultStore, types.Get[TestResultWriter](), types.Get[TestResultReader]())
+	})
+	builder.ConfigureServices(func(hb HostBuilder) {
+		hb.UseLoop("TestLoop", func(context ServiceContext, looper ConfigureLoopContext) {
+			looper.UseFuncProcessor(func() {
+				// do nothing
+			})
+		})
+	})
+	UseService[MyService](builder, NewMyService)
+
+	host := builder.Build()
+	if len(host.GetServices()) != 2 {
+		t.Error("service count is not expected")
+	}
+}
+
+func Test_HostBuilder_child_container(t *testing.T) {
+	builder := NewDefaultHostBuilder()
+	builder.SetHostName("Test")
+	builder.UseDefaultAppRunner()
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		components.RegisterSingletonForTypes(NewTestResultStore, types.Get[TestResultWriter](), types.Get[TestResultReader]())
+	})
+	host := builder.Build()
+
+	tenant := CreateChildContainer(host, dep.Props(dep.Pair("tenant", "contoso")), func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterSingleton[TestResultWriter](components, NewTestResultStore)
+	})
+	defer tenant.Dispose()
+
+	dep.GetComponent[TestResultWriter](tenant).WriteResult("tenant", true)
+	reader := dep.GetComponent[TestResultReader](tenant)
+	if reader != dep.GetComponent[TestResultReader](host.GetComponentProvider()) {
+		t.Errorf("component not registered in child container should be resolved from host")
+	}
+	if reader.HasResult("tenant") {
+		t.Errorf("singleton of child container should not be shared with host")
+	}
+}
+
+type wrappedHostContext struct{ dep.HostContext }
+type wrappedHost struct{ Host }
+
+func (wh wrappedHost) GetContext() dep.HostContext {
+	return wrappedHostContext{wh.Host.GetContext()}
+}
+
+func Test_HostBuilder_child_container_unsupported_host(t *testing.T) {
+	defer test.AssertPanicContent(t, "child container is not supported by host Test", "panic content not expected")
+
+	builder := NewDefaultHostBuilder()
+	builder.SetHostName("Test")
+	builder.UseDefaultAppRunner()
+	host := wrappedHost{builder.Build()}
+
+	CreateChildContainer(host, nil, nil)
+}
+
+func Test_HostBuilder_ValidateOnBuild_not_registered(t *testing.T) {
+	defer test.AssertPanicContent(t, "dependency hosting.MyService is not registered", "panic content not expected")
+
+	hostName := "Test"
+
+	builder := NewDefaultHostBuilder()
+	builder.SetHostName(hostName)
+	builder.UseComponentProvider(func(context BuilderContext, options *dep.ComponentProviderOptions) {
+		options.ValidateOnBuild = true
+	})
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterSingleton[Component](components, func(service MyService) Component { return nil })
+	})
+
+	builder.Build()
+}
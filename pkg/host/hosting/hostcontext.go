@@ -2,6 +2,7 @@ package hosting
 
 import (
 	"context"
+	"time"
 
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
@@ -22,6 +23,13 @@ type DefaultHostContext struct {
 	Application         ApplicationContext
 	Lifecycle           LifecycleHandler
 	Services            map[string]Service
+
+	// services sorted by dependencies, and dependencies of each service
+	ServiceOrder        []string
+	ServiceDependencies map[string][]string
+	ServiceReadyTimeout time.Duration
+	// restart policies of services by name, services not found are never restarted
+	RestartPolicies map[string]RestartPolicy
 }
 
 func NewHostContext(builderContext *HostBuilderContext, props dep.Properties) *DefaultHostContext {
@@ -32,6 +40,8 @@ func NewHostContext(builderContext *HostBuilderContext, props dep.Properties) *D
 		depTracker:     dep.NewDependencyTracker(nil),
 		builderContext: builderContext,
 		Services:       make(map[string]Service),
+
+		RestartPolicies: make(map[string]RestartPolicy),
 	}
 }
 
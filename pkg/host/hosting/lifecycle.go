@@ -2,8 +2,12 @@ package hosting
 
 import (
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
 )
 
 type OnHostReady func(ctxt dep.Context)
@@ -13,6 +17,10 @@ type EventType uint8
 const (
 	EVENT_TYPE_SIGNAL EventType = iota
 	EVENT_TYPE_WINSVC
+	// services are not ready on host start, data is *ServicesNotReady. it can not be refused
+	EVENT_TYPE_READINESS
+	// service is down and escalated by its restart policy, data is *ServiceExit
+	EVENT_TYPE_SERVICE
 )
 
 func (et EventType) String() string {
@@ -21,6 +29,10 @@ func (et EventType) String() string {
 		return "Signal"
 	case EVENT_TYPE_WINSVC:
 		return "WinSvc"
+	case EVENT_TYPE_READINESS:
+		return "Readiness"
+	case EVENT_TYPE_SERVICE:
+		return "Service"
 	default:
 		return fmt.Sprintf("EventType(%d)", et)
 	}
@@ -39,84 +51,263 @@ type OnApplicationStarted func(dep.Context)
 type OnApplicationStopped func(dep.Context)
 type OnApplicationStopping func(dep.Context)
 
+type LifecycleStage uint8
+
+const (
+	LifecycleStage_HostReady LifecycleStage = iota
+	LifecycleStage_AppStarted
+	LifecycleStage_AppStopping
+	LifecycleStage_AppStopped
+)
+
+func (ls LifecycleStage) String() string {
+	switch ls {
+	case LifecycleStage_HostReady:
+		return "HostReady"
+	case LifecycleStage_AppStarted:
+		return "AppStarted"
+	case LifecycleStage_AppStopping:
+		return "AppStopping"
+	case LifecycleStage_AppStopped:
+		return "AppStopped"
+	default:
+		return fmt.Sprintf("LifecycleStage(%d)", ls)
+	}
+}
+
+// hook failed by returned error, panic or timeout is logged, and remaining hooks of the stage still run
+type LifecycleHook func(ctxt dep.Context) error
+
+type HookSettings struct {
+	// name in logs and errors, "<stage>#<sequence>" by default
+	Name string
+	// hooks with higher priority run first, hooks with the same priority run in registration order
+	Priority int
+	// no timeout if not positive. hook timed out is reported as failure, but it can't be cancelled
+	Timeout time.Duration
+}
+
+// components subscribe lifecycle events by implementing below interfaces, they are discovered once the
+// singleton is created. OnStarted runs in creation order of components, OnStopping and OnStopped in reverse order.
+type OnStarted interface {
+	OnStarted(ctxt dep.Context) error
+}
+type OnStopping interface {
+	OnStopping(ctxt dep.Context) error
+}
+type OnStopped interface {
+	OnStopped(ctxt dep.Context) error
+}
+
+// hooks of the same type are all kept and executed in order, instead of replacing the previous one
 type ApplicationLifecycle interface {
 	RegisterOnHostReady(OnHostReady)
 	RegisterOnStopEvent(OnStopEvent)
 	RegisterOnAppStarted(OnApplicationStarted)
 	RegisterOnAppStopped(OnApplicationStopped)
 	RegisterOnAppStopping(OnApplicationStopping)
+
+	RegisterHook(stage LifecycleStage, hook LifecycleHook, settings HookSettings)
 }
 
+// errors of hooks are aggregated in dep.AggregateError
 type LifecycleHandler interface {
-	OnHostReady(context *DefaultHostContext)
+	OnHostReady(context *DefaultHostContext) error
 
-	OnAppStarted(context *DefaultHostContext)
+	OnAppStarted(context *DefaultHostContext) error
 	OnStopEvent(context *DefaultHostContext, event *StopEvent) bool
-	OnAppStopping(context *DefaultHostContext)
-	OnAppStopped(context *DefaultHostContext)
+	OnAppStopping(context *DefaultHostContext) error
+	OnAppStopped(context *DefaultHostContext) error
 }
 
-type DefaultLifecycle struct {
-	hostReadyHook OnHostReady
-	stopEventHook OnStopEvent
+type lifecycleHook struct {
+	hook     LifecycleHook
+	settings HookSettings
+}
 
-	onStoppingHook OnApplicationStopping
-	onStoppedHook  OnApplicationStopped
-	onStartedHook  OnApplicationStarted
+type DefaultLifecycle struct {
+	mutex      sync.Mutex
+	sequence   int
+	hooks      map[LifecycleStage][]*lifecycleHook
+	stopEvents []OnStopEvent
+	components []any
 }
 
 func NewDefaultLifecycle() *DefaultLifecycle {
-	return &DefaultLifecycle{}
+	return &DefaultLifecycle{
+		hooks:      make(map[LifecycleStage][]*lifecycleHook),
+		stopEvents: make([]OnStopEvent, 0),
+		components: make([]any, 0),
+	}
 }
 
 func (l *DefaultLifecycle) RegisterOnHostReady(onHostReady OnHostReady) {
-	l.hostReadyHook = onHostReady
+	l.RegisterHook(LifecycleStage_HostReady, func(ctxt dep.Context) error {
+		onHostReady(ctxt)
+		return nil
+	}, HookSettings{})
 }
 
 func (l *DefaultLifecycle) RegisterOnStopEvent(onStopEvent OnStopEvent) {
-	l.stopEventHook = onStopEvent
+	defer l.mutex.Unlock()
+	l.mutex.Lock()
+	l.stopEvents = append(l.stopEvents, onStopEvent)
 }
 
 func (l *DefaultLifecycle) RegisterOnAppStarted(onAppStarted OnApplicationStarted) {
-	l.onStartedHook = onAppStarted
+	l.RegisterHook(LifecycleStage_AppStarted, func(ctxt dep.Context) error {
+		onAppStarted(ctxt)
+		return nil
+	}, HookSettings{})
 }
 
 func (l *DefaultLifecycle) RegisterOnAppStopped(onAppStopped OnApplicationStopped) {
-	l.onStoppedHook = onAppStopped
+	l.RegisterHook(LifecycleStage_AppStopped, func(ctxt dep.Context) error {
+		onAppStopped(ctxt)
+		return nil
+	}, HookSettings{})
 }
 
 func (l *DefaultLifecycle) RegisterOnAppStopping(onAppStopping OnApplicationStopping) {
-	l.onStoppingHook = onAppStopping
+	l.RegisterHook(LifecycleStage_AppStopping, func(ctxt dep.Context) error {
+		onAppStopping(ctxt)
+		return nil
+	}, HookSettings{})
 }
 
-func (l *DefaultLifecycle) OnHostReady(context *DefaultHostContext) {
-	if l.hostReadyHook != nil {
-		l.hostReadyHook(context)
+func (l *DefaultLifecycle) RegisterHook(stage LifecycleStage, hook LifecycleHook, settings HookSettings) {
+	if hook == nil {
+		panic(fmt.Errorf("lifecycle hook of stage %s is nil", stage))
+	}
+	defer l.mutex.Unlock()
+	l.mutex.Lock()
+
+	l.sequence++
+	if settings.Name == "" {
+		settings.Name = fmt.Sprintf("%s#%d", stage, l.sequence)
 	}
+	l.hooks[stage] = append(l.hooks[stage], &lifecycleHook{hook: hook, settings: settings})
+}
+
+// track component subscribing lifecycle events, ignored if it implements none of OnStarted, OnStopping and OnStopped
+func (l *DefaultLifecycle) TrackComponent(instance any) {
+	switch instance.(type) {
+	case OnStarted, OnStopping, OnStopped:
+	default:
+		return
+	}
+	defer l.mutex.Unlock()
+	l.mutex.Lock()
+	l.components = append(l.components, instance)
+}
+
+func (l *DefaultLifecycle) OnHostReady(context *DefaultHostContext) error {
+	return l.runHooks(context, LifecycleStage_HostReady)
 }
 
 // return true(default): signal is accepted, false: signal is ignored.
+// the event is accepted only if all hooks accept it, and every hook is notified of the event.
 func (l *DefaultLifecycle) OnStopEvent(context *DefaultHostContext, event *StopEvent) bool {
-	if l.stopEventHook != nil {
-		return l.stopEventHook(context, event)
+	l.mutex.Lock()
+	stopEvents := l.stopEvents
+	l.mutex.Unlock()
+
+	accepted := true
+	for _, onStopEvent := range stopEvents {
+		if !onStopEvent(context, event) {
+			accepted = false
+		}
 	}
-	return true
+	return accepted
+}
+
+func (l *DefaultLifecycle) OnAppStarted(context *DefaultHostContext) error {
+	return l.runHooks(context, LifecycleStage_AppStarted)
+}
+
+func (l *DefaultLifecycle) OnAppStopping(context *DefaultHostContext) error {
+	return l.runHooks(context, LifecycleStage_AppStopping)
 }
 
-func (l *DefaultLifecycle) OnAppStarted(context *DefaultHostContext) {
-	if l.onStartedHook != nil {
-		l.onStartedHook(context)
+func (l *DefaultLifecycle) OnAppStopped(context *DefaultHostContext) error {
+	return l.runHooks(context, LifecycleStage_AppStopped)
+}
+
+// registered hooks ordered by priority, followed by hooks of components
+func (l *DefaultLifecycle) getHooks(stage LifecycleStage) []*lifecycleHook {
+	defer l.mutex.Unlock()
+	l.mutex.Lock()
+
+	hooks := append(make([]*lifecycleHook, 0, len(l.hooks[stage])), l.hooks[stage]...)
+	sort.SliceStable(hooks, func(i, j int) bool {
+		return hooks[i].settings.Priority > hooks[j].settings.Priority
+	})
+
+	for i := range l.components {
+		component := l.components[i]
+		if stage != LifecycleStage_AppStarted {
+			component = l.components[len(l.components)-1-i]
+		}
+		if hook := getComponentHook(stage, component); hook != nil {
+			hooks = append(hooks, &lifecycleHook{hook: hook, settings: HookSettings{Name: types.Of(component).FullName()}})
+		}
+	}
+	return hooks
+}
+
+func getComponentHook(stage LifecycleStage, component any) LifecycleHook {
+	switch stage {
+	case LifecycleStage_AppStarted:
+		if c, ok := component.(OnStarted); ok {
+			return c.OnStarted
+		}
+	case LifecycleStage_AppStopping:
+		if c, ok := component.(OnStopping); ok {
+			return c.OnStopping
+		}
+	case LifecycleStage_AppStopped:
+		if c, ok := component.(OnStopped); ok {
+			return c.OnStopped
+		}
 	}
+	return nil
 }
 
-func (l *DefaultLifecycle) OnAppStopping(context *DefaultHostContext) {
-	if l.onStoppingHook != nil {
-		l.onStoppingHook(context)
+func (l *DefaultLifecycle) runHooks(context *DefaultHostContext, stage LifecycleStage) error {
+	errs := make([]error, 0)
+	for _, hook := range l.getHooks(stage) {
+		if err := runHook(context, hook); err != nil {
+			err = fmt.Errorf("lifecycle hook %s of stage %s failed: %w", hook.settings.Name, stage, err)
+			context.GetLogger().Errorw("lifecycle hook failed", "stage", stage.String(), "hook", hook.settings.Name, "error", err)
+			errs = append(errs, err)
+		}
 	}
+	return dep.NewAggregateError(errs...)
 }
 
-func (l *DefaultLifecycle) OnAppStopped(context *DefaultHostContext) {
-	if l.onStoppedHook != nil {
-		l.onStoppedHook(context)
+func runHook(context *DefaultHostContext, hook *lifecycleHook) error {
+	call := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return hook.hook(context)
+	}
+	if hook.settings.Timeout <= 0 {
+		return call()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- call()
+	}()
+	timer := time.NewTimer(hook.settings.Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("timed out after %v", hook.settings.Timeout)
 	}
 }
@@ -0,0 +1,185 @@
+package hosting
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+)
+
+type LifecycleRecorder struct {
+	events []string
+}
+
+func (r *LifecycleRecorder) record(event string) {
+	r.events = append(r.events, event)
+}
+
+type SubscribingComponent struct {
+	name     string
+	recorder *LifecycleRecorder
+}
+
+func (c *SubscribingComponent) OnStarted(ctxt dep.Context) error {
+	c.recorder.record("started:" + c.name)
+	return nil
+}
+func (c *SubscribingComponent) OnStopping(ctxt dep.Context) error {
+	c.recorder.record("stopping:" + c.name)
+	if c.name == "second" {
+		return fmt.Errorf("%s refused to stop", c.name)
+	}
+	return nil
+}
+
+type FirstSubscriber struct{ SubscribingComponent }
+type SecondSubscriber struct{ SubscribingComponent }
+
+func TestLifecycle_multiple_hooks(t *testing.T) {
+	recorder := &LifecycleRecorder{}
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureLifecycle(func(hostContext dep.Context, appLifecycle ApplicationLifecycle) {
+		appLifecycle.RegisterOnAppStarted(func(ctx dep.Context) { recorder.record("first") })
+		appLifecycle.RegisterOnAppStarted(func(ctx dep.Context) { recorder.record("second") })
+		appLifecycle.RegisterHook(LifecycleStage_AppStarted, func(ctx dep.Context) error {
+			recorder.record("priority")
+			return nil
+		}, HookSettings{Priority: 10})
+		appLifecycle.RegisterHook(LifecycleStage_AppStarted, func(ctx dep.Context) error {
+			recorder.record("low")
+			return nil
+		}, HookSettings{Priority: -1})
+	})
+	host := builder.Build().(*DefaultGenericHost)
+
+	host.Start()
+	if strings.Join(recorder.events, ",") != "priority,first,second,low" {
+		t.Errorf("hooks should run by priority then registration order, actual: %v", recorder.events)
+	}
+	if err := host.Shutdown(time.Second); err != nil {
+		t.Errorf("shutdown should succeed: %v", err)
+	}
+}
+
+func TestLifecycle_hook_failures(t *testing.T) {
+	called := false
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureLifecycle(func(hostContext dep.Context, appLifecycle ApplicationLifecycle) {
+		appLifecycle.RegisterHook(LifecycleStage_AppStopping, func(ctx dep.Context) error {
+			return fmt.Errorf("flush failed")
+		}, HookSettings{Name: "flush"})
+		appLifecycle.RegisterOnAppStopping(func(ctx dep.Context) {
+			panic("unexpected")
+		})
+		appLifecycle.RegisterHook(LifecycleStage_AppStopped, func(ctx dep.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}, HookSettings{Name: "slow", Timeout: 10 * time.Millisecond})
+		appLifecycle.RegisterOnAppStopped(func(ctx dep.Context) {
+			called = true
+		})
+	})
+	host := builder.Build().(*DefaultGenericHost)
+	host.Start()
+
+	if err := host.hostContext.Lifecycle.OnAppStopping(host.hostContext); err == nil {
+		t.Errorf("failures of hooks should be returned")
+	} else {
+		aggregate := &dep.AggregateError{}
+		if !errors.As(err, &aggregate) || len(aggregate.Errors) != 2 {
+			t.Errorf("failures of hooks should be aggregated, actual: %v", err)
+		}
+		for _, message := range []string{
+			"lifecycle hook flush of stage AppStopping failed: flush failed",
+			"lifecycle hook AppStopping#2 of stage AppStopping failed: panic: unexpected",
+		} {
+			if !strings.Contains(err.Error(), message) {
+				t.Errorf("error should contain: %s, actual: %v", message, err)
+			}
+		}
+	}
+
+	err := host.Shutdown(time.Second)
+	if err == nil || !strings.Contains(err.Error(), "lifecycle hook slow of stage AppStopped failed: timed out after 10ms") {
+		t.Errorf("timed out hook should fail the shutdown, actual: %v", err)
+	}
+	if !called {
+		t.Errorf("hooks after the failed one should still run")
+	}
+}
+
+func TestLifecycle_host_ready_failure(t *testing.T) {
+	defer test.AssertPanicContent(t, "host ready hooks failed: lifecycle hook warmup of stage HostReady failed", "panic content is not expected")
+
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureLifecycle(func(hostContext dep.Context, appLifecycle ApplicationLifecycle) {
+		appLifecycle.RegisterHook(LifecycleStage_HostReady, func(ctx dep.Context) error {
+			return fmt.Errorf("cache is not warmed up")
+		}, HookSettings{Name: "warmup"})
+	})
+	builder.Build()
+}
+
+func TestLifecycle_stop_event(t *testing.T) {
+	notified := 0
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureLifecycle(func(hostContext dep.Context, appLifecycle ApplicationLifecycle) {
+		appLifecycle.RegisterOnStopEvent(func(dep.Context, *StopEvent) bool {
+			notified++
+			return false
+		})
+		appLifecycle.RegisterOnStopEvent(func(dep.Context, *StopEvent) bool {
+			notified++
+			return true
+		})
+	})
+	host := builder.Build().(*DefaultGenericHost)
+
+	if host.OnStopEvent(&StopEvent{Type: EVENT_TYPE_SIGNAL}) {
+		t.Errorf("stop event should be ignored if any hook refuses it")
+	}
+	if notified != 2 {
+		t.Errorf("all hooks should be notified of stop event, actual: %d", notified)
+	}
+}
+
+func TestLifecycle_component_subscription(t *testing.T) {
+	recorder := &LifecycleRecorder{}
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterSingleton[*FirstSubscriber](components, func() *FirstSubscriber {
+			return &FirstSubscriber{SubscribingComponent{name: "first", recorder: recorder}}
+		})
+		dep.RegisterSingleton[*SecondSubscriber](components, func() *SecondSubscriber {
+			return &SecondSubscriber{SubscribingComponent{name: "second", recorder: recorder}}
+		})
+		dep.RegisterTransient[*SubscribingComponent](components, func() *SubscribingComponent {
+			return &SubscribingComponent{name: "transient", recorder: recorder}
+		})
+	})
+	builder.UseComponentProvider(func(context BuilderContext, options *dep.ComponentProviderOptions) {
+		options.AllowedComponentTypes = append(options.AllowedComponentTypes, dep.StructPtrType)
+	})
+	builder.ConfigureLifecycle(func(hostContext dep.Context, appLifecycle ApplicationLifecycle) {
+		appLifecycle.RegisterOnAppStopping(func(ctx dep.Context) { recorder.record("stopping:hook") })
+	})
+	host := builder.Build().(*DefaultGenericHost)
+	dep.GetComponent[*FirstSubscriber](host.GetComponentProvider())
+	dep.GetComponent[*SecondSubscriber](host.GetComponentProvider())
+	dep.GetComponent[*SubscribingComponent](host.GetComponentProvider())
+
+	host.Start()
+	err := host.Shutdown(time.Second)
+
+	expected := "started:first,started:second,stopping:hook,stopping:second,stopping:first"
+	if strings.Join(recorder.events, ",") != expected {
+		t.Errorf("components should be notified in order of creation, actual: %v", recorder.events)
+	}
+	if err == nil || !strings.Contains(err.Error(), "second refused to stop") {
+		t.Errorf("failure of component hook should be returned by shutdown, actual: %v", err)
+	}
+}
@@ -2,9 +2,13 @@ package hosting
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/clock"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
@@ -22,7 +26,18 @@ type ConfigureGroupContext interface {
 	UseProcessor(types.DataType)
 	UseConditionalProcessor(types.DataType, ConditionMethod)
 	UseProcessorGroup(ConfigureLoopGroupMethod, ConditionMethod)
+	// processors of the group run concurrently by at most maxWorkers, all at once if not positive
+	UseParallelGroup(configureGroup ConfigureLoopGroupMethod, condition ConditionMethod, maxWorkers int)
 	UseFuncProcessor(procFunc dep.FreeStyleProcessorMethod)
+
+	// timeout of each run of the group, i.e. each iteration of the looper, no timeout if not positive
+	SetIterationTimeout(timeout time.Duration)
+	// timeout of processors in the group without their own timeout, no timeout if not positive
+	SetDefaultProcessorTimeout(timeout time.Duration)
+	SetProcessorTimeout(processorType types.DataType, timeout time.Duration)
+	// retry policy of processors in the group without their own policy, processors returning error are not retried by default
+	SetDefaultRetryPolicy(policy RetryPolicy)
+	SetRetryPolicy(processorType types.DataType, policy RetryPolicy)
 }
 
 func UseProcessor[T any](group ConfigureGroupContext, condition ConditionMethod) {
@@ -32,11 +47,27 @@ func UseProcessor[T any](group ConfigureGroupContext, condition ConditionMethod)
 		group.UseConditionalProcessor(types.Get[T](), condition)
 	}
 }
+func SetProcessorTimeout[T any](group ConfigureGroupContext, timeout time.Duration) {
+	group.SetProcessorTimeout(types.Get[T](), timeout)
+}
+func SetRetryPolicy[T any](group ConfigureGroupContext, policy RetryPolicy) {
+	group.SetRetryPolicy(types.Get[T](), policy)
+}
 
 type ConfigureLoopContext interface {
 	ConfigureGroupContext
 
+	// fixed interval, it is not changed by configuration reload
 	SetInterval(time.Duration)
+	// interval evaluated for each iteration, read it from OptionsMonitor to honour reloaded configuration
+	SetIntervalFunc(func() time.Duration)
+	// run at fire times of the schedule instead of interval
+	SetSchedule(schedule Schedule)
+	// cron expression with seconds, e.g. "0 0 2 * * *", fire times are computed in the location, time.Local if nil
+	SetCron(expression string, location *time.Location)
+	SetScheduleOptions(options ScheduleOptions)
+	// lower bound of the wait between iterations when an iteration overruns the interval
+	SetMinInterval(time.Duration)
 	SetRecover(enabled bool)
 	ConfigureLogger(ConfigureLoopLoggerMethod)
 	ConfigureLoopGlobalContext(LoopGlobalContextInitMethod)
@@ -49,10 +80,12 @@ type GroupContext interface {
 }
 
 type LooperSettings struct {
-	Name      string
-	Interval  time.Duration
-	Recover   bool
-	Configure ConfigureLoopMethod
+	Name     string
+	Interval time.Duration
+	// DefaultMinLoopInterval is used if it is zero
+	MinInterval time.Duration
+	Recover     bool
+	Configure   ConfigureLoopMethod
 }
 
 type Looper interface {
@@ -77,6 +110,12 @@ type ProcessorGroup interface {
 	SetName(string)
 	SetScopeContextInitializer(ScopeContextInitMethod)
 	AddProcessor(processorType types.DataType, createInstance CreateProcessorMethod, condition ConditionMethod)
+	SetRunTimeout(timeout time.Duration)
+	SetDefaultProcessorTimeout(timeout time.Duration)
+	SetProcessorTimeout(processorType types.DataType, timeout time.Duration)
+	SetParallel(maxWorkers int)
+	SetDefaultRetryPolicy(policy RetryPolicy)
+	SetRetryPolicy(processorType types.DataType, policy RetryPolicy)
 
 	SetLooperContext(LooperContext)
 	GetLooperContext() LooperContext
@@ -84,22 +123,38 @@ type ProcessorGroup interface {
 	Initialize()
 
 	Run(parent ScopeContext)
-	RunNewIteration(global LoopGlobalContext)
+	// return error if the iteration failed, e.g. processor timed out
+	RunNewIteration(global LoopGlobalContext) error
 }
 
 type DefaultProcessorGroup struct {
 	context          dep.Context
 	logger           logger.Logger
+	clock            clock.Clock
 	looper           LooperContext
 	name             string
 	initGroupContext ScopeContextInitMethod
 	processors       []*ProcessorRecord
+
+	runTimeout        time.Duration
+	defaultTimeout    time.Duration
+	processorTimeouts map[interface{}]time.Duration
+
+	parallel   bool
+	maxWorkers int
+	// creates DI scope of each branch of parallel group
+	scopeFactory dep.ScopeFactory
+
+	defaultRetryPolicy RetryPolicy
+	retryPolicies      map[interface{}]RetryPolicy
 }
 
 func NewDefaultProcessorGroup(context dep.Context) *DefaultProcessorGroup {
 	pg := &DefaultProcessorGroup{
-		context:    context,
-		processors: make([]*ProcessorRecord, 0),
+		context:           context,
+		processors:        make([]*ProcessorRecord, 0),
+		processorTimeouts: make(map[interface{}]time.Duration),
+		retryPolicies:     make(map[interface{}]RetryPolicy),
 	}
 
 	return pg
@@ -111,14 +166,50 @@ func (pg *DefaultProcessorGroup) SetName(name string) {
 func (pg *DefaultProcessorGroup) SetScopeContextInitializer(initScopeContext ScopeContextInitMethod) {
 	pg.initGroupContext = initScopeContext
 }
+// processor of parallel group is created for each run by its branch, see runParallel
 func (pg *DefaultProcessorGroup) AddProcessor(processorType types.DataType, createInstance CreateProcessorMethod, condition ConditionMethod) {
 	record := &ProcessorRecord{
-		Type:      processorType,
-		Condition: condition,
-		Instance:  createInstance(pg.context, processorType, nil),
+		Type:           processorType,
+		Condition:      condition,
+		createInstance: createInstance,
+	}
+	if !pg.parallel {
+		record.Instance = createInstance(pg.context, processorType, nil)
 	}
 	pg.processors = append(pg.processors, record)
 }
+func (pg *DefaultProcessorGroup) SetRunTimeout(timeout time.Duration) {
+	pg.runTimeout = timeout
+}
+func (pg *DefaultProcessorGroup) SetDefaultProcessorTimeout(timeout time.Duration) {
+	pg.defaultTimeout = timeout
+}
+func (pg *DefaultProcessorGroup) SetProcessorTimeout(processorType types.DataType, timeout time.Duration) {
+	pg.processorTimeouts[processorType.Key()] = timeout
+}
+// processors run concurrently by at most maxWorkers go routines, all at once if not positive
+func (pg *DefaultProcessorGroup) SetParallel(maxWorkers int) {
+	pg.parallel = true
+	pg.maxWorkers = maxWorkers
+}
+func (pg *DefaultProcessorGroup) SetDefaultRetryPolicy(policy RetryPolicy) {
+	pg.defaultRetryPolicy = policy
+}
+func (pg *DefaultProcessorGroup) SetRetryPolicy(processorType types.DataType, policy RetryPolicy) {
+	pg.retryPolicies[processorType.Key()] = policy
+}
+func (pg *DefaultProcessorGroup) getRetryPolicy(processorType types.DataType) RetryPolicy {
+	if policy, exist := pg.retryPolicies[processorType.Key()]; exist {
+		return policy
+	}
+	return pg.defaultRetryPolicy
+}
+func (pg *DefaultProcessorGroup) getProcessorTimeout(processorType types.DataType) time.Duration {
+	if timeout, exist := pg.processorTimeouts[processorType.Key()]; exist {
+		return timeout
+	}
+	return pg.defaultTimeout
+}
 
 func (pg *DefaultProcessorGroup) SetLooperContext(looper LooperContext) {
 	pg.looper = looper
@@ -156,6 +247,8 @@ func (pg *DefaultProcessorGroup) getLoggerName() string {
 // init happens after instance is created and all context configuration are done
 func (pg *DefaultProcessorGroup) Initialize() {
 	pg.logger = pg.context.GetLoggerWithName(pg.getLoggerName())
+	pg.clock = dep.GetComponent[clock.Clock](pg.context)
+	pg.scopeFactory = dep.GetComponent[dep.ScopeFactory](pg.context)
 }
 
 func (pg *DefaultProcessorGroup) Run(parent ScopeContext) {
@@ -165,18 +258,30 @@ func (pg *DefaultProcessorGroup) Run(parent ScopeContext) {
 	pg.runWithContext(groupCtxt)
 }
 
-func (pg *DefaultProcessorGroup) RunNewIteration(global LoopGlobalContext) {
+func (pg *DefaultProcessorGroup) RunNewIteration(global LoopGlobalContext) error {
 	// create context for new iteration run of the loop
 	runContext := NewLoopRunContext(global)
 
 	pg.runWithContext(runContext)
+	return runContext.GetError()
 }
 
 func (pg *DefaultProcessorGroup) runWithContext(groupCtxt ScopeContext) {
+	if pg.runTimeout > 0 {
+		ctx, cancel := context.WithTimeout(groupCtxt.Context(), pg.runTimeout)
+		defer cancel()
+		groupCtxt = newDeadlineScopeContext(groupCtxt, ctx)
+	}
+
 	if pg.initGroupContext != nil {
 		pg.initGroupContext(groupCtxt)
 	}
 
+	if pg.parallel {
+		pg.runParallel(groupCtxt)
+		return
+	}
+
 	// start running loop and execute all processors
 	for _, record := range pg.processors {
 		// check processor condition if exist
@@ -187,7 +292,13 @@ func (pg *DefaultProcessorGroup) runWithContext(groupCtxt ScopeContext) {
 		}
 
 		pg.logger.Debugw("run loop processor", "looper", pg.LooperName(), "processor", record.Type.Name())
-		record.Instance.Run(groupCtxt)
+		if err := pg.runProcessor(record, record.Instance, groupCtxt); err != nil {
+			// processor overran or loop is stopping, the rest of the group is skipped
+			if failure := pg.processorStopped(record, err); failure != nil {
+				groupCtxt.GetLoopRunContext().SetFailed(failure)
+			}
+			break
+		}
 
 		// check context complete or loop run stopped
 		if groupCtxt.IsExit() || groupCtxt.GetLoopRunContext().IsStopped() {
@@ -197,6 +308,193 @@ func (pg *DefaultProcessorGroup) runWithContext(groupCtxt ScopeContext) {
 	}
 }
 
+// processor is not run again while its run abandoned by timeout is still in flight
+var ErrProcessorInFlight = errors.New("previous run of processor is still in flight")
+
+// return error to fail the iteration if the processor failed, timed out or is still in flight, nil if it is cancelled
+func (pg *DefaultProcessorGroup) processorStopped(record *ProcessorRecord, err error) error {
+	var failure *ProcessorError
+	if errors.As(err, &failure) {
+		pg.logger.Errorw("loop processor failed, skip the rest of the group", "looper", pg.LooperName(), "processor", record.Type.FullName(), "attempts", failure.Attempts, "error", failure.Err)
+		return err
+	}
+	if errors.Is(err, ErrProcessorInFlight) {
+		pg.logger.Errorw("loop processor is still in flight, skip the rest of the group", "looper", pg.LooperName(), "processor", record.Type.FullName())
+		return fmt.Errorf("processor %s is skipped: %w", record.Type.FullName(), err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		pg.logger.Errorw("loop processor timed out, skip the rest of the group", "looper", pg.LooperName(), "processor", record.Type.FullName())
+		return fmt.Errorf("processor %s timed out: %w", record.Type.FullName(), err)
+	}
+	pg.logger.Infow("loop processor is cancelled, skip the rest of the group", "looper", pg.LooperName(), "processor", record.Type.FullName(), "error", err)
+	return nil
+}
+
+// each processor runs with its own child scope, and is resolved from its own DI scope disposed once the processor completes.
+// no more processor is started once any of them exits its scope, fails or panics, or the loop run is stopped.
+// Panics are aggregated and raised again after all started ones complete
+func (pg *DefaultProcessorGroup) runParallel(groupCtxt ScopeContext) {
+	workers := pg.maxWorkers
+	if workers <= 0 || workers > len(pg.processors) {
+		workers = len(pg.processors)
+	}
+	slots := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var stopped int32
+	failures := make([]error, 0)
+	panics := make([]error, 0)
+
+	for _, record := range pg.processors {
+		slots <- struct{}{}
+		if atomic.LoadInt32(&stopped) != 0 || groupCtxt.GetLoopRunContext().IsStopped() || groupCtxt.Context().Err() != nil {
+			<-slots
+			pg.logger.Debugw("parallel group is stopped, skip the rest of the group", "looper", pg.LooperName(), "processor", record.Type.Name())
+			break
+		}
+
+		branchCtxt := NewGroupScopeContext(pg, groupCtxt)
+		if record.Condition != nil && !record.Condition(branchCtxt) {
+			<-slots
+			continue
+		}
+
+		wg.Add(1)
+		go func(record *ProcessorRecord, branchCtxt *GroupScopeContext) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			defer func() {
+				if r := recover(); r != nil {
+					atomic.StoreInt32(&stopped, 1)
+					mutex.Lock()
+					panics = append(panics, fmt.Errorf("processor %s panicked: %v", record.Type.FullName(), r))
+					mutex.Unlock()
+				}
+			}()
+
+			scope := pg.scopeFactory.CreateScope(pg.context, nil)
+			defer scope.Dispose()
+			instance := record.createInstance(scope.Context(), record.Type, nil)
+
+			pg.logger.Debugw("run loop processor in parallel", "looper", pg.LooperName(), "processor", record.Type.Name(), "scope", scope.GetScopeId())
+			if err := pg.runProcessor(record, instance, branchCtxt); err != nil {
+				atomic.StoreInt32(&stopped, 1)
+				if failure := pg.processorStopped(record, err); failure != nil {
+					mutex.Lock()
+					failures = append(failures, failure)
+					mutex.Unlock()
+				}
+				return
+			}
+			if branchCtxt.IsExit() {
+				atomic.StoreInt32(&stopped, 1)
+			}
+		}(record, branchCtxt)
+	}
+	wg.Wait()
+
+	if err := dep.NewAggregateError(failures...); err != nil {
+		groupCtxt.GetLoopRunContext().SetFailed(err)
+	}
+	if err := dep.NewAggregateError(panics...); err != nil {
+		panic(err)
+	}
+}
+
+// error returned by processor is retried by the retry policy, ProcessorError is returned once it is not retried any more
+func (pg *DefaultProcessorGroup) runProcessor(record *ProcessorRecord, instance LoopProcessor, scope ScopeContext) error {
+	policy := pg.getRetryPolicy(record.Type)
+	for attempt := 1; ; attempt++ {
+		SetVariable(scope, VAR_PROCESSOR_ATTEMPT, attempt)
+		err, stopped := pg.runAttempt(record, instance, scope)
+		if stopped != nil {
+			return stopped
+		}
+		if err == nil {
+			return nil
+		}
+		if !policy.shouldRetry(err, attempt) {
+			return &ProcessorError{Processor: record.Type, Attempts: attempt, Err: err}
+		}
+
+		backoff := policy.backoff(attempt)
+		pg.logger.Warnw("loop processor failed, retrying", "looper", pg.LooperName(), "processor", record.Type.FullName(), "attempt", attempt, "backoff", backoff, "error", err)
+		timer := pg.clock.NewTimer(backoff)
+		select {
+		case <-scope.Context().Done():
+			timer.Stop()
+			return scope.Context().Err()
+		case <-timer.C():
+		}
+	}
+}
+
+// processor runs inline if no deadline applies, it is expected to return once its context is cancelled.
+// otherwise it is abandoned once the deadline is exceeded, and panic of processor is raised again in the caller.
+// the abandoned run keeps its changes of variables to itself, see attemptScopeContext.
+// error returned by the processor is ignored if its context is done, as it is likely caused by cancellation
+func (pg *DefaultProcessorGroup) runAttempt(record *ProcessorRecord, instance LoopProcessor, scope ScopeContext) (err error, stopped error) {
+	ctx := scope.Context()
+	if timeout := pg.getProcessorTimeout(record.Type); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		scope = newDeadlineScopeContext(scope, ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// the processor is not run concurrently with the run abandoned before, as the instance may be shared by iterations
+	if !atomic.CompareAndSwapInt32(&record.running, 0, 1) {
+		return nil, ErrProcessorInFlight
+	}
+
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		defer atomic.StoreInt32(&record.running, 0)
+		err := runProcessorOnce(instance, scope)
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return err, nil
+	}
+
+	type result struct {
+		panicked bool
+		value    any
+		err      error
+	}
+	attempt := newAttemptScopeContext(scope)
+	done := make(chan result, 1)
+	go func() {
+		r := result{panicked: true}
+		defer func() {
+			if r.panicked {
+				r.value = recover()
+			}
+			// released before the result is sent, so that retry of the processor is not taken as in flight
+			atomic.StoreInt32(&record.running, 0)
+			done <- r
+		}()
+		r.err = runProcessorOnce(instance, attempt)
+		r.panicked = false
+	}()
+
+	select {
+	case r := <-done:
+		attempt.apply()
+		if r.panicked {
+			panic(r.value)
+		}
+		if r.err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return r.err, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 type DefaultLoopContext struct {
 	looper       *DefaultLooper
 	groupContext *DefaultGroupContext
@@ -221,6 +519,21 @@ func (lc *DefaultLoopContext) GetName() string {
 func (lc *DefaultLoopContext) SetInterval(interval time.Duration) {
 	lc.looper.timerInterval = interval
 }
+func (lc *DefaultLoopContext) SetIntervalFunc(getInterval func() time.Duration) {
+	lc.looper.getInterval = getInterval
+}
+func (lc *DefaultLoopContext) SetSchedule(schedule Schedule) {
+	lc.looper.schedule = schedule
+}
+func (lc *DefaultLoopContext) SetCron(expression string, location *time.Location) {
+	lc.looper.schedule = MustParseCronSchedule(expression, location)
+}
+func (lc *DefaultLoopContext) SetScheduleOptions(options ScheduleOptions) {
+	lc.looper.scheduleOptions = options
+}
+func (lc *DefaultLoopContext) SetMinInterval(interval time.Duration) {
+	lc.looper.minInterval = interval
+}
 func (lc *DefaultLoopContext) SetRecover(enabled bool) {
 	lc.looper.enableRecover = enabled
 }
@@ -233,6 +546,21 @@ func (lc *DefaultLoopContext) ConfigureLoopGlobalContext(initLoopContext LoopGlo
 func (lc *DefaultLoopContext) ConfigureScopeContext(initScopeContext ScopeContextInitMethod) {
 	lc.looper.processorGroup.SetScopeContextInitializer(initScopeContext)
 }
+func (lc *DefaultLoopContext) SetIterationTimeout(timeout time.Duration) {
+	lc.looper.processorGroup.SetRunTimeout(timeout)
+}
+func (lc *DefaultLoopContext) SetDefaultProcessorTimeout(timeout time.Duration) {
+	lc.looper.processorGroup.SetDefaultProcessorTimeout(timeout)
+}
+func (lc *DefaultLoopContext) SetProcessorTimeout(processorType types.DataType, timeout time.Duration) {
+	lc.looper.processorGroup.SetProcessorTimeout(processorType, timeout)
+}
+func (lc *DefaultLoopContext) SetDefaultRetryPolicy(policy RetryPolicy) {
+	lc.looper.processorGroup.SetDefaultRetryPolicy(policy)
+}
+func (lc *DefaultLoopContext) SetRetryPolicy(processorType types.DataType, policy RetryPolicy) {
+	lc.looper.processorGroup.SetRetryPolicy(processorType, policy)
+}
 
 func (lc *DefaultLoopContext) UseProcessor(processorType types.DataType) {
 	lc.UseConditionalProcessor(processorType, nil)
@@ -243,6 +571,9 @@ func (lc *DefaultLoopContext) UseConditionalProcessor(processorType types.DataTy
 func (lc *DefaultLoopContext) UseProcessorGroup(configureGroup ConfigureLoopGroupMethod, condition ConditionMethod) {
 	lc.groupContext.UseProcessorGroup(configureGroup, condition)
 }
+func (lc *DefaultLoopContext) UseParallelGroup(configureGroup ConfigureLoopGroupMethod, condition ConditionMethod, maxWorkers int) {
+	lc.groupContext.UseParallelGroup(configureGroup, condition, maxWorkers)
+}
 func (lc *DefaultLoopContext) UseFuncProcessor(procFunc dep.FreeStyleProcessorMethod) {
 	lc.groupContext.UseFuncProcessor(procFunc)
 }
@@ -261,8 +592,8 @@ func (gc *DefaultGroupContext) validateProcessorType(processorType types.DataTyp
 	if !processorType.IsInterface() {
 		panic(fmt.Errorf("specified processor type is not an interface: %s", processorType.FullName()))
 	}
-	if !processorType.CheckCompatible(types.Get[LoopProcessor]()) {
-		panic(fmt.Errorf("specified processor type does not implement LoopProcessor interface: %s", processorType.FullName()))
+	if !processorType.CheckCompatible(types.Get[LoopProcessor]()) && !processorType.CheckCompatible(types.Get[LoopProcessorE]()) {
+		panic(fmt.Errorf("specified processor type does not implement LoopProcessor interface nor LoopProcessorE interface: %s", processorType.FullName()))
 	}
 }
 
@@ -272,22 +603,46 @@ func (gc *DefaultGroupContext) SetGroupName(name string) {
 func (gc *DefaultGroupContext) ConfigureScopeContext(initScopeContext ScopeContextInitMethod) {
 	gc.group.SetScopeContextInitializer(initScopeContext)
 }
+func (gc *DefaultGroupContext) SetIterationTimeout(timeout time.Duration) {
+	gc.group.SetRunTimeout(timeout)
+}
+func (gc *DefaultGroupContext) SetDefaultProcessorTimeout(timeout time.Duration) {
+	gc.group.SetDefaultProcessorTimeout(timeout)
+}
+func (gc *DefaultGroupContext) SetProcessorTimeout(processorType types.DataType, timeout time.Duration) {
+	gc.group.SetProcessorTimeout(processorType, timeout)
+}
+func (gc *DefaultGroupContext) SetDefaultRetryPolicy(policy RetryPolicy) {
+	gc.group.SetDefaultRetryPolicy(policy)
+}
+func (gc *DefaultGroupContext) SetRetryPolicy(processorType types.DataType, policy RetryPolicy) {
+	gc.group.SetRetryPolicy(processorType, policy)
+}
 func (gc *DefaultGroupContext) UseProcessor(processorType types.DataType) {
 	gc.UseConditionalProcessor(processorType, nil)
 }
 func (gc *DefaultGroupContext) UseConditionalProcessor(processorType types.DataType, condition ConditionMethod) {
 	gc.validateProcessorType(processorType)
 	createInstance := func(context dep.Context, interfaceType types.DataType, props dep.Properties) LoopProcessor {
-		return context.GetComponent(interfaceType).(LoopProcessor)
+		return toLoopProcessor(context.GetComponent(interfaceType))
 	}
 	gc.group.AddProcessor(processorType, createInstance, condition)
 }
 func (gc *DefaultGroupContext) UseProcessorGroup(configureGroup ConfigureLoopGroupMethod, condition ConditionMethod) {
+	gc.useGroup(configureGroup, condition, false, 0)
+}
+func (gc *DefaultGroupContext) UseParallelGroup(configureGroup ConfigureLoopGroupMethod, condition ConditionMethod, maxWorkers int) {
+	gc.useGroup(configureGroup, condition, true, maxWorkers)
+}
+func (gc *DefaultGroupContext) useGroup(configureGroup ConfigureLoopGroupMethod, condition ConditionMethod, parallel bool, maxWorkers int) {
 	processorType := types.Of(new(ProcessorGroup))
 
 	createInstance := func(context dep.Context, interfaceType types.DataType, props dep.Properties) LoopProcessor {
 		instance := dep.GetComponent[ProcessorGroup](context)
 		instance.SetLooperContext(gc.group.GetLooperContext())
+		if parallel {
+			instance.SetParallel(maxWorkers)
+		}
 
 		groupContext := NewDefaultGroupContext(instance)
 		configureGroup(context, groupContext)
@@ -311,7 +666,13 @@ func (gc *DefaultGroupContext) UseFuncProcessor(procFunc dep.FreeStyleProcessorM
 type ProcessorRecord struct {
 	Type      types.DataType
 	Condition ConditionMethod
-	Instance  LoopProcessor
+	// nil for processor of parallel group
+	Instance LoopProcessor
+
+	createInstance CreateProcessorMethod
+
+	// non-zero while the instance is running, including the run abandoned by timeout
+	running int32
 }
 
 type LooperContext interface {
@@ -323,9 +684,17 @@ type DefaultLooper struct {
 	name    string
 	logger  logger.Logger
 	runner  *LoopRunner
+	clock   clock.Clock
+	// cancelled once the looper is stopped
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	// settings
 	timerInterval   time.Duration
+	getInterval     func() time.Duration
+	minInterval     time.Duration
+	schedule        Schedule
+	scheduleOptions ScheduleOptions
 	enableRecover   bool
 	initLoopContext LoopGlobalContextInitMethod
 
@@ -339,18 +708,24 @@ func NewDefaultLooper(context ServiceContext) *DefaultLooper {
 	return lp
 }
 
-const minLoopInterval = 500 * time.Millisecond
+const DefaultMinLoopInterval = 500 * time.Millisecond
 const maxStopInterval = 500 * time.Millisecond
 
 func (lp *DefaultLooper) Initialize(settings *LooperSettings) {
 	lp.name = settings.Name
 	lp.timerInterval = settings.Interval
+	lp.minInterval = settings.MinInterval
+	if lp.minInterval == 0 {
+		lp.minInterval = DefaultMinLoopInterval
+	}
 	lp.enableRecover = settings.Recover
 
 	lp.logger = lp.context.GetLoggerWithName(lp.getLoggerName())
 	lp.logger.Debugw("initializing Looper", "name", lp.name)
+	lp.ctx, lp.cancel = context.WithCancel(context.Background())
 
 	lp.processorGroup = dep.GetComponent[ProcessorGroup](lp.context)
+	lp.clock = dep.GetComponent[clock.Clock](lp.context)
 
 	loopContext := NewDefaultLoopContext(lp)
 	lp.processorGroup.SetLooperContext(loopContext)
@@ -358,8 +733,9 @@ func (lp *DefaultLooper) Initialize(settings *LooperSettings) {
 
 	lp.runner = NewLoopRunner(LoopRunnerSettings{
 		EnableRecover:   lp.enableRecover,
-		MinLoopInterval: minLoopInterval,
+		MinLoopInterval: lp.minInterval,
 		MaxStopInterval: maxStopInterval,
+		Clock:           lp.clock,
 	})
 	lp.runner.Initialize(func() any {
 		// initialize looper context before loop start
@@ -390,22 +766,40 @@ func (lp *DefaultLooper) getLoggerName() string {
 func (lp *DefaultLooper) Run() {
 	lp.logger.Debugw("Looper started to run", "name", lp.Name())
 
-	lp.runner.Run(lp.timerInterval, func(ctxt any) {
+	if lp.schedule != nil {
+		lp.runner.RunSchedule(lp.schedule, lp.scheduleOptions, func(ctxt any) {
+			loopContext := ctxt.(LoopGlobalContext)
+			lp.runIteration(loopContext)
+		})
+		return
+	}
+
+	getInterval := lp.getInterval
+	if getInterval == nil {
+		getInterval = func() time.Duration { return lp.timerInterval }
+	}
+	lp.runner.RunEx(getInterval, func(ctxt any) {
 		loopContext := ctxt.(LoopGlobalContext)
 		lp.runIteration(loopContext)
 	})
 }
 func (lp *DefaultLooper) runIteration(loopContext LoopGlobalContext) {
 	lp.logger.Debugw("Looper start new iteration", "Name", lp.Name())
-	start := time.Now()
-	lp.processorGroup.RunNewIteration(loopContext)
-	cost := float64(time.Since(start).Milliseconds())
+	start := lp.clock.Now()
+	err := lp.processorGroup.RunNewIteration(loopContext)
+	cost := float64(lp.clock.Since(start).Milliseconds())
+	if err != nil {
+		lp.logger.Warnw("Looper iteration failed", "Name", lp.Name(), "Cost(ms)", cost, "error", err)
+		return
+	}
 	lp.logger.Debugw("Looper completed one iteration", "Name", lp.Name(), "Cost(ms)", cost)
 }
 
 func (lp *DefaultLooper) Stop(ctx context.Context) error {
 	lp.logger.Debugw("shutting down Looper", "name", lp.Name())
 
+	// processors running are cancelled, so that the loop is not blocked
+	lp.cancel()
 	return lp.runner.Stop(ctx)
 }
 
@@ -416,5 +810,6 @@ func createLooper(depCtxt dep.Context, interfaceType types.DataType, props dep.P
 	ctxtProvider := dep.GetComponent[dep.ContextualProvider](dependent)
 	serviceCtxt := NewLoopContext(scopeCtxt, ctxtProvider, interfaceType)
 	dep.TrackDependent(serviceCtxt, dependent)
+	dep.InheritResolution(serviceCtxt, dependent)
 	return NewDefaultLooper(serviceCtxt)
 }
@@ -1,10 +1,16 @@
 package hosting
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/clock"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
@@ -838,3 +844,489 @@ func Test_looper_with_scope(t *testing.T) {
 		}
 	}
 }
+
+func Test_looper_fake_clock(t *testing.T) {
+	fakeClock := test.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterInstance[clock.Clock](components, fakeClock)
+	})
+
+	iterations := make(chan int32, 10)
+	var count int32
+	builder.UseLoop("Test", func(context ServiceContext, looper ConfigureLoopContext) {
+		looper.SetInterval(time.Hour)
+		looper.UseFuncProcessor(func(ctxt ScopeContext) {
+			iterations <- atomic.AddInt32(&count, 1)
+		})
+	})
+
+	host := builder.Build()
+	runner := dep.GetComponent[AsyncAppRunner](host.GetComponentProvider())
+	done := make(chan struct{})
+	go func() {
+		host.Run()
+		close(done)
+	}()
+
+	// iterations are triggered by advancing the clock instead of waiting for an hour
+	receiveIteration(t, iterations, 1)
+	for i := int32(2); i <= 3; i++ {
+		fakeClock.Advance(time.Hour)
+		receiveIteration(t, iterations, i)
+		fakeClock.WaitForTimers(1)
+	}
+
+	runner.SendStopSignal()
+	<-done
+	if actual := atomic.LoadInt32(&count); actual != 3 {
+		t.Errorf("looper should run once per advanced interval, actual iterations: %d", actual)
+	}
+}
+
+func Test_looper_min_interval(t *testing.T) {
+	fakeClock := test.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterInstance[clock.Clock](components, fakeClock)
+	})
+
+	iterations := make(chan int32, 10)
+	var count int32
+	builder.UseLoop("Test", func(context ServiceContext, looper ConfigureLoopContext) {
+		looper.SetInterval(0)
+		looper.SetMinInterval(time.Minute)
+		looper.UseFuncProcessor(func(ctxt ScopeContext) {
+			iterations <- atomic.AddInt32(&count, 1)
+		})
+	})
+
+	host := builder.Build()
+	runner := dep.GetComponent[AsyncAppRunner](host.GetComponentProvider())
+	done := make(chan struct{})
+	go func() {
+		host.Run()
+		close(done)
+	}()
+
+	// the initial timer of zero interval fires right away, the loop waits for the min interval after that
+	receiveIteration(t, iterations, 1)
+	receiveIteration(t, iterations, 2)
+	fakeClock.WaitForTimers(1)
+
+	fakeClock.Advance(time.Minute - time.Second)
+	select {
+	case actual := <-iterations:
+		t.Errorf("iteration should wait for the min interval, actual iteration: %d", actual)
+	case <-time.After(100 * time.Millisecond):
+	}
+	fakeClock.Advance(time.Second)
+	receiveIteration(t, iterations, 3)
+
+	runner.SendStopSignal()
+	<-done
+}
+
+type SlowProc interface {
+	LoopProcessor
+}
+
+// blocks until the context of scope is done
+type SlowProcessor struct {
+	errors chan error
+}
+
+func (sp *SlowProcessor) Run(ctxt ScopeContext) {
+	<-ctxt.Context().Done()
+	sp.errors <- ctxt.Context().Err()
+}
+
+func receiveError(t *testing.T, errs chan error) error {
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatalf("error should be received")
+		return nil
+	}
+}
+
+// start looper running one iteration, return function to stop the host
+func startSlowLooper(t *testing.T, configure ConfigureLoopMethod) (slow *SlowProcessor, runCtxt *LoopRunContext, stop func()) {
+	slow = &SlowProcessor{errors: make(chan error, 10)}
+	runCtxt = new(LoopRunContext)
+
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterInstance[SlowProc](components, slow)
+	})
+	builder.UseLoop("Slow", func(context ServiceContext, looper ConfigureLoopContext) {
+		looper.SetInterval(time.Hour)
+		looper.ConfigureScopeContext(func(ctxt ScopeContext) {
+			*runCtxt = ctxt.GetLoopRunContext()
+		})
+		configure(context, looper)
+	})
+
+	host := builder.Build()
+	done := make(chan struct{})
+	go func() {
+		host.Run()
+		close(done)
+	}()
+	stop = func() {
+		dep.GetComponent[AsyncAppRunner](host.GetComponentProvider()).SendStopSignal()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("host should be stopped")
+		}
+	}
+	return slow, runCtxt, stop
+}
+
+func Test_looper_processor_timeout(t *testing.T) {
+	groupErrors := make(chan error, 1)
+	slow, _, stop := startSlowLooper(t, func(context ServiceContext, looper ConfigureLoopContext) {
+		looper.UseProcessorGroup(func(context dep.Context, group GroupContext) {
+			group.SetDefaultProcessorTimeout(time.Hour)
+			SetProcessorTimeout[SlowProc](group, 20*time.Millisecond)
+			UseProcessor[SlowProc](group, nil)
+			group.UseFuncProcessor(func() {
+				t.Errorf("processors after the one timed out should be skipped")
+			})
+		}, nil)
+		// parent group continues after the group is skipped
+		looper.UseFuncProcessor(func(scope ScopeContext) {
+			groupErrors <- scope.GetLoopRunContext().GetError()
+		})
+	})
+	defer stop()
+
+	if err := receiveError(t, slow.errors); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("context of processor should be timed out, actual: %v", err)
+	}
+	err := receiveError(t, groupErrors)
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "SlowProc timed out") {
+		t.Errorf("iteration should be marked as failed with the processor type, actual: %v", err)
+	}
+}
+
+type FakeLooperContext struct{}
+
+func (lc *FakeLooperContext) GetName() string { return "TestLoop" }
+
+// ignores cancellation, as a processor which is not aware of its context
+type BlockingProcessor struct {
+	runs    int32
+	release chan struct{}
+}
+
+func (bp *BlockingProcessor) Run(ctxt ScopeContext) {
+	atomic.AddInt32(&bp.runs, 1)
+	<-bp.release
+}
+
+func Test_processor_group_in_flight(t *testing.T) {
+	blocking := &BlockingProcessor{release: make(chan struct{})}
+	host := NewDefaultHostBuilder().Build().(*DefaultGenericHost)
+	group := NewDefaultProcessorGroup(host.hostContext)
+	group.SetLooperContext(&FakeLooperContext{})
+	group.SetDefaultProcessorTimeout(10 * time.Millisecond)
+	group.Initialize()
+	group.AddProcessor(types.Of(blocking), func(dep.Context, types.DataType, dep.Properties) LoopProcessor { return blocking }, nil)
+	runIteration := func() error {
+		return group.RunNewIteration(NewLoopGlobalContext(&DefaultLooper{ctx: context.Background()}))
+	}
+
+	if err := runIteration(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("iteration should time out, actual: %v", err)
+	}
+	// the instance is not run concurrently with the abandoned run
+	if err := runIteration(); !errors.Is(err, ErrProcessorInFlight) || atomic.LoadInt32(&blocking.runs) != 1 {
+		t.Errorf("processor should be skipped while its previous run is in flight, runs: %d, actual: %v", blocking.runs, err)
+	}
+
+	close(blocking.release)
+	for i := 0; i < 100 && atomic.LoadInt32(&group.processors[0].running) != 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if err := runIteration(); err != nil || atomic.LoadInt32(&blocking.runs) != 2 {
+		t.Errorf("processor should run again once the abandoned run completes, runs: %d, actual: %v", blocking.runs, err)
+	}
+}
+
+// ignores cancellation, and writes variables once it is released after timed out
+type LateWriterProcessor struct {
+	release chan struct{}
+	done    chan struct{}
+}
+
+func (lp *LateWriterProcessor) Run(ctxt ScopeContext) {
+	defer close(lp.done)
+	<-lp.release
+	for i := 0; i < 100; i++ {
+		ctxt.SetVariable("late", i)
+		_ = ctxt.GetVariable("shared")
+	}
+}
+
+func Test_processor_timed_out_writes_variables(t *testing.T) {
+	late := &LateWriterProcessor{release: make(chan struct{}), done: make(chan struct{})}
+	host := NewDefaultHostBuilder().Build().(*DefaultGenericHost)
+	group := NewDefaultProcessorGroup(host.hostContext)
+	group.SetLooperContext(&FakeLooperContext{})
+	group.SetDefaultProcessorTimeout(10 * time.Millisecond)
+	group.Initialize()
+	group.AddProcessor(types.Of(late), func(dep.Context, types.DataType, dep.Properties) LoopProcessor { return late }, nil)
+
+	runCtxt := NewLoopRunContext(NewLoopGlobalContext(&DefaultLooper{ctx: context.Background()}))
+	runCtxt.SetVariable("shared", 0)
+	group.runWithContext(runCtxt)
+	if err := runCtxt.GetError(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("iteration should time out, actual: %v", err)
+	}
+
+	// the loop goes on with the variables while the abandoned run writes its own
+	close(late.release)
+	for i := 0; i < 100; i++ {
+		runCtxt.SetVariable("shared", i)
+		_ = runCtxt.HasVariable("late", true)
+	}
+	<-late.done
+	if runCtxt.HasVariable("late", true) {
+		t.Errorf("variables set by the processor abandoned by timeout should not be visible to the loop")
+	}
+}
+
+func Test_looper_iteration_timeout(t *testing.T) {
+	slow, runCtxt, stop := startSlowLooper(t, func(context ServiceContext, looper ConfigureLoopContext) {
+		looper.SetIterationTimeout(20 * time.Millisecond)
+		UseProcessor[SlowProc](looper, nil)
+		looper.UseFuncProcessor(func() {
+			t.Errorf("processors after iteration timed out should be skipped")
+		})
+	})
+
+	if err := receiveError(t, slow.errors); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("context of iteration should be timed out, actual: %v", err)
+	}
+	stop()
+	if err := (*runCtxt).GetError(); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("iteration should be marked as failed, actual: %v", err)
+	}
+}
+
+func Test_looper_stop_cancels_processor(t *testing.T) {
+	slow, runCtxt, stop := startSlowLooper(t, func(context ServiceContext, looper ConfigureLoopContext) {
+		UseProcessor[SlowProc](looper, nil)
+	})
+
+	time.Sleep(20 * time.Millisecond)
+	// stop is not blocked by the processor without timeout
+	stop()
+	if err := receiveError(t, slow.errors); !errors.Is(err, context.Canceled) {
+		t.Errorf("context of processor should be cancelled once looper is stopped, actual: %v", err)
+	}
+	if err := (*runCtxt).GetError(); err != nil {
+		t.Errorf("iteration cancelled by stop should not be failed, actual: %v", err)
+	}
+}
+
+func Test_looper_parallel_group(t *testing.T) {
+	builder := NewDefaultHostBuilder()
+
+	results := make(chan string, 10)
+	var running, maxRunning, completed int32
+	branch := func(name string) func(ScopeContext) {
+		return func(ctxt ScopeContext) {
+			current := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+
+			// variables of parent scope are visible, variables of branch are not shared
+			if ctxt.HasVariable("branch", false) {
+				t.Errorf("variable of other branches should not be visible")
+			}
+			ctxt.SetVariable("branch", name)
+			results <- GetVariable[string](ctxt, "shared") + ":" + name
+			atomic.AddInt32(&completed, 1)
+		}
+	}
+
+	builder.UseLoop("Parallel", func(context ServiceContext, looper ConfigureLoopContext) {
+		looper.SetInterval(time.Hour)
+		looper.UseParallelGroup(func(context dep.Context, group GroupContext) {
+			group.ConfigureScopeContext(func(ctxt ScopeContext) {
+				ctxt.SetVariable("shared", "group")
+			})
+			for _, name := range []string{"a", "b", "c", "d"} {
+				group.UseFuncProcessor(branch(name))
+			}
+		}, nil, 2)
+		// run after all processors of the parallel group complete
+		looper.UseFuncProcessor(func(ctxt ScopeContext) {
+			if ctxt.HasVariable("branch", false) {
+				t.Errorf("variable of branches should not be visible after join")
+			}
+			results <- fmt.Sprintf("joined:%d", atomic.LoadInt32(&completed))
+		})
+	})
+
+	host := builder.Build()
+	done := make(chan struct{})
+	go func() {
+		host.Run()
+		close(done)
+	}()
+
+	actual := make([]string, 0)
+	for len(actual) < 5 {
+		select {
+		case result := <-results:
+			actual = append(actual, result)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("parallel group should complete, actual: %v", actual)
+		}
+	}
+	dep.GetComponent[AsyncAppRunner](host.GetComponentProvider()).SendStopSignal()
+	<-done
+
+	if actual[4] != "joined:4" {
+		t.Errorf("processor after parallel group should wait for all branches, actual: %v", actual)
+	}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		if !strings.Contains(strings.Join(actual, ","), "group:"+name) {
+			t.Errorf("branch %s should run with variables of parent scope, actual: %v", name, actual)
+		}
+	}
+	if max := atomic.LoadInt32(&maxRunning); max != 2 {
+		t.Errorf("branches should run concurrently by max workers, actual: %d", max)
+	}
+}
+
+type ActionProcessor func(ctxt ScopeContext)
+
+func (ap ActionProcessor) Run(ctxt ScopeContext) { ap(ctxt) }
+
+func newParallelGroup(maxWorkers int, actions ...ActionProcessor) *DefaultProcessorGroup {
+	host := NewDefaultHostBuilder().Build().(*DefaultGenericHost)
+	group := NewDefaultProcessorGroup(host.hostContext)
+	group.SetLooperContext(&FakeLooperContext{})
+	group.SetParallel(maxWorkers)
+	group.Initialize()
+	for _, action := range actions {
+		processor := action
+		group.AddProcessor(types.Get[ActionProcessor](), func(dep.Context, types.DataType, dep.Properties) LoopProcessor { return processor }, nil)
+	}
+	return group
+}
+
+type BranchState interface {
+	Use()
+}
+type branchState struct {
+	used     int32
+	disposed int32
+}
+
+func (bs *branchState) Use() { atomic.AddInt32(&bs.used, 1) }
+func (bs *branchState) Dispose() error {
+	atomic.AddInt32(&bs.disposed, 1)
+	return nil
+}
+
+func Test_looper_parallel_group_branch_scope(t *testing.T) {
+	var mutex sync.Mutex
+	states := make([]*branchState, 0)
+	builder := NewDefaultHostBuilder()
+	builder.ConfigureComponents(func(context BuilderContext, components dep.ComponentCollection) {
+		dep.RegisterScoped[BranchState, any](components, func() *branchState {
+			mutex.Lock()
+			defer mutex.Unlock()
+			state := &branchState{}
+			states = append(states, state)
+			return state
+		})
+	})
+	host := builder.Build().(*DefaultGenericHost)
+	group := NewDefaultProcessorGroup(host.hostContext)
+	group.SetLooperContext(&FakeLooperContext{})
+	group.SetParallel(0)
+	group.Initialize()
+	groupContext := NewDefaultGroupContext(group)
+	for i := 0; i < 2; i++ {
+		groupContext.UseFuncProcessor(func(state BranchState) { state.Use() })
+	}
+
+	group.Run(NewFakeScopeContext())
+	group.Run(NewFakeScopeContext())
+
+	// each branch of each run resolves the scoped component from its own scope, disposed once the branch completes
+	if len(states) != 4 {
+		t.Fatalf("scoped component should be created for each branch, actual: %d", len(states))
+	}
+	for _, state := range states {
+		if state.used != 1 || state.disposed != 1 {
+			t.Errorf("scoped component should be used by one branch and disposed with its scope, used: %d, disposed: %d", state.used, state.disposed)
+		}
+	}
+}
+
+func Test_looper_parallel_group_exit_scope(t *testing.T) {
+	var runs int32
+	group := newParallelGroup(1,
+		func(ctxt ScopeContext) { atomic.AddInt32(&runs, 1) },
+		func(ctxt ScopeContext) {
+			atomic.AddInt32(&runs, 1)
+			ctxt.ExitScope(Current)
+		},
+		func(ctxt ScopeContext) { atomic.AddInt32(&runs, 1) },
+	)
+
+	parent := NewFakeScopeContext()
+	group.Run(parent)
+	if actual := atomic.LoadInt32(&runs); actual != 2 {
+		t.Errorf("processors should not be started once a branch exits its scope, actual runs: %d", actual)
+	}
+	if parent.IsExit() {
+		t.Errorf("exiting current scope of a branch should not exit the parent scope")
+	}
+}
+
+func Test_looper_parallel_group_panics(t *testing.T) {
+	defer test.AssertPanicContent(t, "2 errors occurred", "panics of branches should be aggregated")
+
+	started := make(chan struct{})
+	var completed int32
+	group := newParallelGroup(0,
+		func(ctxt ScopeContext) {
+			<-started
+			panic("first")
+		},
+		func(ctxt ScopeContext) {
+			<-started
+			panic("second")
+		},
+		func(ctxt ScopeContext) {
+			close(started)
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&completed, 1)
+		},
+	)
+	defer func() {
+		if atomic.LoadInt32(&completed) != 1 {
+			t.Errorf("panic should be raised after all started branches complete")
+		}
+	}()
+
+	group.Run(NewFakeScopeContext())
+}
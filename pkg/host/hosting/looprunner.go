@@ -4,34 +4,46 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync/atomic"
 	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/clock"
 )
 
 type LoopRunnerSettings struct {
 	EnableRecover   bool
 	MinLoopInterval time.Duration
 	MaxStopInterval time.Duration
+	// time source of loop iterations, system clock if nil
+	Clock clock.Clock
 }
 type LoopRunner struct {
 	settings   LoopRunnerSettings
+	clock      clock.Clock
 	ctxtInitor func() any
 
-	Done    chan bool
-	stopped bool
+	Done chan bool
+	// set to 1 by the loop goroutine once it stops, read by Stop on other goroutines
+	stopped int32
 }
 
 func NewLoopRunner(settings LoopRunnerSettings) *LoopRunner {
 	return &LoopRunner{
 		Done:     make(chan bool, 1),
-		stopped:  false,
 		settings: settings,
+		clock:    clock.OrSystem(settings.Clock),
 	}
 }
 func (lr *LoopRunner) Initialize(ctxtInitor func() any) {
 	lr.ctxtInitor = ctxtInitor
 }
 func (lr *LoopRunner) Run(interval time.Duration, loopAction func(any)) {
-	timer := time.NewTimer(interval)
+	lr.RunEx(func() time.Duration { return interval }, loopAction)
+}
+
+// interval is evaluated after each iteration, changes take effect on the next iteration
+func (lr *LoopRunner) RunEx(getInterval func() time.Duration, loopAction func(any)) {
+	timer := lr.clock.NewTimer(getInterval())
 	defer timer.Stop()
 
 	var context any
@@ -41,7 +53,7 @@ func (lr *LoopRunner) Run(interval time.Duration, loopAction func(any)) {
 
 	for {
 		func() {
-			start := time.Now()
+			start := lr.clock.Now()
 			defer func() {
 				if lr.settings.EnableRecover {
 					if r := recover(); r != nil {
@@ -49,8 +61,8 @@ func (lr *LoopRunner) Run(interval time.Duration, loopAction func(any)) {
 					}
 				}
 
-				eclipse := time.Since(start)
-				actual := interval - eclipse
+				eclipse := lr.clock.Since(start)
+				actual := getInterval() - eclipse
 				if actual < lr.settings.MinLoopInterval {
 					actual = lr.settings.MinLoopInterval
 				}
@@ -62,19 +74,126 @@ func (lr *LoopRunner) Run(interval time.Duration, loopAction func(any)) {
 
 		select {
 		case <-lr.Done:
-			lr.stopped = true
+			lr.markStopped()
 			return
-		case <-timer.C:
+		case <-timer.C():
 			continue
 		}
 	}
 }
 
+// wait for fire time is split into steps, so that wall clock is checked again after the process is resumed from suspension
+const maxScheduleWait = time.Minute
+
+// maximum of missed fire times run by MissedRunPolicy_RunAll
+const maxMissedRuns = 100
+
+// loop action is run at fire times computed by the schedule
+func (lr *LoopRunner) RunSchedule(schedule Schedule, options ScheduleOptions, loopAction func(any)) {
+	var context any
+	if lr.ctxtInitor != nil {
+		context = lr.ctxtInitor()
+	}
+
+	next := schedule.Next(lr.wallClockNow())
+	for {
+		if !lr.waitUntil(next) {
+			return
+		}
+
+		// fire times passed since the scheduled one, e.g. process was suspended or previous iteration was too long
+		now := lr.wallClockNow()
+		missed := 0
+		following := schedule.Next(next)
+		for !following.IsZero() && !following.After(now) && missed < maxMissedRuns {
+			missed++
+			following = schedule.Next(following)
+		}
+
+		runs := 1
+		switch {
+		case missed == 0:
+		case options.MissedRuns == MissedRunPolicy_Skip:
+			runs = 0
+		case options.MissedRuns == MissedRunPolicy_RunAll:
+			runs += missed
+		}
+		for i := 0; i < runs; i++ {
+			lr.runAction(context, loopAction)
+		}
+
+		if options.Mode == ScheduleMode_FixedDelay && runs > 0 {
+			next = schedule.Next(lr.wallClockNow())
+		} else {
+			next = following
+		}
+	}
+}
+
+// return false if the runner is stopped before the time, wait forever if the time is zero
+func (lr *LoopRunner) waitUntil(fireTime time.Time) bool {
+	timer := lr.clock.NewTimer(maxScheduleWait)
+	defer timer.Stop()
+	for {
+		// schedule computes from time without monotonic clock reading, so wall clock is compared
+		wait := maxScheduleWait
+		if !fireTime.IsZero() {
+			if wait = lr.clock.Until(fireTime); wait <= 0 {
+				// iterations may be always behind the schedule, stop is checked before running
+				select {
+				case <-lr.Done:
+					lr.markStopped()
+					return false
+				default:
+					return true
+				}
+			}
+			if wait > maxScheduleWait {
+				wait = maxScheduleWait
+			}
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-lr.Done:
+			lr.markStopped()
+			return false
+		case <-timer.C():
+		}
+	}
+}
+
+// monotonic clock stops while the process is suspended, so it is stripped to detect missed fire times by wall clock
+func (lr *LoopRunner) wallClockNow() time.Time {
+	return lr.clock.Now().Round(0)
+}
+
+func (lr *LoopRunner) runAction(context any, loopAction func(any)) {
+	defer func() {
+		if lr.settings.EnableRecover {
+			if r := recover(); r != nil {
+				fmt.Printf("panic from loop action: %v\n", r)
+			}
+		}
+	}()
+	loopAction(context)
+}
+
+func (lr *LoopRunner) markStopped() {
+	atomic.StoreInt32(&lr.stopped, 1)
+}
+
 func (lr *LoopRunner) Stop(ctx context.Context) error {
 	// stop the loop
 	lr.Done <- true
 
-	// wait for loop to stop
+	// wait for loop to stop, polling is on real time since the loop may be driven by a fake clock
 	pollIntervalBase := time.Millisecond
 	nextPollInterval := func() time.Duration {
 		// Add 10% jitter.
@@ -90,7 +209,7 @@ func (lr *LoopRunner) Stop(ctx context.Context) error {
 	timer := time.NewTimer(nextPollInterval())
 	defer timer.Stop()
 	for {
-		if lr.stopped {
+		if atomic.LoadInt32(&lr.stopped) != 0 {
 			return nil
 		}
 		select {
@@ -2,8 +2,11 @@ package hosting
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
 )
 
 func Test_looprunner_basic(t *testing.T) {
@@ -16,13 +19,13 @@ func Test_looprunner_basic(t *testing.T) {
 	runner.Initialize(func() any { return value })
 
 	base := 456
-	result := 0
+	var result int32
 	go runner.Run(time.Duration(1)*time.Second, func(ctxt any) {
-		result = base + ctxt.(int)
+		atomic.StoreInt32(&result, int32(base+ctxt.(int)))
 	})
 
 	time.Sleep(time.Duration(500) * time.Millisecond)
-	actual := result
+	actual := int(atomic.LoadInt32(&result))
 	if actual < base+value {
 		t.Errorf("loop should execute at least one iteration, actual: %d", actual)
 	}
@@ -44,13 +47,13 @@ func Test_looprunner_stop_timeout(t *testing.T) {
 	runner.Initialize(func() any { return value })
 
 	base := 456
-	result := 0
+	var result int32
 	go runner.Run(time.Duration(1)*time.Second, func(ctxt any) {
-		result = base + ctxt.(int)
+		atomic.StoreInt32(&result, int32(base+ctxt.(int)))
 	})
 
 	time.Sleep(time.Duration(500) * time.Millisecond)
-	actual := result
+	actual := int(atomic.LoadInt32(&result))
 	if actual < base+value {
 		t.Errorf("loop should execute at least one iteration, actual: %d", actual)
 	}
@@ -69,3 +72,132 @@ func Test_looprunner_stop_timeout(t *testing.T) {
 		t.Error("stop runner didn't reach timeout error, not expected")
 	}
 }
+
+func runWithIntervalChange(t *testing.T, initial time.Duration, changed time.Duration) int32 {
+	runner := NewLoopRunner(LoopRunnerSettings{
+		EnableRecover:   true,
+		MinLoopInterval: time.Millisecond,
+		MaxStopInterval: 500 * time.Millisecond,
+	})
+
+	interval := int64(initial)
+	var count int32
+	go runner.RunEx(func() time.Duration { return time.Duration(atomic.LoadInt64(&interval)) }, func(ctxt any) {
+		if atomic.AddInt32(&count, 1) == 1 {
+			atomic.StoreInt64(&interval, int64(changed))
+		}
+	})
+
+	time.Sleep(100 * time.Millisecond)
+	if err := runner.Stop(context.Background()); err != nil {
+		t.Errorf("stop runner error: %v", err)
+	}
+	return atomic.LoadInt32(&count)
+}
+
+func Test_looprunner_interval_func(t *testing.T) {
+	// interval changed by the first iteration is used for the next iteration
+	if count := runWithIntervalChange(t, time.Hour, 10*time.Millisecond); count < 3 {
+		t.Errorf("shortened interval should take effect on next iteration, actual iterations: %d", count)
+	}
+	if count := runWithIntervalChange(t, 10*time.Millisecond, time.Hour); count != 1 {
+		t.Errorf("extended interval should take effect on next iteration, actual iterations: %d", count)
+	}
+}
+
+func receiveIteration(t *testing.T, iterations chan int32, expected int32) {
+	select {
+	case actual := <-iterations:
+		if actual != expected {
+			t.Fatalf("iteration %d is expected, actual: %d", expected, actual)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("iteration %d should be triggered", expected)
+	}
+}
+
+func Test_looprunner_fake_clock(t *testing.T) {
+	clock := test.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	runner := NewLoopRunner(LoopRunnerSettings{
+		EnableRecover:   true,
+		MinLoopInterval: 500 * time.Millisecond,
+		MaxStopInterval: 10 * time.Millisecond,
+		Clock:           clock,
+	})
+
+	interval := int64(time.Second)
+	iterations := make(chan int32, 10)
+	var count int32
+	go runner.RunEx(func() time.Duration { return time.Duration(atomic.LoadInt64(&interval)) }, func(ctxt any) {
+		current := atomic.AddInt32(&count, 1)
+		if current == 3 {
+			atomic.StoreInt64(&interval, int64(100*time.Millisecond))
+		}
+		iterations <- current
+	})
+
+	// first iteration runs immediately
+	receiveIteration(t, iterations, 1)
+	clock.Advance(time.Second)
+	receiveIteration(t, iterations, 2)
+
+	// the fired timer is armed again once the iteration completes
+	clock.WaitForTimers(1)
+	clock.Advance(999 * time.Millisecond)
+	if clock.PendingTimers() != 1 {
+		t.Errorf("iteration should not be triggered before interval")
+	}
+	clock.Advance(time.Millisecond)
+	receiveIteration(t, iterations, 3)
+
+	// interval shorter than minimum loop interval is extended
+	clock.WaitForTimers(1)
+	clock.Advance(100 * time.Millisecond)
+	if clock.PendingTimers() != 1 {
+		t.Errorf("iteration should not be triggered before minimum loop interval")
+	}
+	clock.Advance(400 * time.Millisecond)
+	receiveIteration(t, iterations, 4)
+
+	if err := runner.Stop(context.Background()); err != nil {
+		t.Errorf("stop runner error: %v", err)
+	}
+	if len(iterations) != 0 {
+		t.Errorf("no more iteration is expected, actual: %d", atomic.LoadInt32(&count))
+	}
+}
+
+func Test_looprunner_fake_clock_schedule(t *testing.T) {
+	clock := test.NewFakeClock(time.Date(2024, 1, 1, 1, 59, 0, 0, time.UTC))
+	runner := NewLoopRunner(LoopRunnerSettings{EnableRecover: true, MaxStopInterval: 10 * time.Millisecond, Clock: clock})
+
+	iterations := make(chan int32, 10)
+	var count int32
+	go runner.RunSchedule(MustParseCronSchedule("0 0 2 * * *", time.UTC), ScheduleOptions{}, func(ctxt any) {
+		iterations <- atomic.AddInt32(&count, 1)
+	})
+
+	clock.WaitForTimers(1)
+	clock.Advance(59 * time.Second)
+	if clock.PendingTimers() != 1 || len(iterations) != 0 {
+		t.Errorf("schedule should not fire before its fire time")
+	}
+	clock.Advance(time.Second)
+	receiveIteration(t, iterations, 1)
+
+	// waiting for the next day is split into steps of maximum schedule wait
+	clock.WaitForTimers(1)
+	for i := 0; i < 24*60-1; i++ {
+		clock.Advance(time.Minute)
+		clock.WaitForTimers(1)
+	}
+	if len(iterations) != 0 {
+		t.Errorf("schedule should fire once a day")
+	}
+	clock.Advance(time.Minute)
+	receiveIteration(t, iterations, 2)
+
+	if err := runner.Stop(context.Background()); err != nil {
+		t.Errorf("stop runner error: %v", err)
+	}
+}
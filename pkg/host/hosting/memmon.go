@@ -6,6 +6,8 @@ import (
 	"runtime"
 	"sync"
 	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/clock"
 )
 
 type MemoryMonitor interface {
@@ -17,19 +19,24 @@ type DefaultMemoryMonitor struct {
 	Interval time.Duration
 
 	runner *LoopRunner
+	clock  clock.Clock
 }
 
 const memmon_MinLoopInterval = 500 * time.Millisecond
 const memmon_MaxStopInterval = 500 * time.Millisecond
 
-func newMemoryMonitor() *DefaultMemoryMonitor {
+// system clock is used if clk is nil
+func NewMemoryMonitor(clk clock.Clock) *DefaultMemoryMonitor {
+	clk = clock.OrSystem(clk)
 	return &DefaultMemoryMonitor{
 		Interval: time.Duration(5) * time.Second,
 		runner: NewLoopRunner(LoopRunnerSettings{
 			EnableRecover:   true,
 			MinLoopInterval: memmon_MinLoopInterval,
 			MaxStopInterval: memmon_MaxStopInterval,
+			Clock:           clk,
 		}),
+		clock: clk,
 	}
 }
 
@@ -47,7 +54,7 @@ func (mm *DefaultMemoryMonitor) Stop() {
 func (mm *DefaultMemoryMonitor) printMemoryStatistics() {
 	var ms runtime.MemStats
 	runtime.ReadMemStats(&ms)
-	fmt.Printf("Memory Statistics: time - %v, liveobjs - %d, heapusage - %d, osusage - %d\n", time.Now(), ms.Mallocs-ms.Frees, ms.Alloc, ms.Sys)
+	fmt.Printf("Memory Statistics: time - %v, liveobjs - %d, heapusage - %d, osusage - %d\n", mm.clock.Now(), ms.Mallocs-ms.Frees, ms.Alloc, ms.Sys)
 }
 
 // APIs
@@ -56,7 +63,7 @@ var once sync.Once
 
 func GetMemoryMonitor() MemoryMonitor {
 	once.Do(func() {
-		singleton = newMemoryMonitor()
+		singleton = NewMemoryMonitor(nil)
 	})
 	return singleton
 }
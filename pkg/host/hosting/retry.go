@@ -0,0 +1,105 @@
+package hosting
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+// processor returning error, e.g. func processor returning error, which can be retried by RetryPolicy
+type LoopProcessorE interface {
+	RunE(ctxt ScopeContext) error
+}
+
+// scope variable of the attempt of the processor running, starting from 1
+const VAR_PROCESSOR_ATTEMPT = "ProcessorAttempt"
+
+const (
+	DefaultRetryInitialBackoff = 100 * time.Millisecond
+	DefaultRetryMaxBackoff     = 10 * time.Second
+)
+
+type RetryPolicy struct {
+	// attempts including the first run, no retry if less than 2
+	MaxAttempts int
+	// delay before the first retry, doubled for each retry until MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// random fraction of backoff added to it, e.g. 0.2 adds up to 20%
+	Jitter float64
+	// all errors are retried if nil
+	Retryable func(err error) bool
+}
+
+func (rp RetryPolicy) shouldRetry(err error, attempt int) bool {
+	if attempt >= rp.MaxAttempts {
+		return false
+	}
+	return rp.Retryable == nil || rp.Retryable(err)
+}
+
+// backoff after the attempt failed
+func (rp RetryPolicy) backoff(attempt int) time.Duration {
+	backoff, maxBackoff := rp.InitialBackoff, rp.MaxBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryInitialBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRetryMaxBackoff
+	}
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	if rp.Jitter > 0 {
+		backoff += time.Duration(rand.Float64() * rp.Jitter * float64(backoff))
+	}
+	return backoff
+}
+
+// processor returned error and is not retried any more, the iteration is failed with it
+type ProcessorError struct {
+	Processor types.DataType
+	Attempts  int
+	Err       error
+}
+
+func (pe *ProcessorError) Error() string {
+	return fmt.Sprintf("processor %s failed after %d attempts: %v", pe.Processor.FullName(), pe.Attempts, pe.Err)
+}
+func (pe *ProcessorError) Unwrap() error {
+	return pe.Err
+}
+
+// adapts processor implementing LoopProcessorE only, error is raised as panic if run as LoopProcessor
+type errorProcessor struct {
+	processor LoopProcessorE
+}
+
+func (ep *errorProcessor) Run(ctxt ScopeContext) {
+	if err := ep.processor.RunE(ctxt); err != nil {
+		panic(err)
+	}
+}
+func (ep *errorProcessor) RunE(ctxt ScopeContext) error {
+	return ep.processor.RunE(ctxt)
+}
+
+func toLoopProcessor(instance any) LoopProcessor {
+	if processor, ok := instance.(LoopProcessor); ok {
+		return processor
+	}
+	return &errorProcessor{processor: instance.(LoopProcessorE)}
+}
+
+func runProcessorOnce(processor LoopProcessor, ctxt ScopeContext) error {
+	if processorE, ok := processor.(LoopProcessorE); ok {
+		return processorE.RunE(ctxt)
+	}
+	processor.Run(ctxt)
+	return nil
+}
@@ -0,0 +1,155 @@
+package hosting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+type ActionProcessorE func(ctxt ScopeContext) error
+
+func (ap ActionProcessorE) RunE(ctxt ScopeContext) error { return ap(ctxt) }
+
+var errTransient = errors.New("transient")
+var errPermanent = errors.New("permanent")
+
+func runTestIteration(policy RetryPolicy, processors ...any) error {
+	host := NewDefaultHostBuilder().Build().(*DefaultGenericHost)
+	group := NewDefaultProcessorGroup(host.hostContext)
+	group.SetLooperContext(&FakeLooperContext{})
+	group.SetRetryPolicy(types.Get[ActionProcessorE](), policy)
+	group.Initialize()
+	for _, processor := range processors {
+		instance := toLoopProcessor(processor)
+		group.AddProcessor(types.Of(processor), func(dep.Context, types.DataType, dep.Properties) LoopProcessor { return instance }, nil)
+	}
+	return group.RunNewIteration(NewLoopGlobalContext(&DefaultLooper{ctx: context.Background()}))
+}
+
+func TestProcessorGroup_retry(t *testing.T) {
+	attempts := make([]int, 0)
+	next := false
+	err := runTestIteration(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		ActionProcessorE(func(ctxt ScopeContext) error {
+			attempts = append(attempts, GetVariable[int](ctxt, VAR_PROCESSOR_ATTEMPT))
+			if len(attempts) < 3 {
+				return errTransient
+			}
+			return nil
+		}),
+		ActionProcessor(func(ctxt ScopeContext) { next = true }),
+	)
+
+	if err != nil || fmt.Sprint(attempts) != "[1 2 3]" {
+		t.Errorf("processor should succeed on the last attempt, attempts: %v, error: %v", attempts, err)
+	}
+	if !next {
+		t.Errorf("processor after the one succeeded by retry should run")
+	}
+}
+
+func TestProcessorGroup_retry_failed(t *testing.T) {
+	cases := []struct {
+		err      error
+		attempts int
+	}{
+		{errTransient, 3},
+		{errPermanent, 1},
+	}
+	for _, c := range cases {
+		runs := 0
+		err := runTestIteration(RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			Retryable:      func(err error) bool { return !errors.Is(err, errPermanent) },
+		},
+			ActionProcessorE(func(ctxt ScopeContext) error {
+				runs++
+				return c.err
+			}),
+			ActionProcessor(func(ctxt ScopeContext) {
+				t.Errorf("processors after the one failed should be skipped")
+			}),
+		)
+
+		var failure *ProcessorError
+		if !errors.As(err, &failure) || !errors.Is(err, c.err) || failure.Attempts != c.attempts || runs != c.attempts {
+			t.Errorf("iteration should fail after %d attempts of %v, runs: %d, actual: %v", c.attempts, c.err, runs, err)
+		}
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, backoff := range expected {
+		if actual := policy.backoff(i + 1); actual != backoff {
+			t.Errorf("backoff of attempt %d is not expected: %v", i+1, actual)
+		}
+	}
+	if backoff := (RetryPolicy{}).backoff(1); backoff != DefaultRetryInitialBackoff {
+		t.Errorf("backoff should start from default initial backoff: %v", backoff)
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		if actual := policy.backoff(2); actual < 2*time.Second || actual > 3*time.Second {
+			t.Errorf("jitter should be bounded by the fraction of backoff: %v", actual)
+		}
+	}
+}
+
+func Test_looper_retry_func_processor(t *testing.T) {
+	builder := NewDefaultHostBuilder()
+
+	attempts := make(chan int, 10)
+	builder.UseLoop("Retry", func(context ServiceContext, looper ConfigureLoopContext) {
+		looper.SetInterval(time.Hour)
+		SetRetryPolicy[AnonFuncProcessor](looper, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+		// error returned by func processor is retried instead of raised as panic
+		looper.UseFuncProcessor(func(ctxt ScopeContext) error {
+			attempt := GetVariable[int](ctxt, VAR_PROCESSOR_ATTEMPT)
+			attempts <- attempt
+			if attempt < 2 {
+				return errTransient
+			}
+			return nil
+		})
+		looper.UseFuncProcessor(func() {
+			close(attempts)
+		})
+	})
+
+	host := builder.Build()
+	done := make(chan struct{})
+	go func() {
+		host.Run()
+		close(done)
+	}()
+
+	actual := make([]int, 0)
+	timeout := time.After(2 * time.Second)
+loop:
+	for {
+		select {
+		case attempt, ok := <-attempts:
+			if !ok {
+				break loop
+			}
+			actual = append(actual, attempt)
+		case <-timeout:
+			break loop
+		}
+	}
+	dep.GetComponent[AsyncAppRunner](host.GetComponentProvider()).SendStopSignal()
+	<-done
+
+	if fmt.Sprint(actual) != "[1 2]" {
+		t.Errorf("func processor should be retried until it succeeds, actual attempts: %v", actual)
+	}
+}
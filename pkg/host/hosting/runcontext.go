@@ -1,13 +1,19 @@
 package hosting
 
 import (
+	"context"
 	"fmt"
+	"sync"
 )
 
 type LoopRunContext interface {
 	LooperName() string
 	IsStopped() bool
 	SetStopped()
+
+	// mark the iteration failed, e.g. processor timed out, the first error is kept
+	SetFailed(err error)
+	GetError() error
 }
 
 type ScopeContextBase interface {
@@ -27,6 +33,8 @@ type LoopGlobalContext interface {
 
 	LooperName() string
 	GetLooperContext() ServiceContext
+	// cancelled once the looper is stopped
+	Context() context.Context
 }
 
 type ScopeOption int8
@@ -45,12 +53,16 @@ type ScopeContext interface {
 
 	GetLoopRunContext() LoopRunContext
 	GetLooperContext() ServiceContext
+	// cancelled once the looper is stopped, or the iteration or processor times out
+	Context() context.Context
 
 	ExitScope(ScopeOption)
 	IsExit() bool
 }
 
+// variables may be read by processors abandoned by timeout while the loop goes on
 type VariableSet struct {
+	mutex   sync.RWMutex
 	Entries map[string]interface{}
 }
 
@@ -60,15 +72,28 @@ func NewVariableSet() *VariableSet {
 	}
 }
 func (vs *VariableSet) Get(key string) interface{} {
+	defer vs.mutex.RUnlock()
+	vs.mutex.RLock()
 	return vs.Entries[key]
 }
 func (vs *VariableSet) Set(key string, value interface{}) {
+	defer vs.mutex.Unlock()
+	vs.mutex.Lock()
 	vs.Entries[key] = value
 }
 func (vs *VariableSet) Exist(key string) bool {
+	defer vs.mutex.RUnlock()
+	vs.mutex.RLock()
 	_, exist := vs.Entries[key]
 	return exist
 }
+func (vs *VariableSet) copyTo(scope ScopeContextBase) {
+	defer vs.mutex.RUnlock()
+	vs.mutex.RLock()
+	for key, value := range vs.Entries {
+		scope.SetVariable(key, value)
+	}
+}
 
 type DefaultLoopGlobalContext struct {
 	Looper    *DefaultLooper
@@ -88,6 +113,9 @@ func (lrc *DefaultLoopGlobalContext) LooperName() string {
 func (lrc *DefaultLoopGlobalContext) GetLooperContext() ServiceContext {
 	return lrc.Looper.context
 }
+func (lrc *DefaultLoopGlobalContext) Context() context.Context {
+	return lrc.Looper.ctx
+}
 
 func (lrc *DefaultLoopGlobalContext) HasVariable(key string, localScope bool) bool {
 	return lrc.Variables.Exist(key)
@@ -105,7 +133,10 @@ func (lrc *DefaultLoopGlobalContext) SetVariable(key string, value interface{})
 type DefaultLoopRunContext struct {
 	parent LoopGlobalContext
 
+	// processors of parallel group may stop or fail the run concurrently
+	mutex     sync.Mutex
 	Stopped   bool
+	Error     error
 	Variables *VariableSet
 }
 
@@ -121,11 +152,27 @@ func (gsc *DefaultLoopRunContext) LooperName() string {
 	return gsc.parent.LooperName()
 }
 func (gsc *DefaultLoopRunContext) IsStopped() bool {
+	defer gsc.mutex.Unlock()
+	gsc.mutex.Lock()
 	return gsc.Stopped
 }
 func (gsc *DefaultLoopRunContext) SetStopped() {
+	defer gsc.mutex.Unlock()
+	gsc.mutex.Lock()
 	gsc.Stopped = true
 }
+func (gsc *DefaultLoopRunContext) SetFailed(err error) {
+	defer gsc.mutex.Unlock()
+	gsc.mutex.Lock()
+	if gsc.Error == nil {
+		gsc.Error = err
+	}
+}
+func (gsc *DefaultLoopRunContext) GetError() error {
+	defer gsc.mutex.Unlock()
+	gsc.mutex.Lock()
+	return gsc.Error
+}
 
 func (gsc *DefaultLoopRunContext) GetLoopRunContext() LoopRunContext {
 	return gsc
@@ -133,6 +180,9 @@ func (gsc *DefaultLoopRunContext) GetLoopRunContext() LoopRunContext {
 func (gsc *DefaultLoopRunContext) GetLooperContext() ServiceContext {
 	return gsc.parent.GetLooperContext()
 }
+func (gsc *DefaultLoopRunContext) Context() context.Context {
+	return gsc.parent.Context()
+}
 
 func (gsc *DefaultLoopRunContext) HasVariable(key string, localScope bool) bool {
 	exist := gsc.Variables.Exist(key)
@@ -189,6 +239,9 @@ func (gsc *GroupScopeContext) GetLoopRunContext() LoopRunContext {
 func (gsc *GroupScopeContext) GetLooperContext() ServiceContext {
 	return gsc.parent.GetLooperContext()
 }
+func (gsc *GroupScopeContext) Context() context.Context {
+	return gsc.parent.Context()
+}
 
 func (gsc *GroupScopeContext) HasVariable(key string, localScope bool) bool {
 	exist := gsc.Variables.Exist(key)
@@ -222,3 +275,74 @@ func (gsc *GroupScopeContext) ExitScope(option ScopeOption) {
 func (gsc *GroupScopeContext) IsExit() bool {
 	return gsc.complete
 }
+
+// scope shared with the parent, but cancelled by the deadline of the group or processor
+type deadlineScopeContext struct {
+	ScopeContext
+	ctx context.Context
+}
+
+func newDeadlineScopeContext(scope ScopeContext, ctx context.Context) *deadlineScopeContext {
+	return &deadlineScopeContext{
+		ScopeContext: scope,
+		ctx:          ctx,
+	}
+}
+
+func (dsc *deadlineScopeContext) Context() context.Context {
+	return dsc.ctx
+}
+
+// scope of processor attempt run in a separate goroutine. Variables set and scope exited by the processor are kept
+// in the attempt, and applied to the scope only if the attempt completes in time, so that a run abandoned by timeout
+// never changes the scope shared by the loop
+type attemptScopeContext struct {
+	ScopeContext
+	Variables *VariableSet
+
+	mutex sync.Mutex
+	exits []ScopeOption
+}
+
+func newAttemptScopeContext(scope ScopeContext) *attemptScopeContext {
+	return &attemptScopeContext{
+		ScopeContext: scope,
+		Variables:    NewVariableSet(),
+	}
+}
+
+func (asc *attemptScopeContext) HasVariable(key string, localScope bool) bool {
+	return asc.Variables.Exist(key) || asc.ScopeContext.HasVariable(key, localScope)
+}
+func (asc *attemptScopeContext) GetVariable(key string) interface{} {
+	if asc.Variables.Exist(key) {
+		return asc.Variables.Get(key)
+	}
+	return asc.ScopeContext.GetVariable(key)
+}
+func (asc *attemptScopeContext) SetVariable(key string, value interface{}) {
+	asc.Variables.Set(key, value)
+}
+
+func (asc *attemptScopeContext) ExitScope(option ScopeOption) {
+	defer asc.mutex.Unlock()
+	asc.mutex.Lock()
+	asc.exits = append(asc.exits, option)
+}
+func (asc *attemptScopeContext) IsExit() bool {
+	asc.mutex.Lock()
+	exited := len(asc.exits) > 0
+	asc.mutex.Unlock()
+	return exited || asc.ScopeContext.IsExit()
+}
+
+// called by the loop once the attempt completes
+func (asc *attemptScopeContext) apply() {
+	asc.Variables.copyTo(asc.ScopeContext)
+
+	defer asc.mutex.Unlock()
+	asc.mutex.Lock()
+	for _, option := range asc.exits {
+		asc.ScopeContext.ExitScope(option)
+	}
+}
@@ -36,8 +36,8 @@ func Test_RunningMode(t *testing.T) {
 }
 
 func Test_EventType(t *testing.T) {
-	types := []EventType{EVENT_TYPE_SIGNAL, EVENT_TYPE_WINSVC, EventType(2), EventType(3)}
-	names := []string{"Signal", "WinSvc", "EventType(2)", "EventType(3)"}
+	types := []EventType{EVENT_TYPE_SIGNAL, EVENT_TYPE_WINSVC, EVENT_TYPE_READINESS, EVENT_TYPE_SERVICE, EventType(4)}
+	names := []string{"Signal", "WinSvc", "Readiness", "Service", "EventType(4)"}
 	for index, ty := range types {
 		name := ty.String()
 		if names[index] != name {
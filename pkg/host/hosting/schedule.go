@@ -0,0 +1,296 @@
+package hosting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// computes fire times of scheduled looper
+type Schedule interface {
+	// first fire time after the given time, zero time if there is no more fire time
+	Next(after time.Time) time.Time
+}
+
+type ScheduleMode uint8
+
+const (
+	// next fire time is computed from the previous fire time, regardless of how long the iteration takes
+	ScheduleMode_FixedRate ScheduleMode = iota
+	// next fire time is computed from the completion of the previous iteration
+	ScheduleMode_FixedDelay
+)
+
+func (sm ScheduleMode) String() string {
+	switch sm {
+	case ScheduleMode_FixedRate:
+		return "FixedRate"
+	case ScheduleMode_FixedDelay:
+		return "FixedDelay"
+	default:
+		return fmt.Sprintf("ScheduleMode(%d)", sm)
+	}
+}
+
+// how to handle fire times passed without running, e.g. process was suspended or iteration took too long
+type MissedRunPolicy uint8
+
+const (
+	// run once for all missed fire times
+	MissedRunPolicy_RunOnce MissedRunPolicy = iota
+	// skip missed fire times and wait for the next one
+	MissedRunPolicy_Skip
+	// run once for each missed fire time, up to maxMissedRuns
+	MissedRunPolicy_RunAll
+)
+
+func (mp MissedRunPolicy) String() string {
+	switch mp {
+	case MissedRunPolicy_RunOnce:
+		return "RunOnce"
+	case MissedRunPolicy_Skip:
+		return "Skip"
+	case MissedRunPolicy_RunAll:
+		return "RunAll"
+	default:
+		return fmt.Sprintf("MissedRunPolicy(%d)", mp)
+	}
+}
+
+type ScheduleOptions struct {
+	Mode       ScheduleMode
+	MissedRuns MissedRunPolicy
+}
+
+// fires at fixed interval
+type IntervalSchedule struct {
+	Interval time.Duration
+}
+
+func NewIntervalSchedule(interval time.Duration) *IntervalSchedule {
+	if interval <= 0 {
+		panic(fmt.Errorf("interval of schedule should be positive: %v", interval))
+	}
+	return &IntervalSchedule{Interval: interval}
+}
+
+func (is *IntervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(is.Interval)
+}
+
+// schedule of cron expression with seconds: "second minute hour day-of-month month day-of-week".
+// expression of 5 fields fires at second 0, descriptors @yearly, @monthly, @weekly, @daily and @hourly are supported
+type CronSchedule struct {
+	expression string
+	location   *time.Location
+
+	second, minute, hour, dom, month, dow uint64
+	domStar, dowStar                      bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronSecond = cronField{name: "second", min: 0, max: 59}
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is also Sunday
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}
+)
+
+// fire times are computed in the location, time.Local if nil
+func ParseCronSchedule(expression string, location *time.Location) (*CronSchedule, error) {
+	if location == nil {
+		location = time.Local
+	}
+	spec := strings.TrimSpace(expression)
+	if descriptor, exist := cronDescriptors[strings.ToLower(spec)]; exist {
+		spec = descriptor
+	}
+	fields := strings.Fields(spec)
+	if len(fields) == 5 {
+		fields = append([]string{"0"}, fields...)
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 or 6 fields, actual %d", expression, len(fields))
+	}
+
+	cs := &CronSchedule{expression: expression, location: location}
+	targets := []*uint64{&cs.second, &cs.minute, &cs.hour, &cs.dom, &cs.month, &cs.dow}
+	for i, field := range []cronField{cronSecond, cronMinute, cronHour, cronDom, cronMonth, cronDow} {
+		bits, err := field.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expression, err)
+		}
+		*targets[i] = bits
+	}
+	if cs.dow&(1<<7) != 0 {
+		cs.dow |= 1
+	}
+	cs.domStar = fields[3] == "*" || fields[3] == "?"
+	cs.dowStar = fields[5] == "*" || fields[5] == "?"
+	return cs, nil
+}
+
+func MustParseCronSchedule(expression string, location *time.Location) *CronSchedule {
+	cs, err := ParseCronSchedule(expression, location)
+	if err != nil {
+		panic(err)
+	}
+	return cs
+}
+
+func (cs *CronSchedule) String() string {
+	return cs.expression
+}
+
+func (cf cronField) parse(spec string) (uint64, error) {
+	bits := uint64(0)
+	for _, part := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepSpec); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step of %s: %s", cf.name, part)
+			}
+		}
+
+		var low, high int
+		if rangeSpec == "*" || rangeSpec == "?" {
+			low, high = cf.min, cf.max
+		} else {
+			lowSpec, highSpec, isRange := strings.Cut(rangeSpec, "-")
+			var err error
+			if low, err = cf.value(lowSpec); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = cf.value(highSpec); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = cf.max
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range of %s: %s", cf.name, part)
+			}
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (cf cronField) value(spec string) (int, error) {
+	if v, exist := cf.names[strings.ToLower(spec)]; exist {
+		return v, nil
+	}
+	v, err := strconv.Atoi(spec)
+	if err != nil || v < cf.min || v > cf.max {
+		return 0, fmt.Errorf("invalid value of %s: %s, expected %d-%d", cf.name, spec, cf.min, cf.max)
+	}
+	return v, nil
+}
+
+// day matches if both day of month and day of week match, or either of them matches if neither is "*"
+func (cs *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := cs.dom&(1<<uint(t.Day())) != 0
+	dowMatch := cs.dow&(1<<uint(t.Weekday())) != 0
+	if cs.domStar || cs.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// search years ahead for expressions like "0 0 0 29 2 *", zero time is returned if not found
+const cronSearchYears = 5
+
+func (cs *CronSchedule) Next(after time.Time) time.Time {
+	t := after.In(cs.location)
+	// start from the next whole second
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + cronSearchYears
+
+	// lower fields are reset once a higher field is moved forward
+	reset := false
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for cs.month&(1<<uint(t.Month())) == 0 {
+		if !reset {
+			reset = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, cs.location)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+	for !cs.dayMatches(t) {
+		if !reset {
+			reset = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, cs.location)
+		}
+		t = t.AddDate(0, 0, 1)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+	for cs.hour&(1<<uint(t.Hour())) == 0 {
+		if !reset {
+			reset = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, cs.location)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+	for cs.minute&(1<<uint(t.Minute())) == 0 {
+		if !reset {
+			reset = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+	for cs.second&(1<<uint(t.Second())) == 0 {
+		if !reset {
+			reset = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+	return t
+}
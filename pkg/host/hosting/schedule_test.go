@@ -0,0 +1,228 @@
+package hosting
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+)
+
+func parseTime(t *testing.T, value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("invalid time %s: %v", value, err)
+	}
+	return parsed
+}
+
+func TestCronSchedule_next(t *testing.T) {
+	jst := time.FixedZone("JST", 9*3600)
+	cases := []struct {
+		expression string
+		location   *time.Location
+		after      string
+		expected   string
+	}{
+		{"0 0 2 * * *", time.UTC, "2024-01-01T03:00:00Z", "2024-01-02T02:00:00Z"},
+		{"0 0 2 * * *", time.UTC, "2024-01-01T01:59:59.5Z", "2024-01-01T02:00:00Z"},
+		{"0 */15 * * * *", time.UTC, "2024-01-01T10:07:30Z", "2024-01-01T10:15:00Z"},
+		{"0 */15 * * * *", time.UTC, "2024-01-01T10:15:00Z", "2024-01-01T10:30:00Z"},
+		{"*/10 * * * * *", time.UTC, "2024-01-01T10:00:55Z", "2024-01-01T10:01:00Z"},
+		{"30 0 9 * * MON-FRI", time.UTC, "2024-01-05T10:00:00Z", "2024-01-08T09:00:30Z"},
+		{"0 0 0 1 * MON", time.UTC, "2024-01-02T00:00:00Z", "2024-01-08T00:00:00Z"},
+		{"0 0 0 29 FEB *", time.UTC, "2024-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		{"0 0 12 * * 7", time.UTC, "2024-01-01T00:00:00Z", "2024-01-07T12:00:00Z"},
+		{"0 0 * * *", time.UTC, "2024-12-31T23:30:00Z", "2025-01-01T00:00:00Z"},
+		{"@daily", jst, "2024-01-01T00:00:00Z", "2024-01-01T15:00:00Z"},
+		{"@hourly", time.UTC, "2024-01-01T00:00:00Z", "2024-01-01T01:00:00Z"},
+	}
+	for _, c := range cases {
+		schedule, err := ParseCronSchedule(c.expression, c.location)
+		if err != nil {
+			t.Errorf("failed to parse %s: %v", c.expression, err)
+			continue
+		}
+		next := schedule.Next(parseTime(t, c.after))
+		if !next.Equal(parseTime(t, c.expected)) {
+			t.Errorf("next fire time of %s after %s is not expected: %v", c.expression, c.after, next.UTC())
+		}
+	}
+
+	never := MustParseCronSchedule("0 0 0 31 2 *", time.UTC)
+	if next := never.Next(time.Now()); !next.IsZero() {
+		t.Errorf("schedule never fires should return zero time: %v", next)
+	}
+}
+
+func TestCronSchedule_invalid(t *testing.T) {
+	cases := map[string]string{
+		"* * *":             "expected 5 or 6 fields, actual 3",
+		"61 * * * * *":      "invalid value of second: 61, expected 0-59",
+		"0 0 0 * * FUN":     "invalid value of day of week: FUN",
+		"0 */0 * * * *":     "invalid step of minute: */0",
+		"0 0 5-1 * * *":     "invalid range of hour: 5-1",
+		"0 0 0 0 * *":       "invalid value of day of month: 0, expected 1-31",
+		"0 0 0 1 13 *":      "invalid value of month: 13, expected 1-12",
+		"@every 10s":        "expected 5 or 6 fields, actual 2",
+		"0 0 0 * * MON,x-y": "invalid value of day of week: x",
+	}
+	for expression, message := range cases {
+		_, err := ParseCronSchedule(expression, time.UTC)
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("error of %s should contain: %s, actual: %v", expression, message, err)
+		}
+	}
+}
+
+// fires at base + k * step
+type slotSchedule struct {
+	base time.Time
+	step time.Duration
+}
+
+func (ss *slotSchedule) Next(after time.Time) time.Time {
+	if after.Before(ss.base) {
+		return ss.base
+	}
+	return ss.base.Add((after.Sub(ss.base)/ss.step + 1) * ss.step)
+}
+
+// the first iteration takes 3.5 steps, so the 2nd fire time is due and the 3rd and 4th are missed
+func runWithMissedSlots(t *testing.T, policy MissedRunPolicy) int {
+	runner := NewLoopRunner(LoopRunnerSettings{EnableRecover: true, MaxStopInterval: 100 * time.Millisecond})
+	step := 100 * time.Millisecond
+	schedule := &slotSchedule{base: runner.wallClockNow().Add(step), step: step}
+
+	var mutex sync.Mutex
+	starts := make([]time.Duration, 0)
+	go runner.RunSchedule(schedule, ScheduleOptions{MissedRuns: policy}, func(ctxt any) {
+		mutex.Lock()
+		starts = append(starts, time.Since(schedule.base))
+		first := len(starts) == 1
+		mutex.Unlock()
+		if first {
+			time.Sleep(step*3 + step/2)
+		}
+	})
+
+	time.Sleep(step * 5)
+	if err := runner.Stop(context.Background()); err != nil {
+		t.Errorf("stop runner error: %v", err)
+	}
+
+	defer mutex.Unlock()
+	mutex.Lock()
+	// runs before the 5th fire time
+	count := 0
+	for _, start := range starts {
+		if start < step*3+step*9/10 {
+			count++
+		}
+	}
+	return count
+}
+
+func TestLoopRunner_missed_runs(t *testing.T) {
+	if count := runWithMissedSlots(t, MissedRunPolicy_RunOnce); count != 2 {
+		t.Errorf("missed runs should be run once, actual runs: %d", count)
+	}
+	if count := runWithMissedSlots(t, MissedRunPolicy_RunAll); count != 4 {
+		t.Errorf("each missed run should be run, actual runs: %d", count)
+	}
+	if count := runWithMissedSlots(t, MissedRunPolicy_Skip); count != 1 {
+		t.Errorf("missed runs should be skipped, actual runs: %d", count)
+	}
+}
+
+func runWithScheduleMode(t *testing.T, mode ScheduleMode) time.Duration {
+	runner := NewLoopRunner(LoopRunnerSettings{EnableRecover: true, MaxStopInterval: 100 * time.Millisecond})
+
+	var mutex sync.Mutex
+	starts := make([]time.Time, 0)
+	go runner.RunSchedule(NewIntervalSchedule(30*time.Millisecond), ScheduleOptions{Mode: mode}, func(ctxt any) {
+		mutex.Lock()
+		starts = append(starts, time.Now())
+		mutex.Unlock()
+		time.Sleep(30 * time.Millisecond)
+	})
+
+	time.Sleep(200 * time.Millisecond)
+	if err := runner.Stop(context.Background()); err != nil {
+		t.Errorf("stop runner error: %v", err)
+	}
+
+	defer mutex.Unlock()
+	mutex.Lock()
+	if len(starts) < 2 {
+		t.Fatalf("schedule should run more than once, actual runs: %d", len(starts))
+	}
+	return starts[len(starts)-1].Sub(starts[0]) / time.Duration(len(starts)-1)
+}
+
+func TestLoopRunner_schedule_mode(t *testing.T) {
+	if gap := runWithScheduleMode(t, ScheduleMode_FixedRate); gap > 45*time.Millisecond {
+		t.Errorf("fixed rate should start iterations at interval, actual gap: %v", gap)
+	}
+	if gap := runWithScheduleMode(t, ScheduleMode_FixedDelay); gap < 55*time.Millisecond {
+		t.Errorf("fixed delay should wait interval after iterations, actual gap: %v", gap)
+	}
+}
+
+func TestLoopRunner_stop_waiting_schedule(t *testing.T) {
+	runner := NewLoopRunner(LoopRunnerSettings{MaxStopInterval: 100 * time.Millisecond})
+	var count int32
+	go runner.RunSchedule(MustParseCronSchedule("0 0 0 31 2 *", time.UTC), ScheduleOptions{}, func(ctxt any) {
+		atomic.AddInt32(&count, 1)
+	})
+
+	time.Sleep(10 * time.Millisecond)
+	ctxt, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := runner.Stop(ctxt); err != nil {
+		t.Errorf("runner waiting for fire time should be stopped: %v", err)
+	}
+	if atomic.LoadInt32(&count) != 0 {
+		t.Errorf("schedule never fires should not run")
+	}
+}
+
+func Test_looper_schedule(t *testing.T) {
+	builder := NewDefaultHostBuilder()
+
+	var count int32
+	builder.UseLoop("Scheduled", func(context ServiceContext, looper ConfigureLoopContext) {
+		looper.SetSchedule(NewIntervalSchedule(20 * time.Millisecond))
+		looper.SetScheduleOptions(ScheduleOptions{Mode: ScheduleMode_FixedDelay})
+		looper.UseFuncProcessor(func(ctxt ScopeContext) {
+			atomic.AddInt32(&count, 1)
+		})
+	})
+
+	host := builder.Build()
+	runner := dep.GetComponent[AsyncAppRunner](host.GetComponentProvider())
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		runner.SendStopSignal()
+	}()
+	host.Run()
+
+	// interval based looper would run only once within minimum loop interval
+	if actual := atomic.LoadInt32(&count); actual < 3 {
+		t.Errorf("looper should run by schedule, actual iterations: %d", actual)
+	}
+}
+
+func Test_looper_invalid_cron(t *testing.T) {
+	defer test.AssertPanicContent(t, `invalid cron expression "0 0 25 * * *": invalid value of hour: 25`, "panic content is not expected")
+
+	builder := NewDefaultHostBuilder()
+	builder.UseLoop("Scheduled", func(context ServiceContext, looper ConfigureLoopContext) {
+		looper.SetCron("0 0 25 * * *", time.UTC)
+	})
+	builder.Build()
+}
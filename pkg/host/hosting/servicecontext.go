@@ -25,6 +25,7 @@ type DefaultServiceContext struct {
 	contextType        string
 	name               string
 	serviceType        types.DataType
+	resolutionFrame    *dep.ResolutionFrame
 
 	// local context dependencies
 	localDeps dep.DepDict[dep.ComponentGetter]
@@ -74,6 +75,13 @@ func (sc *DefaultServiceContext) GetTracker() dep.DependencyTracker {
 	return sc.depTracker
 }
 
+func (sc *DefaultServiceContext) GetResolutionFrame() *dep.ResolutionFrame {
+	return sc.resolutionFrame
+}
+func (sc *DefaultServiceContext) SetResolutionFrame(frame *dep.ResolutionFrame) {
+	sc.resolutionFrame = frame
+}
+
 func (sc *DefaultServiceContext) UpdateProperties(props dep.Properties) {
 	if sc.props == nil {
 		sc.props = props
@@ -0,0 +1,141 @@
+package hosting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+const DefaultServiceReadyTimeout = 30 * time.Second
+
+// service reporting readiness after Run is called, its dependents are started only after it is ready.
+// WaitReady should return once the service is ready, or with error if it fails to be ready or ctx is done.
+type ReadyWaiter interface {
+	WaitReady(ctx context.Context) error
+}
+
+// service depending on other services by name, it is started after and stopped before them.
+// dependencies can also be declared by AddServiceDependencies of host builder
+type ServiceDependent interface {
+	GetServiceDependencies() []string
+}
+
+// name of hosted service registered by UseService
+func GetServiceName(serviceType types.DataType) string {
+	return "Service:" + serviceType.FullName()
+}
+
+// name of hosted service registered by UseLoop
+func GetLooperName(name string) string {
+	return "Looper:" + name
+}
+
+// merge declared dependencies of services, and sort services so that dependencies go first.
+// services without dependencies between them are sorted by name, for deterministic order
+func resolveServiceDependencies(services map[string]Service, declared map[string][]string) ([]string, map[string][]string, error) {
+	dependencies := make(map[string][]string, len(services))
+	for name, service := range services {
+		dependencies[name] = append([]string{}, declared[name]...)
+		if dependent, ok := service.(ServiceDependent); ok {
+			dependencies[name] = append(dependencies[name], dependent.GetServiceDependencies()...)
+		}
+	}
+	for name := range declared {
+		if _, exist := services[name]; !exist {
+			return nil, nil, fmt.Errorf("dependencies are declared for unknown service: %s", name)
+		}
+	}
+
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch states[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular dependency between services: %s", strings.Join(append(path, name), " -> "))
+		}
+		states[name] = visiting
+		for _, dependency := range dependencies[name] {
+			if _, exist := services[dependency]; !exist {
+				return fmt.Errorf("service %s depends on unknown service: %s", name, dependency)
+			}
+			if err := visit(dependency, append(path, name)); err != nil {
+				return err
+			}
+		}
+		states[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	return order, dependencies, nil
+}
+
+// dependents of each service, i.e. prerequisites of stopping the service
+func getServiceDependents(dependencies map[string][]string) map[string][]string {
+	dependents := make(map[string][]string, len(dependencies))
+	for name, names := range dependencies {
+		for _, dependency := range names {
+			dependents[dependency] = append(dependents[dependency], name)
+		}
+	}
+	return dependents
+}
+
+// run action of each service concurrently once actions of its prerequisites are complete.
+// action is skipped and failed if any prerequisite failed and skipFailed is true
+func runServiceGraph(order []string, prerequisites map[string][]string, skipFailed bool, action func(name string) error) map[string]error {
+	type result struct {
+		done chan struct{}
+		err  error
+	}
+	results := make(map[string]*result, len(order))
+	for _, name := range order {
+		results[name] = &result{done: make(chan struct{})}
+	}
+
+	for _, name := range order {
+		go func(name string, current *result) {
+			defer close(current.done)
+			for _, prerequisite := range prerequisites[name] {
+				r := results[prerequisite]
+				<-r.done
+				if r.err != nil && skipFailed {
+					current.err = fmt.Errorf("service %s is skipped, dependency %s failed", name, prerequisite)
+					return
+				}
+			}
+			current.err = action(name)
+		}(name, results[name])
+	}
+
+	errs := make(map[string]error)
+	for _, name := range order {
+		<-results[name].done
+		if err := results[name].err; err != nil {
+			errs[name] = err
+		}
+	}
+	return errs
+}
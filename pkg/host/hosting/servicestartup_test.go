@@ -0,0 +1,217 @@
+package hosting
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+type StartupRecorder struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (r *StartupRecorder) record(event string) {
+	defer r.mutex.Unlock()
+	r.mutex.Lock()
+	r.events = append(r.events, event)
+}
+func (r *StartupRecorder) indexOf(event string) int {
+	defer r.mutex.Unlock()
+	r.mutex.Lock()
+	for i, e := range r.events {
+		if e == event {
+			return i
+		}
+	}
+	return -1
+}
+
+type OrderedService struct {
+	name     string
+	recorder *StartupRecorder
+	done     chan struct{}
+}
+
+func NewOrderedService(name string, recorder *StartupRecorder) *OrderedService {
+	return &OrderedService{name: name, recorder: recorder, done: make(chan struct{})}
+}
+func (s *OrderedService) Run() {
+	s.recorder.record("run:" + s.name)
+	<-s.done
+}
+func (s *OrderedService) Stop(ctx context.Context) error {
+	s.recorder.record("stop:" + s.name)
+	close(s.done)
+	return nil
+}
+
+type ReadyService struct {
+	*OrderedService
+	delay time.Duration
+}
+
+func (s *ReadyService) WaitReady(ctx context.Context) error {
+	select {
+	case <-time.After(s.delay):
+		s.recorder.record("ready:" + s.name)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type DependentService struct {
+	*OrderedService
+	dependencies []string
+}
+
+func (s *DependentService) GetServiceDependencies() []string {
+	return s.dependencies
+}
+
+type DatabaseService interface{ Service }
+type CacheService interface{ Service }
+type ApiService interface{ Service }
+
+func buildOrderedHost(recorder *StartupRecorder, databaseDelay time.Duration, configure func(HostBuilder)) *DefaultGenericHost {
+	builder := NewDefaultHostBuilder()
+	UseService[DatabaseService](builder, func() *ReadyService {
+		return &ReadyService{OrderedService: NewOrderedService("database", recorder), delay: databaseDelay}
+	})
+	UseService[CacheService](builder, func() *DependentService {
+		return &DependentService{
+			OrderedService: NewOrderedService("cache", recorder),
+			dependencies:   []string{GetServiceName(types.Get[DatabaseService]())},
+		}
+	})
+	UseService[ApiService](builder, func() *OrderedService {
+		return NewOrderedService("api", recorder)
+	})
+	builder.AddServiceDependencies(GetServiceName(types.Get[ApiService]()), GetServiceName(types.Get[CacheService]()))
+	builder.ConfigureLifecycle(func(hostContext dep.Context, appLifecycle ApplicationLifecycle) {
+		appLifecycle.RegisterOnAppStarted(func(ctx dep.Context) { recorder.record("started") })
+	})
+	if configure != nil {
+		configure(builder)
+	}
+	return builder.Build().(*DefaultGenericHost)
+}
+
+func TestHost_ordered_services(t *testing.T) {
+	recorder := &StartupRecorder{}
+	host := buildOrderedHost(recorder, 50*time.Millisecond, nil)
+
+	host.Start()
+	// services without readiness are started but may not be running yet
+	time.Sleep(50 * time.Millisecond)
+	if recorder.indexOf("ready:database") > recorder.indexOf("run:cache") ||
+		recorder.indexOf("run:cache") < 0 || recorder.indexOf("run:api") < 0 || recorder.indexOf("started") < 0 {
+		t.Errorf("dependents should be started after dependencies are ready, actual: %v", recorder.events)
+	}
+	if recorder.indexOf("started") < recorder.indexOf("ready:database") {
+		t.Errorf("OnAppStarted should be called after all services are ready, actual: %v", recorder.events)
+	}
+
+	if err := host.Shutdown(time.Second); err != nil {
+		t.Errorf("shutdown should succeed: %v", err)
+	}
+	stops := make([]string, 0)
+	for _, event := range recorder.events {
+		if strings.HasPrefix(event, "stop:") {
+			stops = append(stops, event)
+		}
+	}
+	if strings.Join(stops, ",") != "stop:api,stop:cache,stop:database" {
+		t.Errorf("services should be stopped in reverse order of dependencies, actual: %v", stops)
+	}
+}
+
+func TestHost_service_not_ready(t *testing.T) {
+	recorder := &StartupRecorder{}
+	host := buildOrderedHost(recorder, time.Second, func(builder HostBuilder) {
+		builder.SetServiceReadyTimeout(20 * time.Millisecond)
+	})
+
+	host.Start()
+	if recorder.indexOf("run:cache") >= 0 || recorder.indexOf("run:api") >= 0 {
+		t.Errorf("dependents of the service not ready should not be started, actual: %v", recorder.events)
+	}
+	if recorder.indexOf("started") >= 0 {
+		t.Errorf("OnAppStarted should be skipped if services are not ready")
+	}
+	_ = host.Shutdown(10 * time.Millisecond)
+}
+
+func TestHost_service_not_ready_shutdown(t *testing.T) {
+	recorder := &StartupRecorder{}
+	var event *StopEvent
+	host := buildOrderedHost(recorder, time.Second, func(builder HostBuilder) {
+		builder.SetServiceReadyTimeout(20 * time.Millisecond)
+		builder.ConfigureLifecycle(func(hostContext dep.Context, appLifecycle ApplicationLifecycle) {
+			appLifecycle.RegisterOnAppStarted(func(ctx dep.Context) { recorder.record("started") })
+			appLifecycle.RegisterOnStopEvent(func(ctx dep.Context, stopEvent *StopEvent) bool {
+				event = stopEvent
+				return false
+			})
+		})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		host.Run()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("host should be shut down if services are not ready, actual: %v", recorder.events)
+	}
+
+	if event == nil || event.Type != EVENT_TYPE_READINESS {
+		t.Fatalf("readiness stop event should be raised, actual: %v", event)
+	}
+	data, ok := event.Data.(*ServicesNotReady)
+	if !ok || !errors.Is(data.Error, context.DeadlineExceeded) {
+		t.Errorf("readiness error should be surfaced, actual: %v", event.Data)
+	}
+	if recorder.indexOf("stop:database") < 0 || recorder.indexOf("stop:cache") >= 0 || recorder.indexOf("started") >= 0 {
+		t.Errorf("only services started should be stopped, actual: %v", recorder.events)
+	}
+}
+
+func TestHostBuilder_invalid_service_dependencies(t *testing.T) {
+	defer test.AssertPanicContent(t, "invalid service dependencies: circular dependency between services", "panic content is not expected")
+
+	buildOrderedHost(&StartupRecorder{}, 0, func(builder HostBuilder) {
+		builder.AddServiceDependencies(GetServiceName(types.Get[DatabaseService]()), GetServiceName(types.Get[ApiService]()))
+	})
+}
+
+func TestResolveServiceDependencies(t *testing.T) {
+	services := map[string]Service{"a": nil, "b": nil, "c": nil, "d": nil}
+	order, _, err := resolveServiceDependencies(services, map[string][]string{"a": {"c"}, "c": {"d", "b"}})
+	if err != nil || strings.Join(order, ",") != "d,b,c,a" {
+		t.Errorf("services should be sorted by dependencies then names, actual: %v, %v", order, err)
+	}
+
+	_, _, err = resolveServiceDependencies(services, map[string][]string{"a": {"x"}})
+	if err == nil || err.Error() != "service a depends on unknown service: x" {
+		t.Errorf("unknown dependency should be reported, actual: %v", err)
+	}
+	_, _, err = resolveServiceDependencies(services, map[string][]string{"x": {"a"}})
+	if err == nil || err.Error() != "dependencies are declared for unknown service: x" {
+		t.Errorf("unknown service should be reported, actual: %v", err)
+	}
+	_, _, err = resolveServiceDependencies(services, map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}})
+	if err == nil || err.Error() != "circular dependency between services: a -> b -> c -> a" {
+		t.Errorf("circular dependency should be reported, actual: %v", err)
+	}
+}
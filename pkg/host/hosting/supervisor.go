@@ -0,0 +1,180 @@
+package hosting
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
+)
+
+type RestartMode uint8
+
+const (
+	// service is not restarted once Run returns or panics
+	RestartMode_Never RestartMode = iota
+	// service is restarted once Run returns or panics
+	RestartMode_Always
+	// service is restarted only if Run panics, returning from Run means the service is complete
+	RestartMode_OnFailure
+)
+
+func (rm RestartMode) String() string {
+	switch rm {
+	case RestartMode_Never:
+		return "Never"
+	case RestartMode_Always:
+		return "Always"
+	case RestartMode_OnFailure:
+		return "OnFailure"
+	default:
+		return fmt.Sprintf("RestartMode(%d)", rm)
+	}
+}
+
+const (
+	DefaultRestartInitialBackoff = time.Second
+	DefaultRestartMaxBackoff     = time.Minute
+)
+
+type RestartPolicy struct {
+	Mode RestartMode
+	// no limit if not positive
+	MaxRestarts int
+	// delay before the first restart, doubled for each restart until MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// raise stop event to shut down the host once the service is down and not restarted
+	Escalate bool
+}
+
+func (rp RestartPolicy) backoff(restarts int) time.Duration {
+	backoff, maxBackoff := rp.InitialBackoff, rp.MaxBackoff
+	if backoff <= 0 {
+		backoff = DefaultRestartInitialBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRestartMaxBackoff
+	}
+	for i := 0; i < restarts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// data of stop event EVENT_TYPE_SERVICE, raised when a service with escalating policy is down
+type ServiceExit struct {
+	Service  string
+	Error    error
+	Restarts int
+}
+
+// runs services and applies their restart policies when they return or panic without being stopped by host
+type ServiceSupervisor struct {
+	logger   logger.Logger
+	policies map[string]RestartPolicy
+	escalate func(event *StopEvent)
+	mutex    sync.Mutex
+	stopping map[string]chan struct{}
+}
+
+func NewServiceSupervisor(logger logger.Logger, policies map[string]RestartPolicy, escalate func(event *StopEvent)) *ServiceSupervisor {
+	return &ServiceSupervisor{
+		logger:   logger,
+		policies: policies,
+		escalate: escalate,
+		stopping: make(map[string]chan struct{}),
+	}
+}
+
+// run the service in a separate go routine
+func (ss *ServiceSupervisor) Run(name string, service Service) {
+	ss.mutex.Lock()
+	stopping, exist := ss.stopping[name]
+	if !exist {
+		stopping = make(chan struct{})
+		ss.stopping[name] = stopping
+	}
+	ss.mutex.Unlock()
+
+	go ss.supervise(name, service, ss.policies[name], stopping)
+}
+
+// called before the service is stopped by host, so that it is not restarted
+func (ss *ServiceSupervisor) Stopping(name string) {
+	defer ss.mutex.Unlock()
+	ss.mutex.Lock()
+
+	stopping, exist := ss.stopping[name]
+	if !exist {
+		stopping = make(chan struct{})
+		ss.stopping[name] = stopping
+	}
+	select {
+	case <-stopping:
+	default:
+		close(stopping)
+	}
+}
+
+func (ss *ServiceSupervisor) isStopping(stopping chan struct{}) bool {
+	select {
+	case <-stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+func (ss *ServiceSupervisor) supervise(name string, service Service, policy RestartPolicy, stopping chan struct{}) {
+	for restarts := 0; ; restarts++ {
+		err := ss.runService(service)
+		if ss.isStopping(stopping) {
+			return
+		}
+
+		if err != nil {
+			ss.logger.Errorw("service failed", "service", name, "error", err, "restarts", restarts)
+		} else {
+			ss.logger.Warnw("service exited without being stopped", "service", name, "restarts", restarts)
+		}
+
+		restart := policy.Mode == RestartMode_Always || (policy.Mode == RestartMode_OnFailure && err != nil)
+		if !restart || (policy.MaxRestarts > 0 && restarts >= policy.MaxRestarts) {
+			ss.giveUp(name, err, restarts, policy)
+			return
+		}
+
+		backoff := policy.backoff(restarts)
+		ss.logger.Infow("restarting service", "service", name, "backoff", backoff, "restarts", restarts+1)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-stopping:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (ss *ServiceSupervisor) giveUp(name string, err error, restarts int, policy RestartPolicy) {
+	if !policy.Escalate {
+		ss.logger.Warnw("service is down and not restarted", "service", name, "policy", policy.Mode.String())
+		return
+	}
+	ss.logger.Errorw("service is down, escalating to host shutdown", "service", name, "restarts", restarts)
+	ss.escalate(&StopEvent{Type: EVENT_TYPE_SERVICE, Data: &ServiceExit{Service: name, Error: err, Restarts: restarts}})
+}
+
+func (ss *ServiceSupervisor) runService(service Service) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	service.Run()
+	return nil
+}
@@ -0,0 +1,185 @@
+package hosting
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/test"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
+)
+
+// panics for the first failures runs, then runs until stopped
+type FlakyService struct {
+	failures int32
+	runs     int32
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewFlakyService(failures int32) *FlakyService {
+	return &FlakyService{failures: failures, done: make(chan struct{})}
+}
+func (s *FlakyService) Run() {
+	if atomic.AddInt32(&s.runs, 1) <= s.failures {
+		panic("flaky")
+	}
+	<-s.done
+}
+func (s *FlakyService) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.done) })
+	return nil
+}
+
+type ExitingService struct {
+	runs int32
+}
+
+func (s *ExitingService) Run() {
+	atomic.AddInt32(&s.runs, 1)
+}
+func (s *ExitingService) Stop(ctx context.Context) error {
+	return nil
+}
+
+func newTestSupervisor(policies map[string]RestartPolicy) (*ServiceSupervisor, chan *StopEvent) {
+	events := make(chan *StopEvent, 1)
+	factory := logger.NewDefaultLoggerFactory()
+	factory.Initialize("Test", true)
+	return NewServiceSupervisor(factory.GetLogger("ServiceSupervisor"), policies, func(event *StopEvent) { events <- event }), events
+}
+
+func waitFor(t *testing.T, condition func() bool, message string) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf(message)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestServiceSupervisor_on_failure(t *testing.T) {
+	supervisor, events := newTestSupervisor(map[string]RestartPolicy{
+		"flaky": {Mode: RestartMode_OnFailure, InitialBackoff: time.Millisecond},
+	})
+	service := NewFlakyService(2)
+	supervisor.Run("flaky", service)
+	waitFor(t, func() bool { return atomic.LoadInt32(&service.runs) == 3 }, "failed service should be restarted")
+
+	supervisor.Stopping("flaky")
+	_ = service.Stop(context.Background())
+	time.Sleep(10 * time.Millisecond)
+	if runs := atomic.LoadInt32(&service.runs); runs != 3 {
+		t.Errorf("service stopped by host should not be restarted, runs: %d", runs)
+	}
+	if len(events) != 0 {
+		t.Errorf("restarted service should not be escalated")
+	}
+}
+
+func TestServiceSupervisor_max_restarts_escalate(t *testing.T) {
+	supervisor, events := newTestSupervisor(map[string]RestartPolicy{
+		"exiting": {Mode: RestartMode_Always, MaxRestarts: 2, InitialBackoff: time.Millisecond, Escalate: true},
+	})
+	service := &ExitingService{}
+	supervisor.Run("exiting", service)
+
+	select {
+	case event := <-events:
+		exit, ok := event.Data.(*ServiceExit)
+		if event.Type != EVENT_TYPE_SERVICE || !ok || exit.Service != "exiting" || exit.Restarts != 2 || exit.Error != nil {
+			t.Errorf("stop event is not expected: %v %+v", event.Type, event.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("service should be escalated after max restarts")
+	}
+	if runs := atomic.LoadInt32(&service.runs); runs != 3 {
+		t.Errorf("service should be restarted until max restarts, runs: %d", runs)
+	}
+}
+
+func TestServiceSupervisor_never(t *testing.T) {
+	supervisor, events := newTestSupervisor(map[string]RestartPolicy{
+		"escalated": {Mode: RestartMode_OnFailure, InitialBackoff: time.Millisecond, Escalate: true},
+	})
+	// panic is recovered, service without policy is never restarted
+	unlisted := NewFlakyService(1)
+	supervisor.Run("unlisted", unlisted)
+	// service completed is not restarted on failure only
+	completed := &ExitingService{}
+	supervisor.Run("escalated", completed)
+
+	select {
+	case event := <-events:
+		if exit := event.Data.(*ServiceExit); exit.Service != "escalated" || exit.Restarts != 0 {
+			t.Errorf("stop event is not expected: %+v", exit)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("completed service should be escalated")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if runs := atomic.LoadInt32(&unlisted.runs); runs != 1 {
+		t.Errorf("service should not be restarted by default, runs: %d", runs)
+	}
+}
+
+func TestRestartPolicy_backoff(t *testing.T) {
+	policy := RestartPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for restarts, backoff := range expected {
+		if actual := policy.backoff(restarts); actual != backoff {
+			t.Errorf("backoff of restart %d is not expected: %v", restarts, actual)
+		}
+	}
+	if backoff := (RestartPolicy{}).backoff(10); backoff != DefaultRestartMaxBackoff {
+		t.Errorf("backoff should be bounded by default max backoff: %v", backoff)
+	}
+}
+
+type CrashingService interface{ Service }
+
+func TestHost_service_escalation(t *testing.T) {
+	builder := NewDefaultHostBuilder()
+	UseService[CrashingService](builder, func() *FlakyService { return NewFlakyService(1) })
+	builder.SetRestartPolicy(GetServiceName(types.Get[CrashingService]()), RestartPolicy{Escalate: true})
+
+	var received *StopEvent
+	builder.ConfigureLifecycle(func(hostContext dep.Context, appLifecycle ApplicationLifecycle) {
+		appLifecycle.RegisterOnStopEvent(func(ctx dep.Context, event *StopEvent) bool {
+			received = event
+			return true
+		})
+	})
+	host := builder.Build()
+
+	done := make(chan struct{})
+	go func() {
+		host.Run()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		dep.GetComponent[AsyncAppRunner](host.GetComponentProvider()).SendStopSignal()
+		t.Fatalf("host should be shut down once the service is escalated")
+	}
+
+	if received == nil || received.Type != EVENT_TYPE_SERVICE {
+		t.Fatalf("OnStopEvent should be notified of the service event, actual: %v", received)
+	}
+	if exit := received.Data.(*ServiceExit); !strings.Contains(exit.Error.Error(), "panic: flaky") {
+		t.Errorf("error of the service is not expected: %v", exit.Error)
+	}
+}
+
+func TestHostBuilder_restart_policy_unknown_service(t *testing.T) {
+	defer test.AssertPanicContent(t, "restart policy is set for unknown service: Looper:none", "panic content is not expected")
+
+	NewDefaultHostBuilder().SetRestartPolicy(GetLooperName("none"), RestartPolicy{}).Build()
+}
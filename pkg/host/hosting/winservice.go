@@ -6,6 +6,7 @@
 package hosting
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -18,6 +19,7 @@ import (
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/debug"
 	"golang.org/x/sys/windows/svc/eventlog"
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/clock"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/dep"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/logger"
 	"goms.io/azureml/mir/mir-vmagent/pkg/host/types"
@@ -113,6 +115,9 @@ func (wsr *DefaultWinServiceRunner) SendStopSignal() {
 func (wsr *DefaultWinServiceRunner) Execute() {
 	// start the host lifecycle
 	wsr.host.Start()
+	if source, ok := wsr.host.(StopEventSource); ok {
+		go wsr.watchStopEvents(source.StopEvents())
+	}
 
 	// wait for stop signal from console
 	wsr.winSvc.ServiceMain()
@@ -122,6 +127,18 @@ func (wsr *DefaultWinServiceRunner) Execute() {
 	}
 }
 
+// stop the windows service once a stop event raised by host is accepted
+func (wsr *DefaultWinServiceRunner) watchStopEvents(stopEvents <-chan *StopEvent) {
+	for event := range stopEvents {
+		if wsr.host.OnStopEvent(event) {
+			wsr.logger.Infow("Stop event is accepted", "Type", event.Type.String(), "Data", event.Data)
+			wsr.winSvc.TriggerStop()
+			return
+		}
+		wsr.logger.Debugw("Stop event is ignored", "Type", event.Type.String(), "Data", event.Data)
+	}
+}
+
 func (wsr *DefaultWinServiceRunner) shutdownHost(timeoutInSec int) {
 	wsr.logger.Infof("shutting down services with timeout(sec): %v", timeoutInSec)
 	// shut down with timeout
@@ -204,6 +221,7 @@ type DefaultWinSVC struct {
 	acceptStopSignal      func(sig os.Signal) bool
 	onStartStopping       func(StopReason)
 	checkStatusProcessor  LoopProcessor
+	clock                 clock.Clock
 }
 
 func NewWinSVC(context dep.Context) *DefaultWinSVC {
@@ -218,6 +236,7 @@ func NewWinSVC(context dep.Context) *DefaultWinSVC {
 		shutdownChan:         make(chan bool),
 		stopped:              false,
 		checkStatusProcessor: nil,
+		clock:                dep.GetComponent[clock.Clock](context),
 	}
 }
 
@@ -467,7 +486,7 @@ func (ws *DefaultWinSVC) checkAndReportStoppingStatus(changes chan<- svc.Status)
 			break
 		}
 
-		time.Sleep(time.Duration(ws.config.StatusChecker.CheckIntervalInMS) * time.Millisecond)
+		ws.clock.Sleep(time.Duration(ws.config.StatusChecker.CheckIntervalInMS) * time.Millisecond)
 	}
 
 	checkpoint++
@@ -525,6 +544,9 @@ func (wslc *WinServiceLoopGlobalContext) LooperName() string {
 func (wslc *WinServiceLoopGlobalContext) GetLooperContext() ServiceContext {
 	return wslc.context
 }
+func (wslc *WinServiceLoopGlobalContext) Context() context.Context {
+	return context.Background()
+}
 
 func (wslc *WinServiceLoopGlobalContext) HasVariable(key string, localScope bool) bool {
 	return wslc.Variables.Exist(key)
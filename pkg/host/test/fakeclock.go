@@ -0,0 +1,135 @@
+package test
+
+import (
+	"sync"
+	"time"
+
+	"goms.io/azureml/mir/mir-vmagent/pkg/host/clock"
+)
+
+// clock whose time moves only by Advance, timers fire synchronously while advancing
+type FakeClock struct {
+	mutex   sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	timers  []*fakeTimer
+}
+
+func NewFakeClock(start time.Time) *FakeClock {
+	fc := &FakeClock{now: start}
+	fc.changed = sync.NewCond(&fc.mutex)
+	return fc
+}
+
+func (fc *FakeClock) Now() time.Time {
+	defer fc.mutex.Unlock()
+	fc.mutex.Lock()
+	return fc.now
+}
+func (fc *FakeClock) Since(t time.Time) time.Duration {
+	return fc.Now().Sub(t)
+}
+func (fc *FakeClock) Until(t time.Time) time.Duration {
+	return t.Sub(fc.Now())
+}
+func (fc *FakeClock) NewTimer(d time.Duration) clock.Timer {
+	ft := &fakeTimer{clock: fc, c: make(chan time.Time, 1)}
+	ft.Reset(d)
+	return ft
+}
+
+// block until the clock is advanced by d
+func (fc *FakeClock) Sleep(d time.Duration) {
+	<-fc.NewTimer(d).C()
+}
+
+// move time forward and fire timers due in order of their deadlines
+func (fc *FakeClock) Advance(d time.Duration) {
+	defer fc.mutex.Unlock()
+	fc.mutex.Lock()
+
+	target := fc.now.Add(d)
+	for {
+		var next *fakeTimer
+		for _, ft := range fc.timers {
+			if !ft.deadline.After(target) && (next == nil || ft.deadline.Before(next.deadline)) {
+				next = ft
+			}
+		}
+		if next == nil {
+			break
+		}
+		if next.deadline.After(fc.now) {
+			fc.now = next.deadline
+		}
+		fc.fire(next)
+	}
+	fc.now = target
+	fc.changed.Broadcast()
+}
+
+// block until count timers are waiting, e.g. loop is waiting for its next iteration
+func (fc *FakeClock) WaitForTimers(count int) {
+	defer fc.mutex.Unlock()
+	fc.mutex.Lock()
+	for len(fc.timers) < count {
+		fc.changed.Wait()
+	}
+}
+
+// number of timers waiting to fire
+func (fc *FakeClock) PendingTimers() int {
+	defer fc.mutex.Unlock()
+	fc.mutex.Lock()
+	return len(fc.timers)
+}
+
+// caller holds the mutex
+func (fc *FakeClock) fire(ft *fakeTimer) {
+	fc.remove(ft)
+	select {
+	case ft.c <- fc.now:
+	default:
+	}
+}
+
+// caller holds the mutex, return false if the timer is not waiting
+func (fc *FakeClock) remove(ft *fakeTimer) bool {
+	for i, pending := range fc.timers {
+		if pending == ft {
+			fc.timers = append(fc.timers[:i], fc.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	c        chan time.Time
+	deadline time.Time
+}
+
+func (ft *fakeTimer) C() <-chan time.Time {
+	return ft.c
+}
+func (ft *fakeTimer) Stop() bool {
+	defer ft.clock.mutex.Unlock()
+	ft.clock.mutex.Lock()
+	return ft.clock.remove(ft)
+}
+func (ft *fakeTimer) Reset(d time.Duration) bool {
+	fc := ft.clock
+	defer fc.mutex.Unlock()
+	fc.mutex.Lock()
+
+	active := fc.remove(ft)
+	ft.deadline = fc.now.Add(d)
+	if d <= 0 {
+		fc.fire(ft)
+	} else {
+		fc.timers = append(fc.timers, ft)
+	}
+	fc.changed.Broadcast()
+	return active
+}
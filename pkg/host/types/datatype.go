@@ -15,9 +15,11 @@ type DataType interface {
 	IsStruct() bool
 	IsPtr() bool
 	IsFunc() bool
+	IsSlice() bool
 
 	ElementType() DataType
 	PointerType() DataType
+	SliceType() DataType
 
 	CheckCompatible(interfaceType DataType) bool
 }
@@ -74,6 +76,10 @@ func (dt *DefaultDataType) IsFunc() bool {
 	return dt.rawType.Kind() == reflect.Func
 }
 
+func (dt *DefaultDataType) IsSlice() bool {
+	return dt.rawType.Kind() == reflect.Slice
+}
+
 func (dt *DefaultDataType) ElementType() DataType {
 	return &DefaultDataType{
 		rawType: dt.rawType.Elem(),
@@ -86,6 +92,12 @@ func (dt *DefaultDataType) PointerType() DataType {
 	}
 }
 
+func (dt *DefaultDataType) SliceType() DataType {
+	return &DefaultDataType{
+		rawType: reflect.SliceOf(dt.rawType),
+	}
+}
+
 func (dt *DefaultDataType) CheckCompatible(interfaceType DataType) bool {
 	return checkCompatible(dt.rawType, interfaceType.(*DefaultDataType).rawType)
 }
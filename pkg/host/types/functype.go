@@ -12,6 +12,9 @@ type FuncType interface {
 	Pkg() string
 	Name() string
 
+	GetNumOfInput() int
+	GetInput(index int) DataType
+
 	GetNumOfOutput() int
 	GetOutput(index int) DataType
 
@@ -44,6 +47,16 @@ func (dt *DefaultFuncType) Name() string {
 	return dt.rawType.Name()
 }
 
+func (ft *DefaultFuncType) GetNumOfInput() int {
+	return ft.rawType.NumIn()
+}
+func (ft *DefaultFuncType) GetInput(index int) DataType {
+	if ft.rawType.NumIn() <= index {
+		panic(fmt.Errorf("input index %d exceeded number of func inputs %d, %s", index, ft.rawType.NumIn(), ft.rawType.String()))
+	}
+	return from(ft.rawType.In(index))
+}
+
 func (ft *DefaultFuncType) GetNumOfOutput() int {
 	return ft.rawType.NumOut()
 }
@@ -417,6 +417,13 @@ func TestFuncType(t *testing.T) {
 	outNum := funcType.GetNumOfOutput()
 	fmt.Printf("output count: %v\n", outNum)
 
+	if funcType.GetNumOfInput() != 1 {
+		t.Errorf("input count of func type is not expected, %v", funcType.GetNumOfInput())
+	}
+	if funcType.GetInput(0).Key() != Get[int]().Key() {
+		t.Errorf("input type of func type is not expected, %v", funcType.GetInput(0).FullName())
+	}
+
 	outType := funcType.GetOutputType()
 	if outType.Key() != Of(new(TestStruct)).Key() {
 		t.Errorf("output type of func type is not expected, %v", outType.FullName())
@@ -455,6 +462,13 @@ func TestFuncOutputType_negative(t *testing.T) {
 
 }
 
+func TestFuncInputType_negative(t *testing.T) {
+	defer test.AssertPanicContent(t, "input index 1 exceeded number of func inputs 1, func(int) (*types.TestStruct, int)", "panic content is not expected")
+
+	funcType := GetFuncType(FuncMultiOutput)
+	_ = funcType.GetInput(1)
+}
+
 func TestFuncTypeAPI_negative(t *testing.T) {
 	defer test.AssertPanicContent(t, "arg func instance is nil", "panic content is not expected")
 
@@ -476,3 +490,84 @@ func TestFuncTypeAPI_negative2(t *testing.T) {
 		t.Error("type of nil interface should not return nil, raise panic instead")
 	}
 }
+
+func TestMakeFunc(t *testing.T) {
+	funcType := ToFuncType(Get[func(int) (TestInterface, error)]())
+	if funcType.GetNumOfInput() != 1 || funcType.GetNumOfOutput() != 2 {
+		t.Errorf("unexpected func type: %s", funcType.FullName())
+	}
+
+	inst := MakeFunc(funcType, func(inputs []interface{}) []interface{} {
+		if inputs[0].(int) > 0 {
+			return []interface{}{&TestStruct{value: inputs[0].(int)}, nil}
+		}
+		return []interface{}{nil, fmt.Errorf("invalid value")}
+	})
+	create := inst.(func(int) (TestInterface, error))
+
+	ti, err := create(1)
+	if err != nil || ti.(*TestStruct).value != 1 {
+		t.Errorf("unexpected outputs: %v, %v", ti, err)
+	}
+	ti, err = create(0)
+	if err == nil || ti != nil {
+		t.Errorf("nil output should be converted to zero value: %v, %v", ti, err)
+	}
+}
+
+func TestZero(t *testing.T) {
+	if Zero(Get[TestInterface]()) != nil {
+		t.Error("zero value of interface should be nil")
+	}
+	if Zero(Get[TestStruct]()).(TestStruct).value != 0 {
+		t.Error("unexpected zero value of struct")
+	}
+}
+
+func TestToFuncType_negative(t *testing.T) {
+	defer test.AssertPanicContent(t, "data type is not func: types.TestStruct", "panic content is not expected")
+
+	_ = ToFuncType(Get[TestStruct]())
+}
+
+func TestMakeSlice(t *testing.T) {
+	sliceType := Get[TestInterface]().SliceType()
+	if sliceType.Key() != Get[[]TestInterface]().Key() || !sliceType.IsSlice() || sliceType.ElementType().Key() != Get[TestInterface]().Key() {
+		t.Errorf("unexpected slice type: %s", sliceType.FullName())
+	}
+
+	items := MakeSlice(sliceType, []interface{}{&TestStruct{value: 1}, nil}).([]TestInterface)
+	if len(items) != 2 || items[0].(*TestStruct).value != 1 || items[1] != nil {
+		t.Errorf("unexpected slice items: %v", items)
+	}
+	if len(MakeSlice(sliceType, nil).([]TestInterface)) != 0 {
+		t.Error("empty slice expected")
+	}
+}
+
+func TestMakeSlice_negative(t *testing.T) {
+	defer test.AssertPanicContent(t, "data type is not slice: types.TestStruct", "panic content is not expected")
+
+	_ = MakeSlice(Get[TestStruct](), nil)
+}
+
+type TestTaggedStruct struct {
+	First  TestInterface `dep:""`
+	Second *TestStruct
+	hidden int
+}
+
+func TestStructFields(t *testing.T) {
+	fields := GetStructFields(Get[TestTaggedStruct]())
+	if len(fields) != 3 || fields[0].Name != "First" || fields[0].Tag.Get("dep") != "" || fields[1].Type.Key() != Get[*TestStruct]().Key() || fields[2].IsExported {
+		t.Errorf("unexpected struct fields: %v", fields)
+	}
+	if _, ok := fields[0].Tag.Lookup("dep"); !ok {
+		t.Error("tag of field should be kept")
+	}
+
+	inst := NewStruct(Get[TestTaggedStruct](), map[int]interface{}{0: &TestStruct{value: 1}, 1: nil}).(*TestTaggedStruct)
+	if inst.First.(*TestStruct).value != 1 || inst.Second != nil {
+		t.Errorf("unexpected struct instance: %v", inst)
+	}
+}
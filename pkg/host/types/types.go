@@ -58,3 +58,104 @@ func GetFuncType(instance interface{}) FuncType {
 func ToFunc(instance interface{}) Func {
 	return newFunc(getAndValidateFuncType(instance), instance)
 }
+
+// get func type from data type, panic if the data type is not func
+func ToFuncType(dataType DataType) FuncType {
+	if !dataType.IsFunc() {
+		panic(fmt.Errorf("data type is not func: %v", dataType.FullName()))
+	}
+	return newFuncType(dataType.(*DefaultDataType).rawType)
+}
+
+// create zero value of the data type, nil for interface, pointer and func types
+func Zero(dataType DataType) interface{} {
+	return reflect.Zero(dataType.(*DefaultDataType).rawType).Interface()
+}
+
+type FuncImpl func(inputs []interface{}) []interface{}
+
+// create func instance of the func type, nil output is converted to zero value of the output type
+func MakeFunc(funcType FuncType, impl FuncImpl) interface{} {
+	rawType := funcType.(*DefaultFuncType).rawType
+	funcValue := reflect.MakeFunc(rawType, func(args []reflect.Value) []reflect.Value {
+		inputs := make([]interface{}, 0, len(args))
+		for _, arg := range args {
+			inputs = append(inputs, arg.Interface())
+		}
+		outputs := impl(inputs)
+		if len(outputs) != rawType.NumOut() {
+			panic(fmt.Errorf("func %s should return %d outputs, actual: %d", rawType.String(), rawType.NumOut(), len(outputs)))
+		}
+		results := make([]reflect.Value, 0, len(outputs))
+		for index, out := range outputs {
+			if out == nil {
+				results = append(results, reflect.Zero(rawType.Out(index)))
+			} else {
+				results = append(results, reflect.ValueOf(out))
+			}
+		}
+		return results
+	})
+	return funcValue.Interface()
+}
+
+// create slice of the slice type with items, nil item is converted to zero value of the element type
+func MakeSlice(sliceType DataType, items []interface{}) interface{} {
+	rawType := sliceType.(*DefaultDataType).rawType
+	if rawType.Kind() != reflect.Slice {
+		panic(fmt.Errorf("data type is not slice: %v", rawType.String()))
+	}
+	slice := reflect.MakeSlice(rawType, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			slice = reflect.Append(slice, reflect.Zero(rawType.Elem()))
+		} else {
+			slice = reflect.Append(slice, reflect.ValueOf(item))
+		}
+	}
+	return slice.Interface()
+}
+
+// field of struct type
+type StructField struct {
+	Name       string
+	Index      int
+	Type       DataType
+	Tag        reflect.StructTag
+	IsExported bool
+}
+
+// get fields of struct type in declaration order, panic if the data type is not struct
+func GetStructFields(structType DataType) []StructField {
+	rawType := structType.(*DefaultDataType).rawType
+	if rawType.Kind() != reflect.Struct {
+		panic(fmt.Errorf("data type is not struct: %v", rawType.String()))
+	}
+	fields := make([]StructField, 0, rawType.NumField())
+	for i := 0; i < rawType.NumField(); i++ {
+		field := rawType.Field(i)
+		fields = append(fields, StructField{
+			Name:       field.Name,
+			Index:      i,
+			Type:       from(field.Type),
+			Tag:        field.Tag,
+			IsExported: field.IsExported(),
+		})
+	}
+	return fields
+}
+
+// create pointer to struct of the struct type with exported fields set by index, nil value is kept as zero value
+func NewStruct(structType DataType, values map[int]interface{}) interface{} {
+	rawType := structType.(*DefaultDataType).rawType
+	if rawType.Kind() != reflect.Struct {
+		panic(fmt.Errorf("data type is not struct: %v", rawType.String()))
+	}
+	instance := reflect.New(rawType)
+	for index, value := range values {
+		if value != nil {
+			instance.Elem().Field(index).Set(reflect.ValueOf(value))
+		}
+	}
+	return instance.Interface()
+}